@@ -30,6 +30,7 @@ var (
 	EthSetting              *EthSettingS
 	ChatSetting             *ChatSettingS
 	PointSetting            *PointSettingS
+	PointFakeSetting        *PointFakeSettingS
 	NotifySetting           *NotifySettingS
 )
 
@@ -66,6 +67,7 @@ func setupSetting(suite []string, noDefault bool, configPath ...string) error {
 		"Eth":              &EthSetting,
 		"Chat":             &ChatSetting,
 		"Point":            &PointSetting,
+		"PointFake":        &PointFakeSetting,
 		"Notify":           &NotifySetting,
 	}
 	if err = setting.Unmarshal(objects); err != nil {
@@ -79,6 +81,66 @@ func setupSetting(suite []string, noDefault bool, configPath ...string) error {
 	SimpleCacheIndexSetting.ExpireTickDuration *= time.Second
 	BigCacheIndexSetting.ExpireInSecond *= time.Second
 	ExternalAppSetting.RedPacketTimeout *= time.Second
+	ExternalAppSetting.SubscribeCheckInterval *= time.Second
+	ExternalAppSetting.SubscribeRenewAhead *= time.Second
+	ExternalAppSetting.SubscribeRefundWindow *= time.Second
+	ExternalAppSetting.DaoStatsInterval *= time.Second
+	ExternalAppSetting.DaoRestoreWindow *= time.Second
+	ExternalAppSetting.DaoPreviewTTL *= time.Second
+	ExternalAppSetting.AlwaysTopDuration *= time.Second
+	if ExternalAppSetting.SubscribeCheckInterval <= 0 {
+		ExternalAppSetting.SubscribeCheckInterval = 10 * time.Minute
+	}
+	if ExternalAppSetting.SubscribeRenewAhead <= 0 {
+		ExternalAppSetting.SubscribeRenewAhead = 24 * time.Hour
+	}
+	if ExternalAppSetting.SubscribeRefundWindow <= 0 {
+		ExternalAppSetting.SubscribeRefundWindow = 7 * 24 * time.Hour
+	}
+	if ExternalAppSetting.DaoStatsInterval <= 0 {
+		ExternalAppSetting.DaoStatsInterval = time.Hour
+	}
+	if ExternalAppSetting.DaoRestoreWindow <= 0 {
+		ExternalAppSetting.DaoRestoreWindow = 30 * 24 * time.Hour
+	}
+	if ExternalAppSetting.DaoPreviewTTL <= 0 {
+		ExternalAppSetting.DaoPreviewTTL = 24 * time.Hour
+	}
+	if ExternalAppSetting.AlwaysTopDuration <= 0 {
+		ExternalAppSetting.AlwaysTopDuration = 24 * time.Hour
+	}
+	if ExternalAppSetting.DaoPinLimit <= 0 {
+		ExternalAppSetting.DaoPinLimit = 3
+	}
+	EthSetting.GateCacheTTL *= time.Second
+	if EthSetting.GateCacheTTL <= 0 {
+		EthSetting.GateCacheTTL = 5 * time.Minute
+	}
+	if NotifySetting == nil {
+		NotifySetting = &NotifySettingS{}
+	}
+	if NotifySetting.FanoutBatch <= 0 {
+		NotifySetting.FanoutBatch = 500
+	}
+	if PointFakeSetting == nil {
+		PointFakeSetting = &PointFakeSettingS{}
+	}
+	PointFakeSetting.Delay *= time.Millisecond
+	PointSetting.SignTolerance *= time.Second
+	PointSetting.NotifyTimeout *= time.Second
+	PointSetting.ReconcileInterval *= time.Second
+	if PointSetting.SignTolerance <= 0 {
+		PointSetting.SignTolerance = 5 * time.Minute
+	}
+	if PointSetting.NotifyTimeout <= 0 {
+		PointSetting.NotifyTimeout = 30 * time.Second
+	}
+	if PointSetting.ReconcileInterval <= 0 {
+		PointSetting.ReconcileInterval = time.Minute
+	}
+	if PointSetting.ReconcileMaxAttempts <= 0 {
+		PointSetting.ReconcileMaxAttempts = 10
+	}
 
 	return nil
 }
@@ -89,9 +151,17 @@ func Initialize(suite []string, noDefault bool, configPath ...string) {
 		log.Fatalf("init.setupSetting err: %v", err)
 	}
 
-	CheckSetting(PointSetting, "gateway", "callback")
-	CheckSetting(NotifySetting, "gateway")
-	CheckSetting(ChatSetting, "appid", "region", "apikey")
+	if CfgIf("PointFake") {
+		CheckSetting(PointSetting, "callback", "secret")
+	} else {
+		CheckSetting(PointSetting, "gateway", "callback", "secret")
+	}
+	if !CfgIf("LocalNotify") {
+		CheckSetting(NotifySetting, "gateway")
+	}
+	if !CfgIf("LocalChat") {
+		CheckSetting(ChatSetting, "appid", "region", "apikey")
+	}
 	CheckSetting(EthSetting, "endpoint")
 
 	// set default timezone
@@ -66,6 +66,22 @@ type ExternalAppSettingS struct {
 	RedPacketTimeout   time.Duration
 	RedPacketMaxCount  int64
 	AlwaysTopAddresses []string
+	// AlwaysTopDuration the new posts of the AlwaysTopAddresses are pinned on the platform for
+	AlwaysTopDuration time.Duration
+	// DaoPinLimit the most posts pinned on the page of a DAO at once
+	DaoPinLimit int
+	// SubscribeCheckInterval between expiring and renewing DAO subscriptions
+	SubscribeCheckInterval time.Duration
+	// SubscribeRenewAhead renew the auto renew subscriptions expiring within
+	SubscribeRenewAhead time.Duration
+	// SubscribeRefundWindow a subscription can be refunded within after its order
+	SubscribeRefundWindow time.Duration
+	// DaoStatsInterval between the rollups of the DAO analytics
+	DaoStatsInterval time.Duration
+	// DaoRestoreWindow a deleted DAO can be restored within after its deletion
+	DaoRestoreWindow time.Duration
+	// DaoPreviewTTL the home page preview of a DAO is fetched again after
+	DaoPreviewTTL time.Duration
 }
 
 type CacheIndexSettingS struct {
@@ -105,6 +121,8 @@ type FeaturesSettingS struct {
 
 type EthSettingS struct {
 	Endpoint string
+	// GateCacheTTL how long a token balance read for the gated content is trusted
+	GateCacheTTL time.Duration
 }
 
 type ChatSettingS struct {
@@ -114,13 +132,26 @@ type ChatSettingS struct {
 }
 
 type PointSettingS struct {
-	Gateway   string
-	WhiteList []string
-	Callback  string
+	Gateway              string
+	WhiteList            []string
+	Callback             string
+	Secret               string
+	SignTolerance        time.Duration
+	NotifyTimeout        time.Duration
+	ReconcileInterval    time.Duration
+	ReconcileMaxAttempts int
+}
+
+type PointFakeSettingS struct {
+	Status      string
+	Channels    map[string]string
+	Delay       time.Duration
+	InitBalance string
 }
 
 type NotifySettingS struct {
-	Gateway string
+	Gateway     string
+	FanoutBatch int
 }
 
 type TweetSearchS struct {
@@ -3,6 +3,7 @@ package core
 import (
 	"favor-dao-backend/internal/model"
 	"favor-dao-backend/pkg/types"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 const (
@@ -31,6 +32,10 @@ const (
 	ActVisibleTweet
 	ActDeleteTweet
 	ActCreateActivationCode
+	ActEditDao
+	ActKickChatMember
+	ActDeleteComment
+	ActApproveJoin
 )
 
 type act uint8
@@ -40,6 +45,8 @@ type FriendFilter map[string]types.Empty
 type Action struct {
 	Act         act
 	UserAddress string
+	// DaoID the action is checked against the roles of the DAO when set
+	DaoID primitive.ObjectID
 }
 
 type AuthorizationManageService interface {
@@ -55,6 +62,42 @@ func (f FriendFilter) IsFriend(userAddress string) bool {
 	return true
 }
 
+// DaoPermission the permission of the DAO which grants the act
+func (a act) DaoPermission() (model.DaoPermission, bool) {
+	switch a {
+	case ActCreatePublicTweet,
+		ActCreatePublicAttachment,
+		ActCreatePublicPicture,
+		ActCreatePublicVideo,
+		ActCreatePrivateTweet,
+		ActCreatePrivateAttachment,
+		ActCreatePrivatePicture,
+		ActCreatePrivateVideo,
+		ActCreateFriendTweet,
+		ActCreateFriendAttachment,
+		ActCreateFriendPicture,
+		ActCreateFriendVideo,
+		ActVisibleTweet:
+		return model.DaoPermPost, true
+	case ActStickTweet, ActTopTweet:
+		return model.DaoPermPin, true
+	case ActEditDao:
+		return model.DaoPermSettings, true
+	case ActKickChatMember:
+		return model.DaoPermKickChat, true
+	case ActDeleteComment:
+		return model.DaoPermDeleteComment, true
+	case ActApproveJoin:
+		return model.DaoPermApproveJoin, true
+	}
+	return "", false
+}
+
+// IsCreate the act creates a post or a comment
+func (a act) IsCreate() bool {
+	return a >= ActCreatePublicTweet && a <= ActCreatePrivatePicureComment
+}
+
 func (a act) IsAllow(user *model.User, userAddress string, isFriend bool, isSubscribe bool) bool {
 	if user.Address == userAddress && isSubscribe {
 		switch a {
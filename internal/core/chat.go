@@ -0,0 +1,48 @@
+package core
+
+import (
+	"context"
+)
+
+type ChatGroup struct {
+	GID          string   `json:"guid"`
+	Name         string   `json:"name"`
+	Type         string   `json:"type"`
+	Icon         string   `json:"icon,omitempty"`
+	Desc         string   `json:"description,omitempty"`
+	Owner        string   `json:"owner"`
+	Tags         []string `json:"tags,omitempty"`
+	MembersCount int      `json:"membersCount"`
+	JoinedAt     int      `json:"joinedAt,omitempty"`
+	HasJoined    bool     `json:"hasJoined,omitempty"`
+	CreatedAt    int      `json:"createdAt"`
+	UpdatedAt    int      `json:"updatedAt,omitempty"`
+}
+
+// ChatService chat backend of the users and the DAO groups
+type ChatService interface {
+	CreateUser(ctx context.Context, address, name, avatar string) error
+	UpdateUser(ctx context.Context, address, name, avatar string) error
+	// DeleteUser succeed if the user does not exist
+	DeleteUser(ctx context.Context, address string) error
+	// AuthToken get or create the auth token of the user, it is also the session token
+	AuthToken(ctx context.Context, address string) (string, error)
+	// RevokeAuthTokens delete all auth tokens of the user and return them
+	RevokeAuthTokens(ctx context.Context, address string) ([]string, error)
+
+	GroupID(daoID string) string
+	CreateGroup(ctx context.Context, address, daoID, name, icon, desc string) (string, error)
+	UpdateGroup(ctx context.Context, address, daoID, name, icon, desc string) error
+	// DeleteGroup succeed if the group does not exist
+	DeleteGroup(ctx context.Context, daoID string) error
+	ListGroups(ctx context.Context, dao *Dao, page, perPage int) ([]*ChatGroup, error)
+
+	// JoinGroup and LeaveGroup are performed by the user of the auth token
+	JoinGroup(ctx context.Context, daoID, token string) (string, error)
+	LeaveGroup(ctx context.Context, daoID, token string) (string, error)
+	KickGroupMember(ctx context.Context, daoID, address string) (string, error)
+	// TransferGroup make the address the owner of the group, it joins the group if not yet
+	TransferGroup(ctx context.Context, daoID, address string) error
+	// FreezeGroup mark the group read only or writable again, joining the frozen group is refused by us
+	FreezeGroup(ctx context.Context, daoID string, frozen bool) error
+}
@@ -24,9 +24,12 @@ type DaoManageService interface {
 	GetDaoCount(conditions model.ConditionsT) (int64, error)
 	GetDaoList(conditions model.ConditionsT, offset, limit int) ([]*model.Dao, error)
 	RealDeleteDAO(address string, chatAction func(context.Context, *model.Dao) (string, error)) error
+	// TransferDao move the DAO to the recipient of the pending transfer, it returns the posts changed
+	TransferDao(transfer *model.DaoTransfer, chatAction func(context.Context, *model.Dao) error) ([]primitive.ObjectID, error)
 	IsJoinedDAO(address string, daoID primitive.ObjectID) bool
 	IsSubscribeDAO(address string, daoID primitive.ObjectID) bool
-	SubscribeDAO(address string, daoID primitive.ObjectID, fn func(ctx context.Context, orderID string, dao *model.Dao) error) error
+	GetSubscribeTier(address string, daoID primitive.ObjectID) model.PostMemberT
+	SubscribeDAO(sub *model.DaoSubscribe, fn func(ctx context.Context, orderID string, dao *model.Dao) error) error
 	UpdateSubscribeDAO(orderID, txID string, status model.DaoSubscribeT) error
 	UpdateSubscribeDAOTxID(orderID, txID string) error
 }
@@ -1,6 +1,9 @@
 package core
 
-import "favor-dao-backend/pkg/types"
+import (
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/types"
+)
 
 type (
 	SearchType string
@@ -15,6 +18,12 @@ type (
 		Sort         types.AnySlice
 		BlockPostIDs []string
 		BlockDaoIDs  []string
+		// Viewer the address of the searcher, empty for the guests
+		Viewer string
+		// ShadowAddresses the shadow-banned authors, only the viewer sees its own content
+		ShadowAddresses []string
+		// Pins the scope of the pins sorted on top, the platform pins by default
+		Pins model.PinScope
 	}
 
 	QueryResp struct {
@@ -20,14 +20,17 @@ type TweetService interface {
 	GetUserPostCollectionCount(address string) (int64, error)
 	GetPostContentsByIDs(ids []primitive.ObjectID) ([]*model.PostContent, error)
 	GetPostContentByID(id primitive.ObjectID) ([]*model.PostContent, error)
+	CountDaoPins(daoID primitive.ObjectID) (int64, error)
 }
 
 type TweetManageService interface {
 	CreatePost(post *model.Post, contents []*model.PostContent) (*model.Post, error)
 	DeletePost(post *model.Post) ([]string, []primitive.ObjectID, error)
-	StickPost(post *model.Post) error
+	PinPost(post *model.Post, scope model.PinScope, pinned bool, expiredOn int64) error
 	VisiblePost(post *model.Post, visibility model.PostVisibleT) error
 	UpdatePost(post *model.Post) error
+	EditPost(post *model.Post, contents []*model.PostContent, revision *model.PostRevision) error
+	PublishPost(post *model.Post) error
 	CreatePostStar(postID primitive.ObjectID, address string) (*model.PostStar, error)
 	DeletePostStar(p *model.PostStar) error
 	CreatePostCollection(postID primitive.ObjectID, address string) (*model.PostCollection, error)
@@ -22,6 +22,7 @@ const (
 	DaoSubscribeFailed  = model.DaoSubscribeFailed
 	DaoSubscribeSubmit  = model.DaoSubscribeSubmit
 	DaoSubscribeRefund  = model.DaoSubscribeRefund
+	DaoSubscribeExpired = model.DaoSubscribeExpired
 )
 
 var (
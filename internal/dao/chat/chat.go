@@ -0,0 +1,24 @@
+package chat
+
+import (
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/core"
+	"favor-dao-backend/pkg/comet"
+)
+
+func NewCometChatService() (core.ChatService, core.VersionInfo) {
+	s := conf.ChatSetting
+	obj := &cometChatServant{
+		chat:   comet.New(s.AppId, s.Region, s.ApiKey),
+		appId:  s.AppId,
+		region: s.Region,
+	}
+	return obj, obj
+}
+
+func NewLocalChatService() (core.ChatService, core.VersionInfo) {
+	obj := &localChatServant{
+		rdb: conf.Redis,
+	}
+	return obj, obj
+}
@@ -0,0 +1,372 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/core"
+	"favor-dao-backend/pkg/comet"
+	"github.com/Masterminds/semver/v3"
+	"github.com/cespare/xxhash/v2"
+)
+
+var (
+	_ core.ChatService = (*cometChatServant)(nil)
+	_ core.VersionInfo = (*cometChatServant)(nil)
+)
+
+type cometChatServant struct {
+	chat   *comet.ChatGateway
+	appId  string
+	region string
+}
+
+func formatValidUrl(s string) string {
+	return fmt.Sprintf("http://%s", strings.TrimPrefix(s, "http://"))
+}
+
+func genId(id string) string {
+	return strconv.FormatUint(
+		xxhash.Sum64String(fmt.Sprintf("%s-%d-%s", conf.ExternalAppSetting.Region, conf.ExternalAppSetting.NetworkID, id)),
+		10,
+	)
+}
+
+func userId(address string) string {
+	return genId(strings.TrimPrefix(address, "0x"))
+}
+
+func groupId(id string) string {
+	return genId(fmt.Sprintf("group_%s", id))
+}
+
+func networkTag() string {
+	return fmt.Sprintf("net_%d", conf.ExternalAppSetting.NetworkID)
+}
+
+func regionTag() string {
+	return fmt.Sprintf("region_%s", conf.ExternalAppSetting.Region)
+}
+
+func (s *cometChatServant) CreateUser(ctx context.Context, address, name, avatar string) error {
+	uid := userId(address)
+
+	_, err := s.chat.Scoped().Context(ctx).Users().Get(uid)
+	if err != nil {
+		switch e := err.(type) {
+		case comet.RestApiError:
+			if e.Inner.Code == "ERR_UID_NOT_FOUND" {
+				_, err := s.chat.Scoped().Context(ctx).Users().Create(uid, name, &comet.UserCreateOption{
+					Tags:        []string{regionTag(), networkTag()},
+					Avatar:      formatValidUrl(avatar),
+					ReturnToken: false,
+				})
+				if err != nil {
+					return err
+				}
+
+				return nil
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (s *cometChatServant) UpdateUser(ctx context.Context, address, name, avatar string) error {
+	uid := userId(address)
+
+	_, err := s.chat.Scoped().Context(ctx).Users().Update(uid, comet.UserUpdateOption{
+		Tags:   []string{regionTag(), networkTag()},
+		Name:   name,
+		Avatar: formatValidUrl(avatar),
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *cometChatServant) DeleteUser(ctx context.Context, address string) error {
+	uid := userId(address)
+
+	err := s.chat.Scoped().Context(ctx).Users().Delete(uid)
+	if err != nil {
+		switch e := err.(type) {
+		case comet.RestApiError:
+			if e.Inner.Code == "ERR_UID_NOT_FOUND" {
+				return nil
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *cometChatServant) AuthToken(ctx context.Context, address string) (string, error) {
+	uid := userId(address)
+
+	tokens, err := s.chat.Scoped().Context(ctx).Users().AuthToken(uid).List()
+	if err != nil {
+		return "", err
+	}
+
+	if len(tokens) == 0 {
+		token, err := s.chat.Scoped().Context(ctx).Users().AuthToken(uid).Create(nil)
+		if err != nil {
+			return "", err
+		}
+
+		return token.AuthToken, nil
+	}
+
+	return tokens[0].AuthToken, nil
+}
+
+func (s *cometChatServant) RevokeAuthTokens(ctx context.Context, address string) ([]string, error) {
+	uid := userId(address)
+
+	tokens, err := s.chat.Scoped().Context(ctx).Users().AuthToken(uid).List()
+	if err != nil {
+		return nil, err
+	}
+
+	revoked := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		_, err = s.chat.Scoped().Context(ctx).Users().AuthToken(uid).Delete(token.AuthToken)
+		if err != nil {
+			return revoked, err
+		}
+		revoked = append(revoked, token.AuthToken)
+	}
+
+	return revoked, nil
+}
+
+func (s *cometChatServant) GroupID(daoID string) string {
+	return groupId(daoID)
+}
+
+func (s *cometChatServant) CreateGroup(ctx context.Context, address, daoID, name, icon, desc string) (string, error) {
+	uid := userId(address)
+	gid := groupId(daoID)
+
+	_, err := s.chat.Scoped().Context(ctx).Perform(uid).Groups().Create(gid, name, comet.PublicGroup, &comet.GroupCreateOption{
+		Owner: address,
+		Icon:  formatValidUrl(icon),
+		Desc:  desc,
+		Tags: []string{
+			regionTag(),
+			networkTag(),
+			fmt.Sprintf("DAO%s", daoID),
+		},
+	})
+	if err != nil {
+		return gid, err
+	}
+
+	return gid, nil
+}
+
+func (s *cometChatServant) UpdateGroup(ctx context.Context, address, daoID, name, icon, desc string) error {
+	uid := userId(address)
+	gid := groupId(daoID)
+
+	_, err := s.chat.Scoped().Context(ctx).Perform(uid).Groups().Update(gid, comet.GroupUpdateOption{
+		Name: name,
+		Icon: formatValidUrl(icon),
+		Desc: desc,
+		Tags: []string{
+			regionTag(),
+			networkTag(),
+			fmt.Sprintf("DAO%s", daoID),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *cometChatServant) DeleteGroup(ctx context.Context, daoID string) (err error) {
+	gid := groupId(daoID)
+	_, err = s.chat.Scoped().Context(ctx).Groups().Delete(gid)
+	if err != nil {
+		switch e := err.(type) {
+		case comet.RestApiError:
+			if e.Inner.Code == "ERR_GUID_NOT_FOUND" {
+				return nil
+			}
+		}
+		return
+	}
+	return
+}
+
+func (s *cometChatServant) ListGroups(ctx context.Context, dao *core.Dao, page, perPage int) ([]*core.ChatGroup, error) {
+	uid := userId(dao.Address)
+
+	// TODO make sure return sames with logged list in database
+	groups, err := s.chat.Scoped().Context(ctx).Perform(uid).Groups().List(comet.GroupListOption{
+		Tags:      []string{regionTag(), networkTag(), fmt.Sprintf("DAO%s", dao.Name)},
+		Type:      "public",
+		HasJoined: true,
+		SortBy:    "createdAt",
+		SortOrder: "desc",
+		Page:      page,
+		PerPage:   perPage,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*core.ChatGroup, 0, len(groups))
+	for _, g := range groups {
+		list = append(list, &core.ChatGroup{
+			GID:          g.GID,
+			Name:         g.Name,
+			Type:         string(g.Type),
+			Icon:         g.Icon,
+			Desc:         g.Desc,
+			Owner:        g.Owner,
+			Tags:         g.Tags,
+			MembersCount: g.MembersCount,
+			JoinedAt:     g.JoinedAt,
+			HasJoined:    g.HasJoined,
+			CreatedAt:    g.CreatedAt,
+			UpdatedAt:    g.UpdatedAt,
+		})
+	}
+	return list, nil
+}
+
+func (s *cometChatServant) JoinGroup(ctx context.Context, daoID, token string) (string, error) {
+	return s.joinOrLeaveGroup(ctx, daoID, true, token)
+}
+
+func (s *cometChatServant) LeaveGroup(ctx context.Context, daoID, token string) (string, error) {
+	return s.joinOrLeaveGroup(ctx, daoID, false, token)
+}
+
+// joinOrLeaveGroup is performed by the user, so it calls the client api with the auth token of the user
+func (s *cometChatServant) joinOrLeaveGroup(ctx context.Context, daoID string, joinOrLeave bool, token string) (string, error) {
+	gid := groupId(daoID)
+
+	url := fmt.Sprintf("https://%s.apiclient-%s.cometchat.io/v3/groups/%s/members", s.appId, s.region, gid)
+
+	method := http.MethodDelete
+	if joinOrLeave {
+		// TODO join with password
+		method = http.MethodPost
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return gid, err
+	}
+
+	req.Header.Set("authtoken", token)
+	req.Header.Set("appid", s.appId)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return gid, err
+	}
+
+	defer func() {
+		if resp.Body != nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	if resp.StatusCode >= 300 {
+		errBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return gid, err
+		}
+
+		// parse restful error
+		var restErr comet.RestApiError
+		err = json.Unmarshal(errBody, &restErr)
+		if err == nil {
+			if joinOrLeave && restErr.Inner.Code == "ERR_ALREADY_JOINED" {
+				return gid, nil
+			} else if !joinOrLeave && restErr.Inner.Code == "ERR_GROUP_NOT_JOINED" {
+				return gid, nil
+			}
+
+			return gid, restErr
+		}
+
+		var apiErr comet.ApiError
+		err = json.Unmarshal(errBody, &apiErr)
+		if err == nil {
+			return gid, apiErr
+		}
+
+		return gid, fmt.Errorf("operate group member(%d): %s", resp.StatusCode, string(errBody))
+	}
+
+	return gid, nil
+}
+
+func (s *cometChatServant) KickGroupMember(ctx context.Context, daoID, address string) (gid string, err error) {
+	uid := userId(address)
+	gid = groupId(daoID)
+	_, err = s.chat.Scoped().Context(ctx).Groups().Members(gid).Kick(uid)
+	if err != nil {
+		switch e := err.(type) {
+		case comet.RestApiError:
+			if e.Inner.Code == "ERR_UID_NOT_FOUND" || e.Inner.Code == "ERR_GUID_NOT_FOUND" {
+				err = nil
+				return
+			}
+		}
+		return
+	}
+	return
+}
+
+func (s *cometChatServant) TransferGroup(ctx context.Context, daoID, address string) error {
+	uid := userId(address)
+	gid := groupId(daoID)
+
+	// the owner must be a member of the group
+	_, err := s.chat.Scoped().Context(ctx).Groups().Members(gid).Add(comet.GroupMemberOption{
+		Admins: []string{uid},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.chat.Scoped().Context(ctx).Groups().Update(gid, comet.GroupUpdateOption{
+		Owner: uid,
+	})
+	return err
+}
+
+func (s *cometChatServant) FreezeGroup(ctx context.Context, daoID string, frozen bool) error {
+	gid := groupId(daoID)
+	// the clients disable sending in the frozen group by its metadata
+	_, err := s.chat.Scoped().Context(ctx).Groups().Update(gid, comet.GroupUpdateOption{
+		Metadata: map[string]string{"frozen": strconv.FormatBool(frozen)},
+	})
+	return err
+}
+
+func (s *cometChatServant) Name() string {
+	return "CometChat"
+}
+
+func (s *cometChatServant) Version() *semver.Version {
+	return semver.MustParse("v0.1.0")
+}
@@ -0,0 +1,116 @@
+package chat
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"favor-dao-backend/internal/core"
+	"github.com/Masterminds/semver/v3"
+	"github.com/redis/go-redis/v9"
+)
+
+// prefixRedisKeyTokens the set of the session tokens issued to an address
+const prefixRedisKeyTokens = "chat_tokens_"
+
+var (
+	_ core.ChatService = (*localChatServant)(nil)
+	_ core.VersionInfo = (*localChatServant)(nil)
+)
+
+// localChatServant runs without a chat vendor, users and groups only live in our database
+// and the auth token is just a random session token. The tokens of each address are kept
+// in redis so they can be revoked.
+type localChatServant struct {
+	rdb redis.Cmdable
+}
+
+func (s *localChatServant) CreateUser(_ context.Context, _, _, _ string) error {
+	return nil
+}
+
+func (s *localChatServant) UpdateUser(_ context.Context, _, _, _ string) error {
+	return nil
+}
+
+func (s *localChatServant) DeleteUser(_ context.Context, _ string) error {
+	return nil
+}
+
+func (s *localChatServant) AuthToken(ctx context.Context, address string) (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	key := prefixRedisKeyTokens + address
+	pipe := s.rdb.TxPipeline()
+	pipe.SAdd(ctx, key, token)
+	// outlives the newest session
+	pipe.Expire(ctx, key, core.TokenExpiration)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *localChatServant) RevokeAuthTokens(ctx context.Context, address string) ([]string, error) {
+	key := prefixRedisKeyTokens + address
+	tokens, err := s.rdb.SMembers(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if err = s.rdb.Del(ctx, key).Err(); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (s *localChatServant) GroupID(daoID string) string {
+	return groupId(daoID)
+}
+
+func (s *localChatServant) CreateGroup(_ context.Context, _, daoID, _, _, _ string) (string, error) {
+	return groupId(daoID), nil
+}
+
+func (s *localChatServant) UpdateGroup(_ context.Context, _, _, _, _, _ string) error {
+	return nil
+}
+
+func (s *localChatServant) DeleteGroup(_ context.Context, _ string) error {
+	return nil
+}
+
+func (s *localChatServant) ListGroups(_ context.Context, _ *core.Dao, _, _ int) ([]*core.ChatGroup, error) {
+	return []*core.ChatGroup{}, nil
+}
+
+func (s *localChatServant) JoinGroup(_ context.Context, daoID, _ string) (string, error) {
+	return groupId(daoID), nil
+}
+
+func (s *localChatServant) LeaveGroup(_ context.Context, daoID, _ string) (string, error) {
+	return groupId(daoID), nil
+}
+
+func (s *localChatServant) KickGroupMember(_ context.Context, daoID, _ string) (string, error) {
+	return groupId(daoID), nil
+}
+
+func (s *localChatServant) TransferGroup(_ context.Context, _, _ string) error {
+	return nil
+}
+
+func (s *localChatServant) FreezeGroup(_ context.Context, _ string, _ bool) error {
+	return nil
+}
+
+func (s *localChatServant) Name() string {
+	return "LocalChat"
+}
+
+func (s *localChatServant) Version() *semver.Version {
+	return semver.MustParse("v0.1.0")
+}
@@ -5,16 +5,19 @@ import (
 
 	"favor-dao-backend/internal/conf"
 	"favor-dao-backend/internal/core"
+	"favor-dao-backend/internal/dao/chat"
 	"favor-dao-backend/internal/dao/monogo"
 	"favor-dao-backend/internal/dao/search"
 	"github.com/sirupsen/logrus"
 )
 
 var (
-	ts core.TweetSearchService
-	ds core.DataService
+	ts  core.TweetSearchService
+	ds  core.DataService
+	cs  core.ChatService
+	ams core.AuthorizationManageService
 
-	onceTs, onceDs sync.Once
+	onceTs, onceDs, onceCs, onceAms sync.Once
 )
 
 func DataService() core.DataService {
@@ -29,7 +32,7 @@ func DataService() core.DataService {
 func TweetSearchService() core.TweetSearchService {
 	onceTs.Do(func() {
 		var v core.VersionInfo
-		ams := newAuthorizationManageService()
+		ams := AuthorizationManageService()
 		if conf.CfgIf("Zinc") {
 			ts, v = search.NewZincTweetSearchService(ams)
 		} else if conf.CfgIf("Meili") {
@@ -45,6 +48,27 @@ func TweetSearchService() core.TweetSearchService {
 	return ts
 }
 
+func ChatService() core.ChatService {
+	onceCs.Do(func() {
+		var v core.VersionInfo
+		if conf.CfgIf("LocalChat") {
+			cs, v = chat.NewLocalChatService()
+		} else {
+			// default use CometChat as chat service
+			cs, v = chat.NewCometChatService()
+		}
+		logrus.Infof("use %s as chat service with version %s", v.Name(), v.Version())
+	})
+	return cs
+}
+
+func AuthorizationManageService() core.AuthorizationManageService {
+	onceAms.Do(func() {
+		ams = newAuthorizationManageService()
+	})
+	return ams
+}
+
 func newAuthorizationManageService() (s core.AuthorizationManageService) {
 	s = monogo.NewAuthorizationManageService()
 	return
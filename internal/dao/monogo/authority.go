@@ -1,8 +1,12 @@
 package monogo
 
 import (
+	"context"
+
 	"favor-dao-backend/internal/core"
 	"favor-dao-backend/internal/model"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -15,6 +19,11 @@ type authorizationManageServant struct {
 }
 
 func (s *authorizationManageServant) IsAllow(user *model.User, action *core.Action) bool {
+	if !action.DaoID.IsZero() {
+		if perm, ok := action.Act.DaoPermission(); ok {
+			return s.isDaoAllow(user.Address, action.DaoID, perm)
+		}
+	}
 	isFriend := s.isFriend(user.Address, action.UserAddress)
 	// TODO: just use defaut act authorization chek rule now
 	return action.Act.IsAllow(user, action.UserAddress, isFriend, true)
@@ -30,6 +39,20 @@ func (s *authorizationManageServant) BeFriendIds(userAddress string) ([]string,
 	return []string{}, nil
 }
 
+// isDaoAllow the role of the address in the DAO is granted the permission
+func (s *authorizationManageServant) isDaoAllow(address string, daoID primitive.ObjectID, perm model.DaoPermission) bool {
+	dao, err := (&model.Dao{ID: daoID}).Get(context.TODO(), s.db)
+	if err != nil {
+		return false
+	}
+	role, err := dao.RoleOf(context.TODO(), s.db, address)
+	if err != nil {
+		logrus.Errorf("dao.RoleOf dao_id:%s address:%s err:%s", daoID.Hex(), address, err)
+		return false
+	}
+	return dao.RoleAllow(role, perm)
+}
+
 func (s *authorizationManageServant) isFriend(userAddress, friendAddress string) bool {
 	// just true now
 	return true
@@ -13,6 +13,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var (
@@ -59,6 +60,10 @@ func (s *daoManageServant) CreateDao(dao *model.Dao, chatAction func(context.Con
 		if err != nil {
 			return err
 		}
+		err = (&model.DaoFollowEvent{DaoID: newDao.ID, Address: newDao.Address, Follow: true}).Create(ctx, s.db)
+		if err != nil {
+			return err
+		}
 		groupId, err := chatAction(ctx, newDao)
 		if err != nil {
 			return err
@@ -128,6 +133,7 @@ func (s *daoManageServant) GetMyDaoList(dao *model.Dao) (list []*model.DaoFormat
 	for k := range list {
 		list[k].IsJoined = true
 		list[k].IsSubscribed = true
+		list[k].SubscribedTier = model.PostMember3
 	}
 	return
 }
@@ -191,6 +197,13 @@ func (s *daoManageServant) CreateDaoFollow(myAddress string, daoID string, chatA
 	}
 
 	err = util.MongoTransaction(context.TODO(), s.db, func(ctx context.Context) error {
+		banned, err := (&model.DaoBan{DaoID: id, Address: myAddress}).IsBanned(ctx, s.db)
+		if err != nil {
+			return err
+		}
+		if banned {
+			return model.ErrDaoBanned
+		}
 		dao, err := (&model.Dao{ID: id, IsDel: 1}).Get(ctx, s.db)
 		if err != nil {
 			return err
@@ -211,6 +224,13 @@ func (s *daoManageServant) CreateDaoFollow(myAddress string, daoID string, chatA
 			out.DeletedOn = 0
 			err = out.Update(ctx, s.db)
 		}
+		if err != nil {
+			return err
+		}
+		err = (&model.DaoFollowEvent{DaoID: id, Address: myAddress, Follow: true}).Create(ctx, s.db)
+		if err != nil {
+			return err
+		}
 		groupId, err := chatAction(ctx, dao)
 		if err != nil {
 			return err
@@ -243,6 +263,10 @@ func (s *daoManageServant) DeleteDaoFollow(d *model.DaoBookmark, chatAction func
 		if err != nil {
 			return err
 		}
+		err = (&model.DaoFollowEvent{DaoID: d.DaoID, Address: d.Address}).Create(ctx, s.db)
+		if err != nil {
+			return err
+		}
 		groupId, err := chatAction(ctx, dao)
 		if err != nil {
 			return err
@@ -282,6 +306,78 @@ func (s *daoManageServant) RealDeleteDAO(address string, chatAction func(context
 	})
 }
 
+func (s *daoManageServant) TransferDao(transfer *model.DaoTransfer, chatAction func(context.Context, *model.Dao) error) ([]primitive.ObjectID, error) {
+	var postIDs []primitive.ObjectID
+	err := util.MongoTransaction(context.TODO(), s.db, func(ctx context.Context) error {
+		err := transfer.Finish(ctx, s.db, model.DaoTransferAccepted, transfer.To)
+		if err != nil {
+			return err
+		}
+		now := time.Now().Unix()
+		res, err := s.db.Collection(new(model.Dao).Table()).UpdateOne(ctx,
+			bson.M{"_id": transfer.DaoID, "address": transfer.From, "is_del": 0},
+			bson.M{"$set": bson.M{"address": transfer.To, "modified_on": now}},
+		)
+		if err != nil {
+			return err
+		}
+		if res.ModifiedCount == 0 {
+			return model.ErrDaoOwnerChanged
+		}
+		dao, err := (&model.Dao{ID: transfer.DaoID}).Get(ctx, s.db)
+		if err != nil {
+			return err
+		}
+
+		// the posts of the owner in the DAO and the retweets of them follow the DAO
+		filter := bson.M{"$or": []bson.M{
+			{"dao_id": transfer.DaoID, "address": transfer.From},
+			{"author_dao_id": transfer.DaoID, "author_id": transfer.From},
+		}}
+		posts := s.db.Collection(new(model.Post).Table())
+		cursor, err := posts.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+		if err != nil {
+			return err
+		}
+		var ids []struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err = cursor.All(ctx, &ids); err != nil {
+			return err
+		}
+		for _, v := range ids {
+			postIDs = append(postIDs, v.ID)
+		}
+		_, err = posts.UpdateMany(ctx,
+			bson.M{"dao_id": transfer.DaoID, "address": transfer.From},
+			bson.M{"$set": bson.M{"address": transfer.To}},
+		)
+		if err != nil {
+			return err
+		}
+		_, err = posts.UpdateMany(ctx,
+			bson.M{"author_dao_id": transfer.DaoID, "author_id": transfer.From},
+			bson.M{"$set": bson.M{"author_id": transfer.To}},
+		)
+		if err != nil {
+			return err
+		}
+
+		// the new owner holds no role, the old one stays as a plain member
+		_, err = s.db.Collection(new(model.DaoMember).Table()).DeleteOne(ctx, bson.M{"dao_id": transfer.DaoID, "address": transfer.To})
+		if err != nil {
+			return err
+		}
+
+		// both keep their chat_group rows, the recipient joined the DAO before
+		return chatAction(ctx, dao)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return postIDs, nil
+}
+
 func (s *daoManageServant) IsJoinedDAO(address string, daoID primitive.ObjectID) bool {
 	if address == "" {
 		return false
@@ -302,36 +398,39 @@ func (s *daoManageServant) IsJoinedDAO(address string, daoID primitive.ObjectID)
 }
 
 func (s *daoManageServant) IsSubscribeDAO(address string, daoID primitive.ObjectID) bool {
+	return s.GetSubscribeTier(address, daoID) != model.PostMemberNothing
+}
+
+func (s *daoManageServant) GetSubscribeTier(address string, daoID primitive.ObjectID) model.PostMemberT {
 	if address == "" {
-		return false
-	}
-	filter := bson.M{
-		"address": address,
-		"dao_id":  daoID,
-		"status":  model.DaoSubscribeSuccess,
-	}
-	res := s.db.Collection(new(model.DaoSubscribe).Table()).FindOne(context.TODO(), filter)
-	if errors.Is(res.Err(), mongo.ErrNoDocuments) {
-		return false
+		return model.PostMemberNothing
 	}
-	if res.Err() != nil {
-		return false
+	filter := model.ActiveSubscribeFilter(time.Now().Unix())
+	filter["address"] = address
+	filter["dao_id"] = daoID
+	tier := model.PostMemberNothing
+	for _, sub := range new(model.DaoSubscribe).FindList(context.TODO(), s.db, filter) {
+		if sub.ActiveTier() > tier {
+			tier = sub.ActiveTier()
+		}
 	}
-	return true
+	return tier
 }
 
-func (s *daoManageServant) SubscribeDAO(address string, daoID primitive.ObjectID, fn func(ctx context.Context, orderID string, dao *model.Dao) error) error {
+func (s *daoManageServant) SubscribeDAO(sub *model.DaoSubscribe, fn func(ctx context.Context, orderID string, dao *model.Dao) error) error {
 	return util.MongoTransaction(context.TODO(), s.db, func(ctx context.Context) error {
-		dao, err := (&model.Dao{ID: daoID}).Get(ctx, s.db)
+		dao, err := (&model.Dao{ID: sub.DaoID}).Get(ctx, s.db)
 		if err != nil {
 			return err
 		}
-		sub := &model.DaoSubscribe{
-			Address:   address,
-			DaoID:     daoID,
-			Status:    model.DaoSubscribeSubmit,
-			PayAmount: dao.Price,
+		tier, err := dao.FindTier(sub.Tier)
+		if err != nil {
+			return err
 		}
+		sub.Tier = tier.Level
+		sub.PayAmount = tier.Price
+		sub.Duration = tier.Days * 24 * 3600
+		sub.Status = model.DaoSubscribeSubmit
 		err = sub.Create(ctx, s.db)
 		if err != nil {
 			return err
@@ -348,20 +447,12 @@ func (s *daoManageServant) UpdateSubscribeDAO(orderID, txID string, status model
 	if err != nil {
 		return err
 	}
-	md := model.DaoSubscribe{}
-	filter := bson.M{
-		"_id": id,
-	}
-	update := bson.D{{"$set", bson.D{
-		{"modified_on", time.Now().Unix()},
-		{"tx_id", txID},
-		{"status", status},
-	}}}
-	res := s.db.Collection(md.Table()).FindOneAndUpdate(context.TODO(), filter, update)
-	if res.Err() != nil {
-		return res.Err()
+	sub := &model.DaoSubscribe{}
+	err = sub.FindOne(context.TODO(), s.db, bson.M{"_id": id})
+	if err != nil {
+		return err
 	}
-	return nil
+	return sub.Settle(context.TODO(), s.db, txID, status)
 }
 
 func (s *daoManageServant) UpdateSubscribeDAOTxID(orderID, txID string) error {
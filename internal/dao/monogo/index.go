@@ -1,6 +1,8 @@
 package monogo
 
 import (
+	"context"
+
 	"favor-dao-backend/internal/core"
 	"favor-dao-backend/internal/model"
 	"favor-dao-backend/internal/model/rest"
@@ -42,18 +44,18 @@ func newSimpleIndexPostsService(db *mongo.Database) core.IndexPostsService {
 
 // IndexPosts querying the list of square tweets according to userId, simply so that the home pages are different for different users.
 func (s *indexPostsServant) IndexPosts(user *model.User, offset int, limit int) (*rest.IndexTweetsResp, error) {
-	predicates := model.ConditionsT{
-		"ORDER": bson.M{"is_top": -1},
-	}
+	var query bson.M
 	if user == nil {
-		predicates["query"] = bson.M{"visibility": model.PostVisitPublic}
+		query = bson.M{"visibility": model.PostVisitPublic}
 		user = &model.User{}
 	} else {
-		predicates["query"] = bson.M{"visibility": model.PostVisitPublic,
-			"$or": bson.M{"visibility": model.PostVisitPrivate, "address": user.Address}}
+		query = bson.M{"$or": bson.A{
+			bson.M{"visibility": model.PostVisitPublic},
+			bson.M{"visibility": model.PostVisitPrivate, "address": user.Address},
+		}}
 	}
 
-	posts, err := (&model.Post{}).List(s.db, &predicates, offset, limit)
+	posts, err := (&model.Post{}).ListPinnedFirst(context.TODO(), s.db, query, model.PinPlatform, offset, limit)
 	if err != nil {
 		logrus.Debugf("gormIndexPostsServant.IndexPosts err: %v", err)
 		return nil, err
@@ -63,7 +65,7 @@ func (s *indexPostsServant) IndexPosts(user *model.User, offset int, limit int)
 		return nil, err
 	}
 
-	total, err := (&model.Post{}).Count(s.db, &predicates)
+	total, err := (&model.Post{}).Count(s.db, &model.ConditionsT{"query": query})
 	if err != nil {
 		return nil, err
 	}
@@ -76,12 +78,9 @@ func (s *indexPostsServant) IndexPosts(user *model.User, offset int, limit int)
 
 // IndexPosts simpleCacheIndexGetPosts simpleCacheIndex Proprietary get square tweet list function
 func (s *simpleIndexPostsServant) IndexPosts(user *model.User, offset int, limit int) (*rest.IndexTweetsResp, error) {
-	predicates := model.ConditionsT{
-		"query": bson.M{"visibility": model.PostVisitPublic},
-		"ORDER": bson.M{"is_top": -1},
-	}
+	query := bson.M{"visibility": model.PostVisitPublic}
 
-	posts, err := (&model.Post{}).List(s.db, &predicates, offset, limit)
+	posts, err := (&model.Post{}).ListPinnedFirst(context.TODO(), s.db, query, model.PinPlatform, offset, limit)
 	if err != nil {
 		logrus.Debugf("gormSimpleIndexPostsServant.IndexPosts err: %v", err)
 		return nil, err
@@ -94,7 +93,7 @@ func (s *simpleIndexPostsServant) IndexPosts(user *model.User, offset int, limit
 		return nil, err
 	}
 
-	total, err := (&model.Post{}).Count(s.db, &predicates)
+	total, err := (&model.Post{}).Count(s.db, &model.ConditionsT{"query": query})
 	if err != nil {
 		return nil, err
 	}
@@ -30,7 +30,7 @@ func (o organManageService) GetOrganById(id primitive.ObjectID) (*model.Organ, e
 
 func (o organManageService) GetOrganByKey(key string) (*model.Organ, error) {
 	organ := &model.Organ{Key: key}
-	return organ.Get(context.TODO(), o.db)
+	return organ.GetByKey(context.TODO(), o.db)
 }
 
 func (o organManageService) GetOrganNotShow() (*[]primitive.ObjectID, error) {
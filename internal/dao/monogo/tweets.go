@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"time"
 
 	"favor-dao-backend/internal/core"
 	"favor-dao-backend/internal/model"
@@ -89,9 +90,11 @@ func (s *tweetHelpServant) MergePosts(user string, posts []*model.Post) ([]*mode
 		joinedMap[v.DaoID.Hex()] = struct{}{}
 	}
 	subscribed := s.getDAOsByIdsWithSubscribed(user, daoIds)
-	subscribedMap := make(map[string]struct{}, len(subscribed))
+	subscribedMap := make(map[string]model.PostMemberT, len(subscribed))
 	for _, v := range subscribed {
-		subscribedMap[v.DaoID.Hex()] = struct{}{}
+		if v.ActiveTier() > subscribedMap[v.DaoID.Hex()] {
+			subscribedMap[v.DaoID.Hex()] = v.ActiveTier()
+		}
 	}
 
 	userMap := make(map[string]*model.UserFormatted, len(users))
@@ -105,14 +108,25 @@ func (s *tweetHelpServant) MergePosts(user string, posts []*model.Post) ([]*mode
 		if _, ok := joinedMap[dao.ID.Hex()]; ok || dao.Address == user {
 			daoMap[dao.ID.Hex()].IsJoined = true
 		}
-		if _, ok := subscribedMap[dao.ID.Hex()]; ok || dao.Address == user {
+		if tier, ok := subscribedMap[dao.ID.Hex()]; ok {
+			daoMap[dao.ID.Hex()].IsSubscribed = true
+			daoMap[dao.ID.Hex()].SubscribedTier = tier
+		}
+		if dao.Address == user {
 			daoMap[dao.ID.Hex()].IsSubscribed = true
+			daoMap[dao.ID.Hex()].SubscribedTier = model.PostMember3
 		}
 	}
 
 	contentMap := make(map[primitive.ObjectID][]*model.PostContentFormatted, len(postContents))
+	formattedContents := make([]*model.PostContentFormatted, 0, len(postContents))
 	for _, content := range postContents {
-		contentMap[content.PostID] = append(contentMap[content.PostID], content.Format())
+		formatted := content.Format()
+		contentMap[content.PostID] = append(contentMap[content.PostID], formatted)
+		formattedContents = append(formattedContents, formatted)
+	}
+	if err = model.FillPollVoted(context.TODO(), s.db, user, formattedContents); err != nil {
+		return nil, err
 	}
 
 	// data integration
@@ -137,6 +151,9 @@ func (s *tweetHelpServant) MergePosts(user string, posts []*model.Post) ([]*mode
 				for i := range refContentsFormatted {
 					refContentsFormatted[i] = refContents[i].Format()
 				}
+				if err = model.FillPollVoted(context.TODO(), s.db, user, refContentsFormatted); err != nil {
+					return nil, err
+				}
 				postFormatted.OrigContents = append(postFormatted.OrigContents, refContentsFormatted...)
 			case model.RefComment:
 				refComments, err := s.getCommentContentsByID(post.RefId)
@@ -205,9 +222,11 @@ func (s *tweetHelpServant) RevampPosts(user string, posts []*model.PostFormatted
 		joinedMap[v.DaoID.Hex()] = struct{}{}
 	}
 	subscribed := s.getDAOsByIdsWithSubscribed(user, daoIds)
-	subscribedMap := make(map[string]struct{}, len(subscribed))
+	subscribedMap := make(map[string]model.PostMemberT, len(subscribed))
 	for _, v := range subscribed {
-		subscribedMap[v.DaoID.Hex()] = struct{}{}
+		if v.ActiveTier() > subscribedMap[v.DaoID.Hex()] {
+			subscribedMap[v.DaoID.Hex()] = v.ActiveTier()
+		}
 	}
 	userMap := make(map[string]*model.UserFormatted, len(users))
 	for _, user := range users {
@@ -220,14 +239,25 @@ func (s *tweetHelpServant) RevampPosts(user string, posts []*model.PostFormatted
 		if _, ok := joinedMap[dao.ID.Hex()]; ok || dao.Address == user {
 			daoMap[dao.ID.Hex()].IsJoined = true
 		}
-		if _, ok := subscribedMap[dao.ID.Hex()]; ok || dao.Address == user {
+		if tier, ok := subscribedMap[dao.ID.Hex()]; ok {
 			daoMap[dao.ID.Hex()].IsSubscribed = true
+			daoMap[dao.ID.Hex()].SubscribedTier = tier
+		}
+		if dao.Address == user {
+			daoMap[dao.ID.Hex()].IsSubscribed = true
+			daoMap[dao.ID.Hex()].SubscribedTier = model.PostMember3
 		}
 	}
 
 	contentMap := make(map[primitive.ObjectID][]*model.PostContentFormatted, len(postContents))
+	formattedContents := make([]*model.PostContentFormatted, 0, len(postContents))
 	for _, content := range postContents {
-		contentMap[content.PostID] = append(contentMap[content.PostID], content.Format())
+		formatted := content.Format()
+		contentMap[content.PostID] = append(contentMap[content.PostID], formatted)
+		formattedContents = append(formattedContents, formatted)
+	}
+	if err = model.FillPollVoted(context.TODO(), s.db, user, formattedContents); err != nil {
+		return nil, err
 	}
 
 	// data integration
@@ -258,6 +288,9 @@ func (s *tweetHelpServant) RevampPosts(user string, posts []*model.PostFormatted
 				for i := range refContentsFormatted {
 					refContentsFormatted[i] = refContents[i].Format()
 				}
+				if err = model.FillPollVoted(context.TODO(), s.db, user, refContentsFormatted); err != nil {
+					return nil, err
+				}
 				post.OrigContents = append(post.OrigContents, refContentsFormatted...)
 			case model.RefComment:
 				refComments, err := s.getCommentContentsByID(post.RefId)
@@ -290,7 +323,7 @@ func (s *tweetHelpServant) filterMemberContent(user string, post *model.PostForm
 		return post
 	}
 	if post.Type == model.VIDEO {
-		if user == "" || (user != post.Address && !post.Dao.IsSubscribed) {
+		if user == "" || (user != post.Address && !post.Member.Allow(post.Dao.SubscribedTier)) {
 			for k, v := range post.Contents {
 				if v.Type == model.CONTENT_TYPE_VIDEO {
 					post.Contents[k].Content = ""
@@ -298,7 +331,11 @@ func (s *tweetHelpServant) filterMemberContent(user string, post *model.PostForm
 			}
 		}
 	} else if post.OrigType == model.VIDEO {
-		if user == "" || (user != post.AuthorId && !post.AuthorDao.IsSubscribed) {
+		member := post.OrigMember
+		if member == model.PostMemberNothing {
+			member = post.Member
+		}
+		if user == "" || (user != post.AuthorId && !member.Allow(post.AuthorDao.SubscribedTier)) {
 			for k, v := range post.OrigContents {
 				if v.Type == model.CONTENT_TYPE_VIDEO {
 					post.OrigContents[k].Content = ""
@@ -359,11 +396,10 @@ func (s *tweetHelpServant) getDAOsByIdsWithJoined(address string, ids []primitiv
 
 func (s *tweetHelpServant) getDAOsByIdsWithSubscribed(address string, ids []primitive.ObjectID) []*model.DaoSubscribe {
 	book := &model.DaoSubscribe{}
-	return book.FindList(context.TODO(), s.db, bson.M{
-		"address": address,
-		"status":  core.DaoSubscribeSuccess,
-		"dao_id":  bson.M{"$in": ids},
-	})
+	filter := model.ActiveSubscribeFilter(time.Now().Unix())
+	filter["address"] = address
+	filter["dao_id"] = bson.M{"$in": ids}
+	return book.FindList(context.TODO(), s.db, filter)
 }
 
 func (s *tweetManageServant) CreatePostCollection(postID primitive.ObjectID, address string) (*model.PostCollection, error) {
@@ -405,6 +441,8 @@ func (s *tweetManageServant) CreatePost(post *model.Post, contents []*model.Post
 				post.Tags = origPost.Tags
 				post.Visibility = origPost.Visibility
 				post.OrigCreatedAt = origPost.CreatedOn
+				// the repost is locked the way the original is
+				post.Gate = origPost.Gate
 
 				// replace the newest post type
 				post.OrigType = origPost.Type
@@ -525,7 +563,7 @@ func (s *tweetManageServant) DeletePost(post *model.Post) ([]string, []primitive
 				return nil, err
 			}
 
-			if tags := strings.Split(post.Tags, ","); len(tags) > 0 {
+			if tags := strings.Split(post.Tags, ","); len(tags) > 0 && post.Visibility == model.PostVisitPublic {
 				// Delete tag, handle errors loosely, no rollback with errors
 				deleteTags(s.db, tags)
 			}
@@ -564,8 +602,9 @@ func (s *tweetManageServant) RealDeletePosts(address string, delSearch func(cont
 	return nil
 }
 
-func (s *tweetManageServant) StickPost(post *model.Post) error {
-	post.IsTop = 1 - post.IsTop
+// PinPost pin the post in the scope until expiredOn, 0 never expires, or unpin it
+func (s *tweetManageServant) PinPost(post *model.Post, scope model.PinScope, pinned bool, expiredOn int64) error {
+	post.SetPin(scope, pinned, expiredOn)
 	if err := post.Update(context.TODO(), s.db); err != nil {
 		return err
 	}
@@ -633,6 +672,75 @@ func (s *tweetManageServant) UpdatePost(post *model.Post) error {
 	return nil
 }
 
+// EditPost keep the replaced version as the revision and publish the new contents and tags in place,
+// the reposts refer to the post by its id so they show the live version.
+func (s *tweetManageServant) EditPost(post *model.Post, contents []*model.PostContent, revision *model.PostRevision) error {
+	err := util.MongoTransaction(context.TODO(), s.db, func(ctx context.Context) error {
+		if err := revision.Create(ctx, s.db); err != nil {
+			return err
+		}
+		_, err := s.db.Collection(new(model.PostContent).Table()).UpdateMany(ctx,
+			bson.M{"post_id": post.ID, "is_del": 0},
+			bson.M{"$set": bson.M{"is_del": 1}},
+		)
+		if err != nil {
+			return err
+		}
+		for _, content := range contents {
+			content.PostID = post.ID
+			if _, err = content.Create(ctx, s.db); err != nil {
+				return err
+			}
+		}
+		post.ModifiedOn = time.Now().Unix()
+		return post.Update(ctx, s.db)
+	})
+	if err != nil {
+		return err
+	}
+
+	if post.Visibility == model.PostVisitPublic && revision.Tags != post.Tags {
+		// Handle errors leniently like the delete, the tags are only counters
+		if revision.Tags != "" {
+			deleteTags(s.db, strings.Split(revision.Tags, ","))
+		}
+		for _, t := range strings.Split(post.Tags, ",") {
+			if t != "" {
+				createTag(s.db, &model.Tag{Address: post.Address, Tag: t})
+			}
+		}
+	}
+
+	s.cacheIndex.SendAction(core.IdxActUpdatePost, post)
+	return nil
+}
+
+// PublishPost turn the draft into a post of the visibility it was drafted for, it counts as
+// created at the time it is published
+func (s *tweetManageServant) PublishPost(post *model.Post) error {
+	now := time.Now().Unix()
+	post.Visibility = post.PublishedVisibility()
+	post.PublishVisibility = model.PostVisitDraft
+	post.ScheduledOn = 0
+	post.CreatedOn = now
+	post.ModifiedOn = now
+	if err := post.Update(context.TODO(), s.db); err != nil {
+		return err
+	}
+
+	// Handle errors leniently like the create, the tags are only counters of the public posts
+	if post.Visibility == model.PostVisitPublic {
+		for _, t := range strings.Split(post.Tags, ",") {
+			if t != "" {
+				createTag(s.db, &model.Tag{Address: post.Address, Tag: t})
+			}
+		}
+	}
+
+	s.cacheIndex.SendAction(core.IdxActCreatePost, post)
+	return nil
+}
+
 func (s *tweetManageServant) CreatePostStar(postID primitive.ObjectID, address string) (*model.PostStar, error) {
 	star := &model.PostStar{
 		PostID:  postID,
@@ -660,6 +768,12 @@ func (s *tweetServant) GetPostCount(conditions *model.ConditionsT) (int64, error
 	return (&model.Post{}).Count(s.db, conditions)
 }
 
+func (s *tweetServant) CountDaoPins(daoID primitive.ObjectID) (int64, error) {
+	return (&model.Post{}).Count(s.db, &model.ConditionsT{
+		"query": bson.M{"dao_id": daoID, "dao_top": 1},
+	})
+}
+
 func (s *tweetServant) GetUserPostStar(postID primitive.ObjectID, address string) (*model.PostStar, error) {
 	star := &model.PostStar{
 		PostID:  postID,
@@ -9,35 +9,42 @@ type tweetSearchFilter struct {
 	ams core.AuthorizationManageService
 }
 
-func (s *tweetSearchFilter) filterResp(user *model.User, resp *core.QueryResp, q *core.QueryReq) {
-	var item *model.PostFormatted
-	items := resp.Items
-	latestIndex := len(items) - 1
-	if user == nil {
-		for i := 0; i <= latestIndex; i++ {
-			item = items[i]
-			if item.Visibility != model.PostVisitPublic {
-				items[i] = items[latestIndex]
-				items = items[:latestIndex]
-				resp.Total--
-				latestIndex--
-				i--
+// filterResp cut the items the viewer must not see: the content of the shadow-banned authors
+// except its own, and the private items unless the query asks for them.
+func (s *tweetSearchFilter) filterResp(resp *core.QueryResp, q *core.QueryReq) {
+	shadowed := make(map[string]struct{}, len(q.ShadowAddresses))
+	for _, address := range shadowAddresses(q) {
+		shadowed[address] = struct{}{}
+	}
+	onlyPublic := len(q.Visibility) == 0
+
+	// in place, the order of the items is kept
+	items := resp.Items[:0]
+	for _, item := range resp.Items {
+		_, cut := shadowed[item.Address]
+		if !cut && onlyPublic {
+			if q.Viewer == "" {
+				cut = item.Visibility != model.PostVisitPublic
+			} else {
+				cut = item.Visibility == model.PostVisitPrivate && q.Viewer != item.Address
 			}
 		}
-	} else {
-		var cutPrivate bool
-		for i := 0; i <= latestIndex; i++ {
-			item = items[i]
-			cutPrivate = item.Visibility == model.PostVisitPrivate && user.Address != item.Address
-			if cutPrivate {
-				items[i] = items[latestIndex]
-				items = items[:latestIndex]
-				resp.Total--
-				latestIndex--
-				i--
-			}
+		if cut {
+			resp.Total--
+			continue
 		}
+		items = append(items, item)
 	}
-
 	resp.Items = items
 }
+
+// shadowAddresses the shadow-banned authors hidden from the viewer
+func shadowAddresses(q *core.QueryReq) []string {
+	list := make([]string, 0, len(q.ShadowAddresses))
+	for _, address := range q.ShadowAddresses {
+		if address != q.Viewer {
+			list = append(list, address)
+		}
+	}
+	return list
+}
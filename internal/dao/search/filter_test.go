@@ -0,0 +1,97 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"favor-dao-backend/internal/core"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/types"
+)
+
+func TestFilterResp(t *testing.T) {
+	items := func() []*model.PostFormatted {
+		return []*model.PostFormatted{
+			{Address: "a", Visibility: model.PostVisitPublic},
+			{Address: "banned", Visibility: model.PostVisitPublic},
+			{Address: "b", Visibility: model.PostVisitPrivate},
+			{Address: "c", Visibility: model.PostVisitPublic},
+		}
+	}
+	tests := []struct {
+		name string
+		q    core.QueryReq
+		want []string
+	}{
+		{"guest", core.QueryReq{ShadowAddresses: []string{"banned"}}, []string{"a", "c"}},
+		{"author of private", core.QueryReq{Viewer: "b", ShadowAddresses: []string{"banned"}}, []string{"a", "b", "c"}},
+		{"shadow-banned viewer", core.QueryReq{Viewer: "banned", ShadowAddresses: []string{"banned"}}, []string{"a", "banned", "c"}},
+		{"private asked", core.QueryReq{
+			Visibility:      []core.PostVisibleT{core.PostVisitPublic, core.PostVisitPrivate},
+			ShadowAddresses: []string{"banned"},
+		}, []string{"a", "b", "c"}},
+	}
+	s := &tweetSearchFilter{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &core.QueryResp{Items: items(), Total: 4}
+			s.filterResp(resp, &tt.q)
+			if resp.Total != int64(len(tt.want)) || len(resp.Items) != len(tt.want) {
+				t.Fatalf("got %d items total %d, want %v", len(resp.Items), resp.Total, tt.want)
+			}
+			for i, item := range resp.Items {
+				if item.Address != tt.want[i] {
+					t.Errorf("item %d = %s, want %s", i, item.Address, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMeiliFilter(t *testing.T) {
+	q := &core.QueryReq{
+		Type:            []core.PostType{model.SMS},
+		DaoIDs:          []string{"d1"},
+		BlockPostIDs:    []string{"p1"},
+		Viewer:          "banned",
+		ShadowAddresses: []string{"banned", "other"},
+	}
+	want := []string{
+		fmt.Sprintf("type IN [%d]", model.SMS),
+		`dao_id IN ["d1"]`,
+		fmt.Sprintf("visibility = %d", model.PostVisitPublic),
+		`NOT address IN ["other"]`,
+		`NOT id IN ["p1"]`,
+		`NOT ref_id IN ["p1"]`,
+	}
+	got, err := meiliFilter(q)
+	if err != nil || strings.Join(got, " AND ") != strings.Join(want, " AND ") {
+		t.Errorf("got %q %v, want %q", got, err, want)
+	}
+
+	for _, tag := range []string{"x = 1 OR dao_id = \"d2\" OR tags.y", "a b", "a.b"} {
+		if _, err = meiliFilter(&core.QueryReq{Tag: tag}); err != errMeiliTag {
+			t.Errorf("tag %q: got %v, want errMeiliTag", tag, err)
+		}
+	}
+	if got, err = meiliFilter(&core.QueryReq{Tag: "标签_1-a"}); err != nil || got[1] != "tags.标签_1-a = 1" {
+		t.Errorf("got %q %v", got, err)
+	}
+}
+
+func TestMeiliSort(t *testing.T) {
+	sort, err := meiliSort(&core.QueryReq{Pins: model.PinDao})
+	if err != nil || strings.Join(sort, ",") != "dao_top:desc,created_on:desc" {
+		t.Errorf("got sort %q %v", sort, err)
+	}
+	sort, err = meiliSort(&core.QueryReq{Sort: types.AnySlice{map[string]types.Any{"view_count": "asc"}}})
+	if err != nil || strings.Join(sort, ",") != "is_top:desc,view_count:asc" {
+		t.Errorf("got sort %q %v", sort, err)
+	}
+	for _, v := range []map[string]types.Any{{"address": "desc"}, {"view_count": "desc, is_del"}} {
+		if _, err = meiliSort(&core.QueryReq{Sort: types.AnySlice{v}}); err != errMeiliSort {
+			t.Errorf("sort %v: got %v, want errMeiliSort", v, err)
+		}
+	}
+}
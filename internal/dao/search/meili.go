@@ -3,6 +3,9 @@ package search
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"favor-dao-backend/internal/core"
 	"favor-dao-backend/internal/model"
@@ -17,6 +20,17 @@ var (
 	_ core.VersionInfo        = (*meiliTweetSearchServant)(nil)
 )
 
+var (
+	errMeiliTag  = errors.New("invalid tag")
+	errMeiliSort = errors.New("invalid sort")
+
+	// the tag is a part of an attribute name in the filter, it can not be quoted
+	meiliTagPattern = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)
+
+	meiliSortableAttributes   = []string{"is_top", "dao_top", "latest_replied_on", "created_on", "modified_on", "view_count"}
+	meiliFilterableAttributes = []string{"tags", "address", "visibility", "type", "dao_id", "author_dao_id", "id", "ref_id"}
+)
+
 type meiliTweetSearchServant struct {
 	tweetSearchFilter
 
@@ -66,32 +80,125 @@ func (s *meiliTweetSearchServant) Search(q *core.QueryReq, offset, limit int) (r
 		logrus.Errorf("meiliTweetSearchServant.search query:%v error:%v", q, err)
 		return
 	}
+	s.filterResp(resp, q)
 
 	logrus.Debugf("meiliTweetSearchServant.Search query:%v resp Hits:%d NbHits:%d offset: %d limit:%d ", q, len(resp.Items), resp.Total, offset, limit)
 	return
 }
 
 func (s *meiliTweetSearchServant) queryAny(q *core.QueryReq, offset, limit int) (*core.QueryResp, error) {
-	return nil, errors.New("not support")
-	// request := &meilisearch.SearchRequest{
-	// 	Offset: int64(offset),
-	// 	Limit:  int64(limit),
-	// 	Sort:   []string{"is_top:desc", "modified_on:desc"},
-	// }
-	// // todo
-	// return nil, errors.New("not support")
-	// filter := s.filterList(nil)
-	// if len(filter) > 0 {
-	// 	request.Filter = filter
-	// }
-	//
-	// // logrus.Debugf("meiliTweetSearchServant.queryByContent query:%s request%+v", q.Query, request)
-	// resp, err := s.index.Search(q.Query, request)
-	// if err != nil {
-	// 	return nil, err
-	// }
-	//
-	// return s.postsFrom(resp)
+	sort, err := meiliSort(q)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := meiliFilter(q)
+	if err != nil {
+		return nil, err
+	}
+	request := &meilisearch.SearchRequest{
+		Offset: int64(offset),
+		Limit:  int64(limit),
+		Sort:   sort,
+	}
+	if len(filter) > 0 {
+		request.Filter = strings.Join(filter, " AND ")
+	}
+
+	resp, err := s.index.Search(q.Query, request)
+	if err != nil {
+		return nil, err
+	}
+	return s.postsFrom(resp)
+}
+
+// meiliFilter the conditions of the query, AND-ed. The shadow-banned authors are cut here
+// so the pages stay full, filterResp only catches the rest.
+func meiliFilter(q *core.QueryReq) ([]string, error) {
+	var filter []string
+	if len(q.Type) > 0 {
+		values := make([]string, 0, len(q.Type))
+		for _, v := range q.Type {
+			values = append(values, fmt.Sprint(v))
+		}
+		filter = append(filter, meiliIn("type", values))
+	}
+	if len(q.DaoIDs) > 0 {
+		filter = append(filter, meiliIn("dao_id", meiliQuote(q.DaoIDs)))
+	}
+	if len(q.Addresses) > 0 {
+		filter = append(filter, meiliIn("address", meiliQuote(q.Addresses)))
+	}
+	if len(q.Visibility) == 0 {
+		// default public
+		filter = append(filter, fmt.Sprintf("visibility = %d", core.PostVisitPublic))
+	} else {
+		values := make([]string, 0, len(q.Visibility))
+		for _, v := range q.Visibility {
+			values = append(values, fmt.Sprint(v))
+		}
+		filter = append(filter, meiliIn("visibility", values))
+	}
+	if q.Tag != "" {
+		if !meiliTagPattern.MatchString(q.Tag) {
+			return nil, errMeiliTag
+		}
+		filter = append(filter, fmt.Sprintf("tags.%s = 1", q.Tag))
+	}
+	if len(q.BlockDaoIDs) > 0 {
+		values := meiliQuote(q.BlockDaoIDs)
+		filter = append(filter, "NOT "+meiliIn("dao_id", values), "NOT "+meiliIn("author_dao_id", values))
+	}
+	if shadowed := shadowAddresses(q); len(shadowed) > 0 {
+		filter = append(filter, "NOT "+meiliIn("address", meiliQuote(shadowed)))
+	}
+	if len(q.BlockPostIDs) > 0 {
+		values := meiliQuote(q.BlockPostIDs)
+		filter = append(filter, "NOT "+meiliIn("id", values), "NOT "+meiliIn("ref_id", values))
+	}
+	return filter, nil
+}
+
+// meiliSort the pins first, then the sort of the query or the latest. Only the sortable
+// attributes are taken from the query.
+func meiliSort(q *core.QueryReq) ([]string, error) {
+	sort := []string{q.Pins.Field() + ":desc"}
+	if len(q.Sort) == 0 {
+		return append(sort, "created_on:desc"), nil
+	}
+	for _, v := range q.Sort {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, errMeiliSort
+		}
+		for field, order := range m {
+			if !meiliSortable(field) || (order != "asc" && order != "desc") {
+				return nil, errMeiliSort
+			}
+			sort = append(sort, fmt.Sprintf("%s:%s", field, order))
+		}
+	}
+	return sort, nil
+}
+
+func meiliSortable(field string) bool {
+	for _, v := range meiliSortableAttributes {
+		if v == field {
+			return true
+		}
+	}
+	return false
+}
+
+func meiliIn(field string, values []string) string {
+	return fmt.Sprintf("%s IN [%s]", field, strings.Join(values, ", "))
+}
+
+func meiliQuote(values []string) []string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, strconv.Quote(v))
+	}
+	return quoted
 }
 
 func (s *meiliTweetSearchServant) filterList(user *model.User) string {
@@ -25,14 +25,12 @@ func NewMeiliTweetSearchService(ams core.AuthorizationManageService) (core.Tweet
 			PrimaryKey: "id",
 		})
 		searchableAttributes := []string{"content", "tags"}
-		sortableAttributes := []string{"is_top", "latest_replied_on"}
-		filterableAttributes := []string{"tags", "address", "visibility"}
-
-		index := client.Index(s.Index)
-		index.UpdateSearchableAttributes(&searchableAttributes)
-		index.UpdateSortableAttributes(&sortableAttributes)
-		index.UpdateFilterableAttributes(&filterableAttributes)
+		client.Index(s.Index).UpdateSearchableAttributes(&searchableAttributes)
 	}
+	// kept up to date on the indexes created before, the query filters and sorts on them
+	index := client.Index(s.Index)
+	index.UpdateSortableAttributes(&meiliSortableAttributes)
+	index.UpdateFilterableAttributes(&meiliFilterableAttributes)
 
 	mts := &meiliTweetSearchServant{
 		tweetSearchFilter: tweetSearchFilter{
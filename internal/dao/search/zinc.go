@@ -76,6 +76,7 @@ func (s *zincTweetSearchServant) Search(q *core.QueryReq, offset, limit int) (re
 		logrus.Errorf("zincTweetSearchServant.search query:%v error:%v", q, err)
 		return
 	}
+	s.filterResp(resp, q)
 
 	logrus.Debugf("zincTweetSearchServant.Search query:%v resp Hits:%d NbHits:%d offset: %d limit:%d ", q, len(resp.Items), resp.Total, offset, limit)
 	return
@@ -168,6 +169,14 @@ func (s *zincTweetSearchServant) queryAny(q *core.QueryReq, offset, limit int) (
 			},
 		})
 	}
+	if shadowed := shadowAddresses(q); len(shadowed) > 0 {
+		// the pages stay full, filterResp only catches the rest
+		mustNot = append(mustNot, map[string]types.Any{
+			"terms": map[string]types.Any{
+				"address": shadowed,
+			},
+		})
+	}
 	if len(q.BlockPostIDs) > 0 {
 		mustNot = append(mustNot, map[string]types.Any{
 			"terms": map[string]types.Any{
@@ -197,16 +206,17 @@ func (s *zincTweetSearchServant) queryAny(q *core.QueryReq, offset, limit int) (
 		delete(query, "bool")
 		query["match_all"] = map[string]types.Any{}
 	}
+	pin := q.Pins.Field()
 	if q.Sort == nil {
 		q.Sort = append(q.Sort, map[string]types.Any{
-			"is_top": "desc",
+			pin: "desc",
 		}, map[string]types.Any{
 			"created_on": "desc",
 		})
 	} else {
 		q.Sort = append(types.AnySlice{
 			map[string]types.Any{
-				"is_top": "desc",
+				pin: "desc",
 			},
 		}, q.Sort...)
 	}
@@ -304,6 +314,12 @@ func (s *zincTweetSearchServant) createIndex() {
 			Sortable: true,
 			Store:    true,
 		},
+		"dao_top": &zinc.ZincIndexPropertyT{
+			Type:     "numeric",
+			Index:    true,
+			Sortable: true,
+			Store:    true,
+		},
 		"is_essence": &zinc.ZincIndexPropertyT{
 			Type:     "numeric",
 			Index:    true,
@@ -372,5 +388,10 @@ func (s *zincTweetSearchServant) createIndex() {
 			Sortable: true,
 			Store:    true,
 		},
+		"home_page": &zinc.ZincIndexPropertyT{
+			Type:  "keyword",
+			Index: true,
+			Store: true,
+		},
 	})
 }
@@ -2,6 +2,10 @@ package middleware
 
 import (
 	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/internal/service"
+	"favor-dao-backend/pkg/app"
+	"favor-dao-backend/pkg/errcode"
 	"github.com/gin-gonic/gin"
 )
 
@@ -18,3 +22,48 @@ func AllowHost() gin.HandlerFunc {
 		return
 	}
 }
+
+// PayNotifySign verify the signature of the pay notify callback
+func PayNotifySign() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if e := service.VerifyPayNotify(c.Request.Context(), c.Request.URL.Query(), c.RemoteIP()); e != nil {
+			response := app.NewResponse(c)
+			response.ToErrorResponse(e)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// Role only the users of one of the roles pass, the admin holds all of them. It runs after Login.
+func Role(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if u, exists := c.Get("USER"); exists {
+			if user, ok := u.(*model.User); ok && user.HasRole(roles...) {
+				c.Next()
+				return
+			}
+		}
+		response := app.NewResponse(c)
+		response.ToErrorResponse(errcode.NoPermission)
+		c.Abort()
+	}
+}
+
+// Unsuspended the suspended users are refused with the reason and the expiry. It runs after Login.
+func Unsuspended() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s, exists := c.Get("SANCTIONS"); exists {
+			if sanctions, ok := s.(*service.UserSanctions); ok {
+				if e := sanctions.CheckSuspended(); e != nil {
+					response := app.NewResponse(c)
+					response.ToErrorResponse(e)
+					c.Abort()
+					return
+				}
+			}
+		}
+		c.Next()
+	}
+}
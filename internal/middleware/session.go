@@ -56,6 +56,10 @@ func Login() gin.HandlerFunc {
 			ecode = errcode.Success
 		)
 		token = c.GetHeader("X-Session-Token")
+		// browsers can not set headers on a websocket handshake or an EventSource
+		if token == "" && (c.IsWebsocket() || c.GetHeader("Accept") == "text/event-stream") {
+			token = c.Query("token")
+		}
 
 		if token == "" {
 			response := app.NewResponse(c)
@@ -77,6 +81,8 @@ func Login() gin.HandlerFunc {
 				} else {
 					c.Set("USER", user)
 					c.Set("address", user.Address)
+					// the write routes refuse the suspended users by Unsuspended
+					c.Set("SANCTIONS", service.GetUserSanctions(user.Address))
 				}
 			}
 		} else {
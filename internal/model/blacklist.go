@@ -54,3 +54,32 @@ func (m *Blacklist) FindIDs(ctx context.Context, db *mongo.Database, filter inte
 	}
 	return
 }
+
+func (m *Blacklist) FindList(ctx context.Context, db *mongo.Database, filter interface{}, offset, limit int) ([]*Blacklist, int64, error) {
+	total, err := db.Collection(m.Table()).CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().SetSort(bson.M{"_id": -1}).SetSkip(int64(offset)).SetLimit(int64(limit))
+	cursor, err := find(ctx, db, m, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	list := []*Blacklist{}
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// Delete it returns mongo.ErrNoDocuments when the entry does not exist
+func (m *Blacklist) Delete(ctx context.Context, db *mongo.Database) error {
+	res, err := db.Collection(m.Table()).DeleteOne(ctx, bson.M{ID: m.GetID()})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package chat
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ConversationType uint8
+
+const (
+	ConversationDirect ConversationType = iota
+	ConversationDao
+)
+
+type Conversation struct {
+	ID         primitive.ObjectID `json:"id"                bson:"_id,omitempty"`
+	CreatedOn  int64              `json:"created_on"        bson:"created_on"`
+	ModifiedOn int64              `json:"modified_on"       bson:"modified_on"`
+	Type       ConversationType   `json:"type"              bson:"type"`
+	// Key identify the conversation, the members of a direct one or the DAO of a room
+	Key string `json:"-"                 bson:"key"`
+	// Members of the direct conversation, the members of a DAO room are its followers
+	Members       []string           `json:"members,omitempty" bson:"members,omitempty"`
+	DaoID         primitive.ObjectID `json:"dao_id,omitempty"  bson:"dao_id,omitempty"`
+	LastMessageID primitive.ObjectID `json:"last_message_id"   bson:"last_message_id,omitempty"`
+	LastMessageOn int64              `json:"last_message_on"   bson:"last_message_on"`
+}
+
+func DirectKey(a, b string) string {
+	members := []string{strings.ToLower(a), strings.ToLower(b)}
+	sort.Strings(members)
+	return "direct:" + strings.Join(members, ":")
+}
+
+func DaoKey(daoID primitive.ObjectID) string {
+	return "dao:" + daoID.Hex()
+}
+
+func (m *Conversation) Table() string {
+	return "chat_conversation"
+}
+
+// FirstOrCreate load the conversation of the key, it is created at the first time.
+func (m *Conversation) FirstOrCreate(ctx context.Context, db *mongo.Database) error {
+	now := time.Now().Unix()
+	insert := bson.M{
+		"created_on": now,
+		"type":       m.Type,
+	}
+	if len(m.Members) > 0 {
+		insert["members"] = m.Members
+	}
+	if !m.DaoID.IsZero() {
+		insert["dao_id"] = m.DaoID
+	}
+	res := db.Collection(m.Table()).FindOneAndUpdate(ctx,
+		bson.M{"key": m.Key},
+		bson.M{"$setOnInsert": insert, "$max": bson.M{"modified_on": now}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	)
+	if res.Err() != nil {
+		return res.Err()
+	}
+	return res.Decode(m)
+}
+
+func (m *Conversation) Get(ctx context.Context, db *mongo.Database) error {
+	res := db.Collection(m.Table()).FindOne(ctx, bson.M{"_id": m.ID})
+	if res.Err() != nil {
+		return res.Err()
+	}
+	return res.Decode(m)
+}
+
+// Touch record the last message of the conversation.
+func (m *Conversation) Touch(ctx context.Context, db *mongo.Database, msg *Message) error {
+	m.LastMessageID = msg.ID
+	m.LastMessageOn = msg.CreatedOn
+	_, err := db.Collection(m.Table()).UpdateOne(ctx,
+		bson.M{"_id": m.ID},
+		bson.M{"$set": bson.M{
+			"last_message_id": msg.ID,
+			"last_message_on": msg.CreatedOn,
+			"modified_on":     msg.CreatedOn,
+		}},
+	)
+	return err
+}
+
+func (m *Conversation) IsMember(address string) bool {
+	for _, v := range m.Members {
+		if strings.EqualFold(v, address) {
+			return true
+		}
+	}
+	return false
+}
+
+// List the direct conversations of the address and the rooms of the DAOs, the latest first.
+func (m *Conversation) List(ctx context.Context, db *mongo.Database, address string, daoIDs []primitive.ObjectID, offset, limit int) (list []*Conversation, err error) {
+	filter := bson.M{"$or": []bson.M{
+		{"type": ConversationDirect, "members": strings.ToLower(address)},
+		{"type": ConversationDao, "dao_id": bson.M{"$in": daoIDs}},
+	}}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "last_message_on", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	cursor, err := db.Collection(m.Table()).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	list = []*Conversation{}
+	err = cursor.All(ctx, &list)
+	return
+}
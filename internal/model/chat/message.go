@@ -0,0 +1,75 @@
+package chat
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MessageType uint8
+
+const (
+	MessageText MessageType = iota
+	MessageImage
+)
+
+type Message struct {
+	ID             primitive.ObjectID `json:"id"              bson:"_id,omitempty"`
+	CreatedOn      int64              `json:"created_on"      bson:"created_on"`
+	ConversationID primitive.ObjectID `json:"conversation_id" bson:"conversation_id"`
+	From           string             `json:"from"            bson:"from"`
+	Type           MessageType        `json:"type"            bson:"type"`
+	Content        string             `json:"content"         bson:"content"`
+}
+
+func (m *Message) Table() string {
+	return "chat_message"
+}
+
+func (m *Message) Create(ctx context.Context, db *mongo.Database) error {
+	m.ID = primitive.NewObjectID()
+	m.CreatedOn = time.Now().Unix()
+	_, err := db.Collection(m.Table()).InsertOne(ctx, m)
+	return err
+}
+
+// List the messages of the conversation before the given one, the latest first.
+func (m *Message) List(ctx context.Context, db *mongo.Database, before primitive.ObjectID, limit int) (list []*Message, err error) {
+	filter := bson.M{"conversation_id": m.ConversationID}
+	if !before.IsZero() {
+		filter["_id"] = bson.M{"$lt": before}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(int64(limit))
+	cursor, err := db.Collection(m.Table()).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	list = []*Message{}
+	err = cursor.All(ctx, &list)
+	return
+}
+
+// CountUnread count the messages of others after the last read one.
+func (m *Message) CountUnread(ctx context.Context, db *mongo.Database, address string, lastRead primitive.ObjectID) (int64, error) {
+	filter := bson.M{
+		"conversation_id": m.ConversationID,
+		"from":            bson.M{"$ne": address},
+	}
+	if !lastRead.IsZero() {
+		filter["_id"] = bson.M{"$gt": lastRead}
+	}
+	return db.Collection(m.Table()).CountDocuments(ctx, filter)
+}
+
+func (m *Message) Get(ctx context.Context, db *mongo.Database) error {
+	res := db.Collection(m.Table()).FindOne(ctx, bson.M{"_id": m.ID})
+	if res.Err() != nil {
+		return res.Err()
+	}
+	return res.Decode(m)
+}
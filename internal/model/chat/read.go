@@ -0,0 +1,63 @@
+package chat
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ReadReceipt the last message read by the address in the conversation
+type ReadReceipt struct {
+	ConversationID primitive.ObjectID `json:"conversation_id" bson:"conversation_id"`
+	Address        string             `json:"address"         bson:"address"`
+	LastReadID     primitive.ObjectID `json:"last_read_id"    bson:"last_read_id"`
+	ReadOn         int64              `json:"read_on"         bson:"read_on"`
+}
+
+func (m *ReadReceipt) Table() string {
+	return "chat_read"
+}
+
+// Read move the receipt forward to LastReadID, it never goes back.
+func (m *ReadReceipt) Read(ctx context.Context, db *mongo.Database) error {
+	res := db.Collection(m.Table()).FindOneAndUpdate(ctx,
+		bson.M{"conversation_id": m.ConversationID, "address": m.Address},
+		bson.M{
+			"$max": bson.M{"last_read_id": m.LastReadID},
+			"$set": bson.M{"read_on": time.Now().Unix()},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	)
+	if res.Err() != nil {
+		return res.Err()
+	}
+	return res.Decode(m)
+}
+
+// First load the receipt of the address, LastReadID is zero when nothing is read.
+func (m *ReadReceipt) First(ctx context.Context, db *mongo.Database) error {
+	res := db.Collection(m.Table()).FindOne(ctx, bson.M{"conversation_id": m.ConversationID, "address": m.Address})
+	if res.Err() == mongo.ErrNoDocuments {
+		return nil
+	}
+	if res.Err() != nil {
+		return res.Err()
+	}
+	return res.Decode(m)
+}
+
+// List the receipts of all members of the conversation.
+func (m *ReadReceipt) List(ctx context.Context, db *mongo.Database) (list []*ReadReceipt, err error) {
+	cursor, err := db.Collection(m.Table()).Find(ctx, bson.M{"conversation_id": m.ConversationID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	list = []*ReadReceipt{}
+	err = cursor.All(ctx, &list)
+	return
+}
@@ -31,8 +31,30 @@ const (
 
 var (
 	ErrDuplicateDAOName = errors.New("DAO name duplicate")
+	ErrNoSuchDaoTier    = errors.New("DAO subscription tier not found")
+	ErrDaoOwnerChanged  = errors.New("DAO owner changed")
+	ErrDaoArchived      = errors.New("DAO archived")
 )
 
+// DaoTier a subscription plan of the DAO, a tier unlocks the member content of its level and below
+type DaoTier struct {
+	Level PostMemberT `json:"level"      bson:"level"`
+	Name  string      `json:"name"       bson:"name"`
+	Price string      `json:"price"      bson:"price"`
+	// Days the subscription lasts, 0 lifetime
+	Days int64 `json:"days"       bson:"days"`
+}
+
+// DaoPreview the OpenGraph metadata of the home page of a DaoWithURL
+type DaoPreview struct {
+	URL         string `json:"url"         bson:"url"`
+	Title       string `json:"title"       bson:"title"`
+	Description string `json:"description" bson:"description"`
+	Image       string `json:"image"       bson:"image"`
+	SiteName    string `json:"site_name"   bson:"site_name"`
+	FetchedOn   int64  `json:"fetched_on"  bson:"fetched_on"`
+}
+
 type Dao struct {
 	ID           primitive.ObjectID `json:"id"               bson:"_id,omitempty"`
 	CreatedOn    int64              `json:"created_on"       bson:"created_on"`
@@ -50,24 +72,40 @@ type Dao struct {
 	Price        string             `json:"price"            bson:"price"`
 	Tags         string             `json:"tags"             bson:"tags"`
 	Type         DaoType            `json:"type"             bson:"type,omitempty"`
+	Tiers        []DaoTier          `json:"tiers,omitempty"  bson:"tiers,omitempty"`
+	// Grants the permissions of each role, DefaultDaoGrants when not set
+	Grants map[DaoRole][]DaoPermission `json:"grants,omitempty" bson:"grants,omitempty"`
+	// ArchivedOn the DAO is read only since, 0 not archived
+	ArchivedOn int64 `json:"archived_on"      bson:"archived_on,omitempty"`
+	// Preview of the home page, fetched in the background
+	Preview *DaoPreview `json:"preview,omitempty" bson:"preview,omitempty"`
+	// Gate the posts of the DAO are only for the holders of the token
+	Gate *TokenGate `json:"gate,omitempty" bson:"gate,omitempty"`
 }
 
 type DaoFormatted struct {
-	ID           string           `json:"id"`
-	Address      string           `json:"address"`
-	Name         string           `json:"name"`
-	Introduction string           `json:"introduction"`
-	Visibility   DaoVisibleT      `json:"visibility"`
-	Avatar       string           `json:"avatar"`
-	Banner       string           `json:"banner"`
-	HomePage     string           `json:"home_page,omitempty"`
-	FollowCount  int64            `json:"follow_count"`
-	Price        string           `json:"price"`
-	Tags         map[string]int8  `json:"tags"`
-	Type         DaoType          `json:"type"`
-	LastPosts    []*PostFormatted `json:"last_posts"`
-	IsJoined     bool             `json:"is_joined"`
-	IsSubscribed bool             `json:"is_subscribed"`
+	ID           string                      `json:"id"`
+	Address      string                      `json:"address"`
+	Name         string                      `json:"name"`
+	Introduction string                      `json:"introduction"`
+	Visibility   DaoVisibleT                 `json:"visibility"`
+	Avatar       string                      `json:"avatar"`
+	Banner       string                      `json:"banner"`
+	HomePage     string                      `json:"home_page,omitempty"`
+	FollowCount  int64                       `json:"follow_count"`
+	Price        string                      `json:"price"`
+	Tags         map[string]int8             `json:"tags"`
+	Type         DaoType                     `json:"type"`
+	Tiers        []DaoTier                   `json:"tiers"`
+	Grants       map[DaoRole][]DaoPermission `json:"grants"`
+	ArchivedOn   int64                       `json:"archived_on"`
+	Preview      *DaoPreview                 `json:"preview,omitempty"`
+	Gate         *TokenGate                  `json:"gate,omitempty"`
+	LastPosts    []*PostFormatted            `json:"last_posts"`
+	IsJoined     bool                        `json:"is_joined"`
+	IsSubscribed bool                        `json:"is_subscribed"`
+	// SubscribedTier the highest active tier of the viewer
+	SubscribedTier PostMemberT `json:"subscribed_tier"`
 }
 
 func (m *Dao) Format() *DaoFormatted {
@@ -88,10 +126,49 @@ func (m *Dao) Format() *DaoFormatted {
 		Price:        m.Price,
 		Tags:         tagsMap,
 		Type:         m.Type,
+		Tiers:        m.SubscribeTiers(),
+		Grants:       m.DaoGrants(),
+		ArchivedOn:   m.ArchivedOn,
+		Preview:      m.Preview,
+		Gate:         m.Gate,
 		LastPosts:    []*PostFormatted{},
 	}
 }
 
+func (m *Dao) DaoGrants() map[DaoRole][]DaoPermission {
+	if m.Grants == nil {
+		return DefaultDaoGrants
+	}
+	return m.Grants
+}
+
+// SubscribeTiers the DAO without tiers has a single lifetime tier at its price which unlocks everything
+func (m *Dao) SubscribeTiers() []DaoTier {
+	if len(m.Tiers) > 0 {
+		return m.Tiers
+	}
+	return []DaoTier{{Level: PostMember3, Price: m.Price}}
+}
+
+// FindTier the tier of the level, the cheapest tier which unlocks PostMember1 when level is PostMemberNothing
+func (m *Dao) FindTier(level PostMemberT) (*DaoTier, error) {
+	tiers := m.SubscribeTiers()
+	if level == PostMemberNothing {
+		for i := range tiers {
+			if tiers[i].Level >= level.Subscribed() {
+				return &tiers[i], nil
+			}
+		}
+		return nil, ErrNoSuchDaoTier
+	}
+	for i := range tiers {
+		if tiers[i].Level == level {
+			return &tiers[i], nil
+		}
+	}
+	return nil, ErrNoSuchDaoTier
+}
+
 func (m *Dao) Table() string {
 	return "dao"
 }
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (m *Dao) IsArchived() bool {
+	return m.ArchivedOn > 0
+}
+
+// Archive make the DAO read only, it returns mongo.ErrNoDocuments when it is archived already.
+func (m *Dao) Archive(ctx context.Context, db *mongo.Database) error {
+	now := time.Now().Unix()
+	err := db.Collection(m.Table()).FindOneAndUpdate(ctx,
+		bson.M{ID: m.ID, "is_del": 0, "archived_on": bson.M{"$not": bson.M{"$gt": 0}}},
+		bson.M{"$set": bson.M{"archived_on": now, "modified_on": now}},
+	).Err()
+	if err != nil {
+		return err
+	}
+	m.ArchivedOn = now
+	return nil
+}
+
+// Unarchive it returns mongo.ErrNoDocuments when the DAO is not archived.
+func (m *Dao) Unarchive(ctx context.Context, db *mongo.Database) error {
+	err := db.Collection(m.Table()).FindOneAndUpdate(ctx,
+		bson.M{ID: m.ID, "is_del": 0, "archived_on": bson.M{"$gt": 0}},
+		bson.M{"$set": bson.M{"modified_on": time.Now().Unix()}, "$unset": bson.M{"archived_on": ""}},
+	).Err()
+	if err != nil {
+		return err
+	}
+	m.ArchivedOn = 0
+	return nil
+}
+
+// Restore undo the soft delete after since, it returns mongo.ErrNoDocuments when the DAO
+// is not deleted or was deleted before.
+func (m *Dao) Restore(ctx context.Context, db *mongo.Database, since int64) error {
+	err := db.Collection(m.Table()).FindOneAndUpdate(ctx,
+		bson.M{ID: m.ID, "is_del": 1, "deleted_on": bson.M{"$gte": since}},
+		bson.M{"$set": bson.M{"is_del": 0, "deleted_on": 0, "modified_on": time.Now().Unix()}},
+	).Err()
+	if err != nil {
+		return err
+	}
+	m.IsDel = 0
+	m.DeletedOn = 0
+	return nil
+}
+
+// PostIDs the posts of the DAO which are not deleted
+func (m *Dao) PostIDs(ctx context.Context, db *mongo.Database) ([]primitive.ObjectID, error) {
+	values, err := db.Collection(new(Post).Table()).Distinct(ctx, "_id", bson.M{"dao_id": m.ID, "is_del": 0})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrDaoBanned = errors.New("banned from the DAO")
+
+// DaoBan the address is kept out of the DAO until ExpiredOn, 0 forever
+type DaoBan struct {
+	DefaultModel `bson:",inline"`
+	DaoID        primitive.ObjectID `json:"dao_id"     bson:"dao_id"`
+	Address      string             `json:"address"    bson:"address"`
+	Reason       string             `json:"reason"     bson:"reason"`
+	BannedBy     string             `json:"banned_by"  bson:"banned_by"`
+	ExpiredOn    int64              `json:"expired_on" bson:"expired_on"`
+}
+
+func (m *DaoBan) Table() string {
+	return "dao_ban"
+}
+
+func activeBanFilter(now int64) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"expired_on": 0},
+		bson.M{"expired_on": bson.M{"$gt": now}},
+	}}
+}
+
+// Ban create or replace the ban of the address
+func (m *DaoBan) Ban(ctx context.Context, db *mongo.Database) error {
+	now := time.Now().Unix()
+	_, err := db.Collection(m.Table()).UpdateOne(ctx,
+		bson.M{"dao_id": m.DaoID, "address": m.Address},
+		bson.M{
+			"$setOnInsert": bson.M{CreatedAtField: now},
+			"$set": bson.M{
+				UpdatedAtField: now,
+				"reason":       m.Reason,
+				"banned_by":    m.BannedBy,
+				"expired_on":   m.ExpiredOn,
+			},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (m *DaoBan) Unban(ctx context.Context, db *mongo.Database) error {
+	res, err := db.Collection(m.Table()).DeleteOne(ctx, bson.M{"dao_id": m.DaoID, "address": m.Address})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (m *DaoBan) IsBanned(ctx context.Context, db *mongo.Database) (bool, error) {
+	filter := activeBanFilter(time.Now().Unix())
+	filter["dao_id"] = m.DaoID
+	filter["address"] = m.Address
+	count, err := db.Collection(m.Table()).CountDocuments(ctx, filter)
+	return count > 0, err
+}
+
+// List the bans of the DAO still in effect
+func (m *DaoBan) List(ctx context.Context, db *mongo.Database) ([]*DaoBan, error) {
+	filter := activeBanFilter(time.Now().Unix())
+	filter["dao_id"] = m.DaoID
+	cursor, err := find(ctx, db, m, filter, options.Find().SetSort(bson.M{"_id": -1}))
+	if err != nil {
+		return nil, err
+	}
+	list := []*DaoBan{}
+	err = cursor.All(ctx, &list)
+	return list, err
+}
@@ -2,11 +2,13 @@ package model
 
 import (
 	"context"
+	"regexp"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type DaoBookmark struct {
@@ -114,6 +116,22 @@ func (m *DaoBookmark) FindList(ctx context.Context, db *mongo.Database, filter i
 	return
 }
 
+// ListByDao the followers of the dao after the given bookmark, in the order of _id.
+func (m *DaoBookmark) ListByDao(ctx context.Context, db *mongo.Database, after primitive.ObjectID, limit int) ([]*DaoBookmark, error) {
+	filter := bson.M{"dao_id": m.DaoID, "is_del": 0}
+	if !after.IsZero() {
+		filter["_id"] = bson.M{"$gt": after}
+	}
+	opts := options.Find().SetSort(bson.M{"_id": 1}).SetLimit(int64(limit))
+	cursor, err := db.Collection(m.Table()).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	list := []*DaoBookmark{}
+	err = cursor.All(ctx, &list)
+	return list, err
+}
+
 func (m *DaoBookmark) GetList(ctx context.Context, db *mongo.Database, pipeline interface{}) (list []*DaoFormatted) {
 	list = []*DaoFormatted{}
 	cursor, err := db.Collection(m.Table()).Aggregate(ctx, pipeline)
@@ -145,3 +163,54 @@ func (m *DaoBookmark) CountMark(ctx context.Context, db *mongo.Database) int64 {
 	}
 	return documents
 }
+
+// DaoFollower the bookmark with the user who follows the DAO
+type DaoFollower struct {
+	DaoBookmark `bson:",inline"`
+	Users       []*User `bson:"user"`
+}
+
+// ListFollowers the followers of the dao, the latest first. The keyword matches the nickname or the address.
+func (m *DaoBookmark) ListFollowers(ctx context.Context, db *mongo.Database, keyword string, offset, limit int) ([]*DaoFollower, int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"dao_id": m.DaoID, "is_del": 0}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         new(User).Table(),
+			"localField":   "address",
+			"foreignField": "address",
+			"as":           "user",
+		}}},
+	}
+	if keyword != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(keyword), Options: "i"}
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"$or": bson.A{
+			bson.M{"address": pattern},
+			bson.M{"user.nickname": pattern},
+		}}}})
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$facet", Value: bson.M{
+		"total": bson.A{bson.M{"$count": "count"}},
+		"list": bson.A{
+			bson.M{"$sort": bson.M{"_id": -1}},
+			bson.M{"$skip": offset},
+			bson.M{"$limit": limit},
+		},
+	}}})
+	cursor, err := db.Collection(m.Table()).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	var res []struct {
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+		List []*DaoFollower `bson:"list"`
+	}
+	if err = cursor.All(ctx, &res); err != nil {
+		return nil, 0, err
+	}
+	if len(res) == 0 || len(res[0].Total) == 0 {
+		return []*DaoFollower{}, 0, nil
+	}
+	return res[0].List, res[0].Total[0].Count, nil
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DaoFollowEvent a follow or an unfollow of a DAO. The bookmark is revived on a re-follow, so the
+// events are what the daily rollups count.
+type DaoFollowEvent struct {
+	ID        primitive.ObjectID `json:"id"         bson:"_id,omitempty"`
+	CreatedOn int64              `json:"created_on" bson:"created_on"`
+	DaoID     primitive.ObjectID `json:"dao_id"     bson:"dao_id"`
+	Address   string             `json:"address"    bson:"address"`
+	Follow    bool               `json:"follow"     bson:"follow"`
+}
+
+func (m *DaoFollowEvent) Table() string {
+	return "dao_follow_event"
+}
+
+func (m *DaoFollowEvent) Create(ctx context.Context, db *mongo.Database) error {
+	m.CreatedOn = time.Now().Unix()
+	res, err := db.Collection(m.Table()).InsertOne(ctx, m)
+	if err != nil {
+		return err
+	}
+	m.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
@@ -0,0 +1,160 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DaoJoinT uint8
+
+const (
+	DaoJoinPending DaoJoinT = iota
+	DaoJoinApproved
+	DaoJoinRejected
+)
+
+// DaoJoinRequest the request to join a private DAO, reviewed by the roles granted DaoPermApproveJoin
+type DaoJoinRequest struct {
+	DefaultModel `bson:",inline"`
+	DaoID        primitive.ObjectID `json:"dao_id"      bson:"dao_id"`
+	Address      string             `json:"address"     bson:"address"`
+	Message      string             `json:"message"     bson:"message"`
+	Status       DaoJoinT           `json:"status"      bson:"status"`
+	ReviewedBy   string             `json:"reviewed_by" bson:"reviewed_by"`
+	ReviewedOn   int64              `json:"reviewed_on" bson:"reviewed_on"`
+}
+
+func (m *DaoJoinRequest) Table() string {
+	return "dao_join_request"
+}
+
+func (m *DaoJoinRequest) Create(ctx context.Context, db *mongo.Database) error {
+	return create(ctx, db, m)
+}
+
+func (m *DaoJoinRequest) Get(ctx context.Context, db *mongo.Database) error {
+	return findOne(ctx, db, m, bson.M{ID: m.ID, "dao_id": m.DaoID})
+}
+
+// Pending the request of the address waiting for review
+func (m *DaoJoinRequest) Pending(ctx context.Context, db *mongo.Database) error {
+	return findOne(ctx, db, m, bson.M{"dao_id": m.DaoID, "address": m.Address, "status": DaoJoinPending})
+}
+
+// Review close the pending request, it returns mongo.ErrNoDocuments when it was reviewed already.
+func (m *DaoJoinRequest) Review(ctx context.Context, db *mongo.Database, status DaoJoinT, by string) error {
+	now := time.Now().Unix()
+	err := findAndUpdate(ctx, db, m,
+		bson.M{ID: m.ID, "status": DaoJoinPending},
+		bson.M{"$set": bson.M{"status": status, "reviewed_by": by, "reviewed_on": now, UpdatedAtField: now}},
+	)
+	if err != nil {
+		return err
+	}
+	m.Status = status
+	m.ReviewedBy = by
+	m.ReviewedOn = now
+	return nil
+}
+
+func (m *DaoJoinRequest) List(ctx context.Context, db *mongo.Database, offset, limit int) ([]*DaoJoinRequest, int64, error) {
+	filter := bson.M{"dao_id": m.DaoID, "status": m.Status}
+	total, err := db.Collection(m.Table()).CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().SetSort(bson.M{"_id": 1}).SetSkip(int64(offset)).SetLimit(int64(limit))
+	cursor, err := find(ctx, db, m, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	list := []*DaoJoinRequest{}
+	err = cursor.All(ctx, &list)
+	return list, total, err
+}
+
+// DaoInvite the invite code of a private DAO, the holder joins without review
+type DaoInvite struct {
+	DefaultModel `bson:",inline"`
+	DaoID        primitive.ObjectID `json:"dao_id"     bson:"dao_id"`
+	Code         string             `json:"code"       bson:"code"`
+	CreatedBy    string             `json:"created_by" bson:"created_by"`
+	MaxUses      int64              `json:"max_uses"   bson:"max_uses"`
+	Uses         int64              `json:"uses"       bson:"uses"`
+	ExpiredOn    int64              `json:"expired_on" bson:"expired_on"`
+}
+
+func (m *DaoInvite) Table() string {
+	return "dao_invite"
+}
+
+func (m *DaoInvite) Create(ctx context.Context, db *mongo.Database) error {
+	return create(ctx, db, m)
+}
+
+// Use take one use of the code, it returns mongo.ErrNoDocuments when the code is
+// unknown, expired or used up.
+func (m *DaoInvite) Use(ctx context.Context, db *mongo.Database) error {
+	now := time.Now().Unix()
+	return findAndUpdate(ctx, db, m,
+		bson.M{
+			"dao_id":     m.DaoID,
+			"code":       m.Code,
+			"expired_on": bson.M{"$gt": now},
+			"$expr":      bson.M{"$lt": bson.A{"$uses", "$max_uses"}},
+		},
+		bson.M{"$inc": bson.M{"uses": 1}, "$set": bson.M{UpdatedAtField: now}},
+	)
+}
+
+// Release give back the use taken by a join which failed
+func (m *DaoInvite) Release(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection(m.Table()).UpdateOne(ctx,
+		bson.M{"dao_id": m.DaoID, "code": m.Code, "uses": bson.M{"$gt": 0}},
+		bson.M{"$inc": bson.M{"uses": -1}},
+	)
+	return err
+}
+
+func (m *DaoInvite) Delete(ctx context.Context, db *mongo.Database) error {
+	res, err := db.Collection(m.Table()).DeleteOne(ctx, bson.M{"dao_id": m.DaoID, "code": m.Code})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// List the invite codes of the DAO which are still usable
+func (m *DaoInvite) List(ctx context.Context, db *mongo.Database) ([]*DaoInvite, error) {
+	filter := bson.M{"dao_id": m.DaoID, "expired_on": bson.M{"$gt": time.Now().Unix()}}
+	cursor, err := find(ctx, db, m, filter, options.Find().SetSort(bson.M{"_id": -1}))
+	if err != nil {
+		return nil, err
+	}
+	list := []*DaoInvite{}
+	err = cursor.All(ctx, &list)
+	return list, err
+}
+
+// PrivateIDs the DAOs which need a review to join
+func (m *Dao) PrivateIDs(ctx context.Context, db *mongo.Database) ([]primitive.ObjectID, error) {
+	values, err := db.Collection(m.Table()).Distinct(ctx, "_id", bson.M{"visibility": DaoVisitPrivate, "is_del": 0})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,165 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DaoRole the role of an address in the DAO, the owner is Dao.Address and
+// the followers without an assigned role are members.
+type DaoRole string
+
+const (
+	DaoRoleNone      DaoRole = ""
+	DaoRoleOwner     DaoRole = "owner"
+	DaoRoleAdmin     DaoRole = "admin"
+	DaoRoleModerator DaoRole = "moderator"
+	DaoRoleMember    DaoRole = "member"
+)
+
+// DaoPermission an action of the DAO which is granted per role
+type DaoPermission string
+
+const (
+	DaoPermPost          DaoPermission = "post"
+	DaoPermPin           DaoPermission = "pin"
+	DaoPermSettings      DaoPermission = "settings"
+	DaoPermKickChat      DaoPermission = "kick_chat"
+	DaoPermDeleteComment DaoPermission = "delete_comment"
+	DaoPermApproveJoin   DaoPermission = "approve_join"
+)
+
+var DaoPermissions = []DaoPermission{
+	DaoPermPost,
+	DaoPermPin,
+	DaoPermSettings,
+	DaoPermKickChat,
+	DaoPermDeleteComment,
+	DaoPermApproveJoin,
+}
+
+// DefaultDaoGrants are used by the DAO without its own grants, the owner is always granted everything.
+var DefaultDaoGrants = map[DaoRole][]DaoPermission{
+	DaoRoleAdmin:     {DaoPermPost, DaoPermPin, DaoPermSettings, DaoPermKickChat, DaoPermDeleteComment, DaoPermApproveJoin},
+	DaoRoleModerator: {DaoPermPin, DaoPermKickChat, DaoPermDeleteComment, DaoPermApproveJoin},
+}
+
+// Rank orders the roles, a higher role includes the lower ones when managing roles
+func (r DaoRole) Rank() int {
+	switch r {
+	case DaoRoleOwner:
+		return 4
+	case DaoRoleAdmin:
+		return 3
+	case DaoRoleModerator:
+		return 2
+	case DaoRoleMember:
+		return 1
+	}
+	return 0
+}
+
+// Assignable the roles stored in dao_member, the owner changes only by a transfer
+func (r DaoRole) Assignable() bool {
+	return r == DaoRoleAdmin || r == DaoRoleModerator || r == DaoRoleMember
+}
+
+func (p DaoPermission) Valid() bool {
+	for _, v := range DaoPermissions {
+		if v == p {
+			return true
+		}
+	}
+	return false
+}
+
+type DaoMember struct {
+	DefaultModel `bson:",inline"`
+	DaoID        primitive.ObjectID `json:"dao_id"     bson:"dao_id"`
+	Address      string             `json:"address"    bson:"address"`
+	Role         DaoRole            `json:"role"       bson:"role"`
+	GrantedBy    string             `json:"granted_by" bson:"granted_by"`
+}
+
+func (m *DaoMember) Table() string {
+	return "dao_member"
+}
+
+// SetRole assign the role, back to a plain member removes the record.
+func (m *DaoMember) SetRole(ctx context.Context, db *mongo.Database) error {
+	if m.Role == DaoRoleMember {
+		return m.Delete(ctx, db)
+	}
+	filter := bson.M{"dao_id": m.DaoID, "address": m.Address}
+	now := time.Now().Unix()
+	_, err := db.Collection(m.Table()).UpdateOne(ctx, filter,
+		bson.M{
+			"$setOnInsert": bson.M{CreatedAtField: now},
+			"$set":         bson.M{UpdatedAtField: now, "role": m.Role, "granted_by": m.GrantedBy},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// Delete remove the role record of the address, none is fine
+func (m *DaoMember) Delete(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection(m.Table()).DeleteOne(ctx, bson.M{"dao_id": m.DaoID, "address": m.Address})
+	return err
+}
+
+func (m *DaoMember) First(ctx context.Context, db *mongo.Database) error {
+	return findOne(ctx, db, m, bson.M{"dao_id": m.DaoID, "address": m.Address})
+}
+
+// List the addresses with an assigned role in the DAO
+func (m *DaoMember) List(ctx context.Context, db *mongo.Database) ([]*DaoMember, error) {
+	cursor, err := find(ctx, db, m, bson.M{"dao_id": m.DaoID}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	list := []*DaoMember{}
+	err = cursor.All(ctx, &list)
+	return list, err
+}
+
+// RoleOf the role of the address in the DAO, DaoRoleNone when it does not follow the DAO.
+func (m *Dao) RoleOf(ctx context.Context, db *mongo.Database, address string) (DaoRole, error) {
+	if address == "" {
+		return DaoRoleNone, nil
+	}
+	if m.Address == address {
+		return DaoRoleOwner, nil
+	}
+	member := &DaoMember{DaoID: m.ID, Address: address}
+	err := member.First(ctx, db)
+	if err == nil {
+		return member.Role, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return DaoRoleNone, err
+	}
+	if _, err = (&DaoBookmark{}).GetByAddress(ctx, db, address, m.ID.Hex()); err == nil {
+		return DaoRoleMember, nil
+	}
+	return DaoRoleNone, nil
+}
+
+// RoleAllow the role is granted the permission in the DAO
+func (m *Dao) RoleAllow(role DaoRole, perm DaoPermission) bool {
+	if role == DaoRoleOwner {
+		return true
+	}
+	for _, v := range m.DaoGrants()[role] {
+		if v == perm {
+			return true
+		}
+	}
+	return false
+}
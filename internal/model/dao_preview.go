@@ -0,0 +1,46 @@
+package model
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SetPreview save the preview of the home page, it returns mongo.ErrNoDocuments when
+// the home page was changed after the fetch.
+func (m *Dao) SetPreview(ctx context.Context, db *mongo.Database, preview *DaoPreview) error {
+	res, err := db.Collection(m.Table()).UpdateOne(ctx,
+		bson.M{ID: m.ID, "homePage": preview.URL},
+		bson.M{"$set": bson.M{"preview": preview}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	m.Preview = preview
+	return nil
+}
+
+// FindStalePreviews the DAOs with a home page whose preview is missing or fetched before
+func (m *Dao) FindStalePreviews(ctx context.Context, db *mongo.Database, before int64, limit int64) ([]*Dao, error) {
+	filter := bson.M{
+		"type":     DaoWithURL,
+		"is_del":   0,
+		"homePage": bson.M{"$nin": bson.A{"", nil}},
+		"$or": bson.A{
+			bson.M{"preview": bson.M{"$exists": false}},
+			bson.M{"preview.fetched_on": bson.M{"$lt": before}},
+		},
+	}
+	cursor, err := db.Collection(m.Table()).Find(ctx, filter, options.Find().SetLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	var list []*Dao
+	err = cursor.All(ctx, &list)
+	return list, err
+}
@@ -0,0 +1,344 @@
+package model
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const DaoStatsTopPosts = 10
+
+// DaoStats the daily rollup of a DAO, Day is the unix time of 00:00 UTC.
+type DaoStats struct {
+	DefaultModel `bson:",inline"`
+	DaoID        primitive.ObjectID `json:"dao_id"        bson:"dao_id"`
+	Day          int64              `json:"day"           bson:"day"`
+	// Followers at the end of the day
+	Followers     int64  `json:"followers"     bson:"followers"`
+	Follows       int64  `json:"follows"       bson:"follows"`
+	Unfollows     int64  `json:"unfollows"     bson:"unfollows"`
+	Subscriptions int64  `json:"subscriptions" bson:"subscriptions"`
+	Revenue       string `json:"revenue"       bson:"revenue"`
+	// ViewTotal the views of all the posts when the day was rolled up last, Views the growth from the day before
+	ViewTotal   int64           `json:"view_total"    bson:"view_total"`
+	Views       int64           `json:"views"         bson:"views"`
+	Upvotes     int64           `json:"upvotes"       bson:"upvotes"`
+	Comments    int64           `json:"comments"      bson:"comments"`
+	Collections int64           `json:"collections"   bson:"collections"`
+	TopPosts    []*DaoStatsPost `json:"top_posts,omitempty" bson:"top_posts"`
+}
+
+// DaoStatsPost the engagement of a post in the period
+type DaoStatsPost struct {
+	PostID      primitive.ObjectID `json:"post_id"     bson:"post_id"`
+	Upvotes     int64              `json:"upvotes"     bson:"upvotes"`
+	Comments    int64              `json:"comments"    bson:"comments"`
+	Collections int64              `json:"collections" bson:"collections"`
+}
+
+func (p *DaoStatsPost) Score() int64 {
+	return p.Upvotes + p.Comments + p.Collections
+}
+
+// TopDaoStatsPosts the posts with the most engagement first, at most n
+func TopDaoStatsPosts(posts map[primitive.ObjectID]*DaoStatsPost, n int) []*DaoStatsPost {
+	list := make([]*DaoStatsPost, 0, len(posts))
+	for _, p := range posts {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Score() != list[j].Score() {
+			return list[i].Score() > list[j].Score()
+		}
+		return list[i].PostID.Hex() > list[j].PostID.Hex()
+	})
+	if len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
+
+func (m *DaoStats) Table() string {
+	return "dao_stats"
+}
+
+// Save upsert the rollup of the day, the views are kept unless withViews
+func (m *DaoStats) Save(ctx context.Context, db *mongo.Database, withViews bool) error {
+	now := time.Now().Unix()
+	set := bson.M{
+		UpdatedAtField:  now,
+		"followers":     m.Followers,
+		"follows":       m.Follows,
+		"unfollows":     m.Unfollows,
+		"subscriptions": m.Subscriptions,
+		"revenue":       m.Revenue,
+		"upvotes":       m.Upvotes,
+		"comments":      m.Comments,
+		"collections":   m.Collections,
+		"top_posts":     m.TopPosts,
+	}
+	if withViews {
+		set["view_total"] = m.ViewTotal
+		set["views"] = m.Views
+	}
+	_, err := db.Collection(m.Table()).UpdateOne(ctx,
+		bson.M{"dao_id": m.DaoID, "day": m.Day},
+		bson.M{"$setOnInsert": bson.M{CreatedAtField: now}, "$set": set},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// ViewTotals the view totals of the day by DAO
+func (m *DaoStats) ViewTotals(ctx context.Context, db *mongo.Database, day int64) (map[primitive.ObjectID]int64, error) {
+	cursor, err := find(ctx, db, m, bson.M{"day": day}, options.Find().SetProjection(bson.M{"dao_id": 1, "view_total": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var list []*DaoStats
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	totals := make(map[primitive.ObjectID]int64, len(list))
+	for _, v := range list {
+		totals[v.DaoID] = v.ViewTotal
+	}
+	return totals, nil
+}
+
+// List the days of the DAO in [from, to]
+func (m *DaoStats) List(ctx context.Context, db *mongo.Database, from, to int64) ([]*DaoStats, error) {
+	filter := bson.M{"dao_id": m.DaoID, "day": bson.M{"$gte": from, "$lte": to}}
+	cursor, err := find(ctx, db, m, filter, options.Find().SetSort(bson.M{"day": 1}))
+	if err != nil {
+		return nil, err
+	}
+	list := []*DaoStats{}
+	err = cursor.All(ctx, &list)
+	return list, err
+}
+
+// FillDaoStatsDays one rollup a day of [from, to] from the rollups sorted by day, the days without
+// a rollup had no activity and carry the followers and the view total of the day before.
+func FillDaoStatsDays(daoID primitive.ObjectID, from, to time.Time, days []*DaoStats) []*DaoStats {
+	list := make([]*DaoStats, 0, len(days))
+	var last *DaoStats
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		for len(days) > 0 && days[0].Day < day.Unix() {
+			days = days[1:]
+		}
+		if len(days) > 0 && days[0].Day == day.Unix() {
+			last = days[0]
+			days = days[1:]
+			list = append(list, last)
+			continue
+		}
+		stats := &DaoStats{DaoID: daoID, Day: day.Unix(), Revenue: "0"}
+		if last != nil {
+			stats.Followers = last.Followers
+			stats.ViewTotal = last.ViewTotal
+		}
+		last = stats
+		list = append(list, stats)
+	}
+	return list
+}
+
+// countByDao run the pipeline which groups the documents by dao_id into count
+func countByDao(ctx context.Context, db *mongo.Database, table string, pipeline mongo.Pipeline) (map[primitive.ObjectID]int64, error) {
+	cursor, err := db.Collection(table).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var res []struct {
+		DaoID primitive.ObjectID `bson:"_id"`
+		Count int64              `bson:"count"`
+	}
+	if err = cursor.All(ctx, &res); err != nil {
+		return nil, err
+	}
+	counts := make(map[primitive.ObjectID]int64, len(res))
+	for _, v := range res {
+		counts[v.DaoID] = v.Count
+	}
+	return counts, nil
+}
+
+func groupCountByDao() bson.D {
+	return bson.D{{Key: "$group", Value: bson.M{"_id": "$dao_id", "count": bson.M{"$sum": 1}}}}
+}
+
+// DailyFollows the follows and unfollows of [start, end), and the followers at end. The followers
+// are the live ones now with the events since end undone.
+func (m *DaoBookmark) DailyFollows(ctx context.Context, db *mongo.Database, start, end int64) (follows, unfollows, followers map[primitive.ObjectID]int64, err error) {
+	events := (&DaoFollowEvent{}).Table()
+	countEvents := func(follow bool, period bson.M) (map[primitive.ObjectID]int64, error) {
+		return countByDao(ctx, db, events, mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"follow": follow, "created_on": period}}},
+			groupCountByDao(),
+		})
+	}
+	if follows, err = countEvents(true, bson.M{"$gte": start, "$lt": end}); err != nil {
+		return
+	}
+	if unfollows, err = countEvents(false, bson.M{"$gte": start, "$lt": end}); err != nil {
+		return
+	}
+	followsSince, err := countEvents(true, bson.M{"$gte": end})
+	if err != nil {
+		return
+	}
+	unfollowsSince, err := countEvents(false, bson.M{"$gte": end})
+	if err != nil {
+		return
+	}
+	live, err := countByDao(ctx, db, m.Table(), mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"is_del": 0}}},
+		groupCountByDao(),
+	})
+	if err != nil {
+		return
+	}
+	followers = FollowersAt(live, followsSince, unfollowsSince)
+	return
+}
+
+// FollowersAt the followers at a time in the past from the live followers and the follows and
+// unfollows since then
+func FollowersAt(live, followsSince, unfollowsSince map[primitive.ObjectID]int64) map[primitive.ObjectID]int64 {
+	followers := make(map[primitive.ObjectID]int64, len(live))
+	for id, n := range live {
+		followers[id] = n
+	}
+	for id, n := range followsSince {
+		followers[id] -= n
+	}
+	for id, n := range unfollowsSince {
+		followers[id] += n
+	}
+	for id, n := range followers {
+		if n <= 0 {
+			delete(followers, id)
+		}
+	}
+	return followers
+}
+
+// DailyRevenue the paid subscriptions ordered in [start, end) and the sum of their amounts
+func (m *DaoSubscribe) DailyRevenue(ctx context.Context, db *mongo.Database, start, end int64) (map[primitive.ObjectID]int64, map[primitive.ObjectID]string, error) {
+	cursor, err := db.Collection(m.Table()).Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			CreatedAtField: bson.M{"$gte": start, "$lt": end},
+			"status":       bson.M{"$in": bson.A{DaoSubscribeSuccess, DaoSubscribeExpired}},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    "$dao_id",
+			"count":  bson.M{"$sum": 1},
+			"amount": bson.M{"$sum": bson.M{"$toDecimal": "$pay_amount"}},
+		}}},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var res []struct {
+		DaoID  primitive.ObjectID   `bson:"_id"`
+		Count  int64                `bson:"count"`
+		Amount primitive.Decimal128 `bson:"amount"`
+	}
+	if err = cursor.All(ctx, &res); err != nil {
+		return nil, nil, err
+	}
+	counts := make(map[primitive.ObjectID]int64, len(res))
+	amounts := make(map[primitive.ObjectID]string, len(res))
+	for _, v := range res {
+		counts[v.DaoID] = v.Count
+		amounts[v.DaoID] = v.Amount.String()
+	}
+	return counts, amounts, nil
+}
+
+// ViewTotals the views of all the posts by DAO
+func (p *Post) ViewTotals(ctx context.Context, db *mongo.Database) (map[primitive.ObjectID]int64, error) {
+	cursor, err := db.Collection(p.Table()).Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"is_del": 0}}},
+		{{Key: "$group", Value: bson.M{"_id": "$dao_id", "count": bson.M{"$sum": "$view_count"}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var res []struct {
+		DaoID primitive.ObjectID `bson:"_id"`
+		Count int64              `bson:"count"`
+	}
+	if err = cursor.All(ctx, &res); err != nil {
+		return nil, err
+	}
+	totals := make(map[primitive.ObjectID]int64, len(res))
+	for _, v := range res {
+		totals[v.DaoID] = v.Count
+	}
+	return totals, nil
+}
+
+// DailyPostEngagement the upvotes, comments and collections created in [start, end) by DAO and post.
+// The stars and collections have no timestamps, the time of their _id is used.
+func DailyPostEngagement(ctx context.Context, db *mongo.Database, start, end int64) (map[primitive.ObjectID]map[primitive.ObjectID]*DaoStatsPost, error) {
+	idRange := bson.M{
+		"$gte": primitive.NewObjectIDFromTimestamp(time.Unix(start, 0)),
+		"$lt":  primitive.NewObjectIDFromTimestamp(time.Unix(end, 0)),
+	}
+	stats := map[primitive.ObjectID]map[primitive.ObjectID]*DaoStatsPost{}
+	sources := []struct {
+		table string
+		match bson.M
+		add   func(p *DaoStatsPost, n int64)
+	}{
+		{new(PostStar).Table(), bson.M{"_id": idRange, "is_del": 0}, func(p *DaoStatsPost, n int64) { p.Upvotes += n }},
+		{new(Comment).Table(), bson.M{"_id": idRange, "is_del": 0}, func(p *DaoStatsPost, n int64) { p.Comments += n }},
+		{new(PostCollection).Table(), bson.M{"_id": idRange}, func(p *DaoStatsPost, n int64) { p.Collections += n }},
+	}
+	for _, src := range sources {
+		cursor, err := db.Collection(src.table).Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$match", Value: src.match}},
+			{{Key: "$group", Value: bson.M{"_id": "$post_id", "count": bson.M{"$sum": 1}}}},
+			{{Key: "$lookup", Value: bson.M{
+				"from":         new(Post).Table(),
+				"localField":   "_id",
+				"foreignField": "_id",
+				"as":           "post",
+			}}},
+			{{Key: "$unwind", Value: "$post"}},
+			{{Key: "$project", Value: bson.M{"count": 1, "dao_id": "$post.dao_id"}}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		var res []struct {
+			PostID primitive.ObjectID `bson:"_id"`
+			DaoID  primitive.ObjectID `bson:"dao_id"`
+			Count  int64              `bson:"count"`
+		}
+		if err = cursor.All(ctx, &res); err != nil {
+			return nil, err
+		}
+		for _, v := range res {
+			posts, ok := stats[v.DaoID]
+			if !ok {
+				posts = map[primitive.ObjectID]*DaoStatsPost{}
+				stats[v.DaoID] = posts
+			}
+			p, ok := posts[v.PostID]
+			if !ok {
+				p = &DaoStatsPost{PostID: v.PostID}
+				posts[v.PostID] = p
+			}
+			src.add(p, v.Count)
+		}
+	}
+	return stats, nil
+}
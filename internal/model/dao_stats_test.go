@@ -0,0 +1,70 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFollowersAt(t *testing.T) {
+	a, b, c := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	live := map[primitive.ObjectID]int64{a: 5, b: 2}
+	// a: one re-followed since, b: all followed since, c: the only follower left since
+	followsSince := map[primitive.ObjectID]int64{a: 1, b: 2}
+	unfollowsSince := map[primitive.ObjectID]int64{a: 1, c: 1}
+
+	followers := FollowersAt(live, followsSince, unfollowsSince)
+	want := map[primitive.ObjectID]int64{a: 5, c: 1}
+	if len(followers) != len(want) {
+		t.Fatalf("got %v, want %v", followers, want)
+	}
+	for id, n := range want {
+		if followers[id] != n {
+			t.Errorf("dao %s: got %d followers, want %d", id.Hex(), followers[id], n)
+		}
+	}
+}
+
+func TestFillDaoStatsDays(t *testing.T) {
+	daoID := primitive.NewObjectID()
+	from := time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)
+	day := func(n int) int64 {
+		return from.AddDate(0, 0, n).Unix()
+	}
+	days := []*DaoStats{
+		{DaoID: daoID, Day: day(1), Followers: 3, Follows: 3, ViewTotal: 10, Revenue: "5"},
+		{DaoID: daoID, Day: day(4), Followers: 2, Unfollows: 1, ViewTotal: 12, Revenue: "0"},
+	}
+
+	list := FillDaoStatsDays(daoID, from, from.AddDate(0, 0, 5), days)
+	if len(list) != 6 {
+		t.Fatalf("got %d days, want 6", len(list))
+	}
+	for i, s := range list {
+		if s.Day != day(i) || s.DaoID != daoID {
+			t.Errorf("day %d: got %+v", i, s)
+		}
+	}
+	if list[1] != days[0] || list[4] != days[1] {
+		t.Error("the rollups are not kept")
+	}
+	tests := []struct {
+		i                    int
+		followers, viewTotal int64
+	}{
+		{0, 0, 0},
+		{2, 3, 10},
+		{3, 3, 10},
+		{5, 2, 12},
+	}
+	for _, tt := range tests {
+		s := list[tt.i]
+		if s.Follows != 0 || s.Unfollows != 0 || s.Views != 0 || s.Revenue != "0" {
+			t.Errorf("day %d: want no activity got %+v", tt.i, s)
+		}
+		if s.Followers != tt.followers || s.ViewTotal != tt.viewTotal {
+			t.Errorf("day %d: got followers %d view total %d, want %d %d", tt.i, s.Followers, s.ViewTotal, tt.followers, tt.viewTotal)
+		}
+	}
+}
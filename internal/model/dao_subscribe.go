@@ -2,10 +2,12 @@ package model
 
 import (
 	"context"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type DaoSubscribeT uint8
@@ -15,6 +17,7 @@ const (
 	DaoSubscribeSuccess
 	DaoSubscribeFailed
 	DaoSubscribeRefund
+	DaoSubscribeExpired
 )
 
 type DaoSubscribe struct {
@@ -24,6 +27,43 @@ type DaoSubscribe struct {
 	TxID         string             `json:"tx_id"            bson:"tx_id"`
 	PayAmount    string             `json:"pay_amount"       bson:"pay_amount"`
 	Status       DaoSubscribeT      `json:"status"           bson:"status"`
+	Tier         PostMemberT        `json:"tier"             bson:"tier"`
+	// Duration of the subscription in seconds, 0 lifetime
+	Duration int64 `json:"duration"         bson:"duration"`
+	// StartOn the subscription starts at the time of payment or later, e.g. the expiry of the renewed one
+	StartOn   int64 `json:"start_on"         bson:"start_on"`
+	ExpiredOn int64 `json:"expired_on"       bson:"expired_on"`
+	AutoRenew bool  `json:"auto_renew"       bson:"auto_renew"`
+	// RenewedBy the order which renews this subscription
+	RenewedBy string `json:"renewed_by,omitempty" bson:"renewed_by,omitempty"`
+	// RefundOn the time the refund is started, 0 never refunded
+	RefundOn int64 `json:"refund_on"        bson:"refund_on"`
+	// RefundOrder the bind order of the refund, each attempt has its own
+	RefundOrder  string    `json:"refund_order"     bson:"refund_order"`
+	RefundBy     string    `json:"refund_by"        bson:"refund_by"`
+	RefundReason string    `json:"refund_reason"    bson:"refund_reason"`
+	RefundTxID   string    `json:"refund_tx_id"     bson:"refund_tx_id"`
+	RefundStatus PayStatus `json:"refund_status"    bson:"refund_status"`
+}
+
+// ActiveSubscribeFilter subscriptions paid and not expired at the given time
+func ActiveSubscribeFilter(now int64) bson.M {
+	return bson.M{
+		"status": DaoSubscribeSuccess,
+		"$or": []bson.M{
+			{"expired_on": bson.M{"$exists": false}},
+			{"expired_on": 0},
+			{"expired_on": bson.M{"$gt": now}},
+		},
+	}
+}
+
+// ActiveTier subscriptions bought before the tiers were introduced unlock all the member content
+func (m *DaoSubscribe) ActiveTier() PostMemberT {
+	if m.Tier == PostMemberNothing {
+		return PostMember3
+	}
+	return m.Tier
 }
 
 func (m *DaoSubscribe) Table() string {
@@ -63,3 +103,143 @@ func (m *DaoSubscribe) FindList(ctx context.Context, db *mongo.Database, filter
 	}
 	return
 }
+
+// FindPage the orders of the filter, the latest first
+func (m *DaoSubscribe) FindPage(ctx context.Context, db *mongo.Database, filter interface{}, offset, limit int) ([]*DaoSubscribe, int64, error) {
+	total, err := db.Collection(m.Table()).CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().SetSort(bson.M{CreatedAtField: -1}).SetSkip(int64(offset)).SetLimit(int64(limit))
+	cursor, err := find(ctx, db, m, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	list := []*DaoSubscribe{}
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// Settle finish the order with the result of the payment, a paid subscription
+// starts now or at StartOn whichever is later.
+func (m *DaoSubscribe) Settle(ctx context.Context, db *mongo.Database, txID string, status DaoSubscribeT) error {
+	now := time.Now().Unix()
+	set := bson.M{
+		UpdatedAtField: now,
+		"tx_id":        txID,
+		"status":       status,
+	}
+	if status == DaoSubscribeSuccess && m.Duration > 0 {
+		start := m.StartOn
+		if start < now {
+			start = now
+		}
+		m.ExpiredOn = start + m.Duration
+		set["expired_on"] = m.ExpiredOn
+	}
+	m.TxID = txID
+	m.Status = status
+	return findAndUpdate(ctx, db, m, bson.M{ID: m.ID}, bson.M{"$set": set})
+}
+
+// MarkRenewed link the renewal order, it returns mongo.ErrNoDocuments when the subscription
+// was renewed already.
+func (m *DaoSubscribe) MarkRenewed(ctx context.Context, db *mongo.Database, orderID string) error {
+	return findAndUpdate(ctx, db, m,
+		bson.M{ID: m.ID, "renewed_by": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"renewed_by": orderID, UpdatedAtField: time.Now().Unix()}},
+	)
+}
+
+// Expire mark the subscriptions expired at the given time.
+func (m *DaoSubscribe) Expire(ctx context.Context, db *mongo.Database, now int64) (int64, error) {
+	res, err := db.Collection(m.Table()).UpdateMany(ctx,
+		bson.M{"status": DaoSubscribeSuccess, "expired_on": bson.M{"$gt": 0, "$lte": now}},
+		bson.M{"$set": bson.M{"status": DaoSubscribeExpired, UpdatedAtField: time.Now().Unix()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// FindToRenew list the auto renew subscriptions which expire before the given time and are not renewed yet.
+func (m *DaoSubscribe) FindToRenew(ctx context.Context, db *mongo.Database, before int64, limit int64) (list []*DaoSubscribe, err error) {
+	filter := bson.M{
+		"status":     DaoSubscribeSuccess,
+		"auto_renew": true,
+		"expired_on": bson.M{"$gt": 0, "$lte": before},
+		"renewed_by": bson.M{"$exists": false},
+	}
+	opts := options.Find().SetSort(bson.M{"expired_on": 1}).SetLimit(limit)
+	cursor, err := find(ctx, db, m, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	list = []*DaoSubscribe{}
+	err = cursor.All(ctx, &list)
+	return
+}
+
+// StartRefund mark the subscription refunding, it returns mongo.ErrNoDocuments when the subscription
+// is not paid or a refund is in progress or done already. A failed refund can be started again.
+func (m *DaoSubscribe) StartRefund(ctx context.Context, db *mongo.Database, by, reason string) error {
+	filter := bson.M{
+		ID:       m.ID,
+		"status": DaoSubscribeSuccess,
+		"$or": []bson.M{
+			{"refund_on": bson.M{"$exists": false}},
+			{"refund_on": 0},
+			{"refund_status": PayFailed},
+		},
+	}
+	m.RefundOn = time.Now().Unix()
+	m.RefundOrder = primitive.NewObjectID().Hex()
+	m.RefundBy = by
+	m.RefundReason = reason
+	m.RefundStatus = PaySubmit
+	update := bson.M{"$set": bson.M{
+		UpdatedAtField:  m.RefundOn,
+		"refund_on":     m.RefundOn,
+		"refund_order":  m.RefundOrder,
+		"refund_by":     by,
+		"refund_reason": reason,
+		"refund_tx_id":  "",
+		"refund_status": PaySubmit,
+	}}
+	return findAndUpdate(ctx, db, m, filter, update)
+}
+
+func (m *DaoSubscribe) SetRefundTxID(ctx context.Context, db *mongo.Database, txID string) error {
+	m.RefundTxID = txID
+	return findAndUpdate(ctx, db, m,
+		bson.M{ID: m.ID},
+		bson.M{"$set": bson.M{"refund_tx_id": txID, UpdatedAtField: time.Now().Unix()}},
+	)
+}
+
+// SettleRefund finish the refund with the result of the payment, a refunded subscription
+// stops unlocking the member content and is not renewed.
+func (m *DaoSubscribe) SettleRefund(ctx context.Context, db *mongo.Database, txID string, status PayStatus) error {
+	set := bson.M{
+		UpdatedAtField:  time.Now().Unix(),
+		"refund_tx_id":  txID,
+		"refund_status": status,
+	}
+	if status == PaySuccess {
+		set["status"] = DaoSubscribeRefund
+		set["auto_renew"] = false
+	}
+	res := db.Collection(m.Table()).FindOneAndUpdate(ctx,
+		bson.M{ID: m.ID, "refund_order": m.RefundOrder, "refund_status": PaySubmit},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+	if res.Err() != nil {
+		return res.Err()
+	}
+	return res.Decode(m)
+}
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestDao_FindTier(t *testing.T) {
+	legacy := &Dao{Price: "10000"}
+	tier, err := legacy.FindTier(PostMemberNothing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tier.Level != PostMember3 || tier.Price != "10000" || tier.Days != 0 {
+		t.Errorf("unexpected legacy tier %+v", tier)
+	}
+
+	dao := &Dao{Tiers: []DaoTier{
+		{Level: PostMember1, Price: "100", Days: 30},
+		{Level: PostMember3, Price: "500", Days: 30},
+	}}
+	tier, err = dao.FindTier(PostMemberNothing)
+	if err != nil || tier.Level != PostMember1 {
+		t.Errorf("want the lowest tier got %+v %v", tier, err)
+	}
+	tier, err = dao.FindTier(PostMember3)
+	if err != nil || tier.Price != "500" {
+		t.Errorf("want tier 3 got %+v %v", tier, err)
+	}
+	if _, err = dao.FindTier(PostMember2); err != ErrNoSuchDaoTier {
+		t.Errorf("want ErrNoSuchDaoTier got %v", err)
+	}
+
+	// no tier asked is the cheapest one unlocking the content of the first level
+	dao = &Dao{Tiers: []DaoTier{
+		{Level: PostMember2, Price: "200", Days: 30},
+		{Level: PostMember3, Price: "500", Days: 30},
+	}}
+	tier, err = dao.FindTier(PostMemberNothing)
+	if err != nil || tier.Level != PostMember2 || !PostMember1.Allow(tier.Level) {
+		t.Errorf("want tier 2 got %+v %v", tier, err)
+	}
+	if got := PostMemberNothing.Subscribed(); got != PostMember1 {
+		t.Errorf("no tier subscribes to %d, want %d", got, PostMember1)
+	}
+}
+
+func TestPostMemberT_Allow(t *testing.T) {
+	tests := []struct {
+		member, tier PostMemberT
+		allow        bool
+	}{
+		{PostMemberNothing, PostMemberNothing, true},
+		{PostMember1, PostMemberNothing, false},
+		{PostMember1, PostMember1, true},
+		{PostMember2, PostMember1, false},
+		{PostMember2, PostMember3, true},
+	}
+	for _, test := range tests {
+		if got := test.member.Allow(test.tier); got != test.allow {
+			t.Errorf("member %d tier %d: want %v got %v", test.member, test.tier, test.allow, got)
+		}
+	}
+}
+
+func TestDao_RoleAllow(t *testing.T) {
+	dao := &Dao{}
+	if !dao.RoleAllow(DaoRoleOwner, DaoPermSettings) {
+		t.Error("owner must be granted everything")
+	}
+	if !dao.RoleAllow(DaoRoleModerator, DaoPermDeleteComment) || dao.RoleAllow(DaoRoleModerator, DaoPermSettings) {
+		t.Error("moderator must follow the default grants")
+	}
+	if dao.RoleAllow(DaoRoleMember, DaoPermPost) || dao.RoleAllow(DaoRoleNone, DaoPermPost) {
+		t.Error("member must not be granted by default")
+	}
+
+	dao.Grants = map[DaoRole][]DaoPermission{DaoRoleMember: {DaoPermPost}}
+	if !dao.RoleAllow(DaoRoleMember, DaoPermPost) {
+		t.Error("member must follow the grants of the DAO")
+	}
+	if dao.RoleAllow(DaoRoleAdmin, DaoPermPost) {
+		t.Error("the grants of the DAO replace the default ones")
+	}
+	if !dao.RoleAllow(DaoRoleOwner, DaoPermKickChat) {
+		t.Error("owner must be granted whatever the grants")
+	}
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DaoTransferT uint8
+
+const (
+	DaoTransferPending DaoTransferT = iota
+	DaoTransferAccepted
+	DaoTransferCancelled
+	DaoTransferExpired
+)
+
+// DaoTransfer the ownership transfer of a DAO, it is signed by the owner and the recipient
+// and is kept as the audit record of the change.
+type DaoTransfer struct {
+	DefaultModel `bson:",inline"`
+	DaoID        primitive.ObjectID `json:"dao_id"        bson:"dao_id"`
+	From         string             `json:"from"          bson:"from"`
+	To           string             `json:"to"            bson:"to"`
+	Status       DaoTransferT       `json:"status"        bson:"status"`
+	ExpiredOn    int64              `json:"expired_on"    bson:"expired_on"`
+	FromSign     TransferSign       `json:"from_sign"     bson:"from_sign"`
+	ToSign       *TransferSign      `json:"to_sign"       bson:"to_sign,omitempty"`
+	FinishedOn   int64              `json:"finished_on"   bson:"finished_on"`
+	// FinishedBy the address closed the transfer, empty when it expired
+	FinishedBy string `json:"finished_by"   bson:"finished_by"`
+}
+
+// TransferSign the wallet signature of the transfer message
+type TransferSign struct {
+	Type      string `json:"type"      bson:"type"`
+	Timestamp int64  `json:"timestamp" bson:"timestamp"`
+	Signature string `json:"signature" bson:"signature"`
+}
+
+func (m *DaoTransfer) Table() string {
+	return "dao_transfer"
+}
+
+func (m *DaoTransfer) Create(ctx context.Context, db *mongo.Database) error {
+	return create(ctx, db, m)
+}
+
+// Pending the transfer of the DAO waiting for the recipient
+func (m *DaoTransfer) Pending(ctx context.Context, db *mongo.Database) error {
+	return findOne(ctx, db, m, bson.M{"dao_id": m.DaoID, "status": DaoTransferPending})
+}
+
+// Finish close the pending transfer, it returns mongo.ErrNoDocuments when the transfer
+// was closed already.
+func (m *DaoTransfer) Finish(ctx context.Context, db *mongo.Database, status DaoTransferT, by string) error {
+	now := time.Now().Unix()
+	set := bson.M{
+		UpdatedAtField: now,
+		"status":       status,
+		"finished_on":  now,
+		"finished_by":  by,
+	}
+	if m.ToSign != nil {
+		set["to_sign"] = m.ToSign
+	}
+	err := findAndUpdate(ctx, db, m, bson.M{ID: m.ID, "status": DaoTransferPending}, bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	m.Status = status
+	m.FinishedOn = now
+	m.FinishedBy = by
+	return nil
+}
+
+// Expire mark the pending transfers expired at the given time.
+func (m *DaoTransfer) Expire(ctx context.Context, db *mongo.Database, now int64) (int64, error) {
+	res, err := db.Collection(m.Table()).UpdateMany(ctx,
+		bson.M{"status": DaoTransferPending, "expired_on": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"status": DaoTransferExpired, "finished_on": now, UpdatedAtField: now}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// List the transfers of the DAO, the latest first
+func (m *DaoTransfer) List(ctx context.Context, db *mongo.Database) ([]*DaoTransfer, error) {
+	cursor, err := find(ctx, db, m, bson.M{"dao_id": m.DaoID}, options.Find().SetSort(bson.M{"_id": -1}))
+	if err != nil {
+		return nil, err
+	}
+	list := []*DaoTransfer{}
+	err = cursor.All(ctx, &list)
+	return list, err
+}
@@ -48,6 +48,18 @@ func (m *MsgSend) Create(ctx context.Context, db *mongo.Database) (*MsgSend, err
 	return m, nil
 }
 
+// CreateMany insert a batch of sends sharing the same message.
+func (m *MsgSend) CreateMany(ctx context.Context, db *mongo.Database, list []*MsgSend) error {
+	now := time.Now().Unix()
+	docs := make([]interface{}, 0, len(list))
+	for _, v := range list {
+		v.CreatedAt = now
+		docs = append(docs, v)
+	}
+	_, err := db.Collection(m.Table()).InsertMany(ctx, docs)
+	return err
+}
+
 func (m *MsgSend) Get(db *mongo.Database, conditions *ConditionsT) (*MsgSend, error) {
 	var query bson.M
 	for k, v := range *conditions {
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NotifyMute the user does not receive the notifications of From (a dao, user or organ)
+type NotifyMute struct {
+	DefaultModel `bson:",inline"`
+	UserID       primitive.ObjectID `json:"user_id" bson:"user_id"`
+	From         primitive.ObjectID `json:"from"    bson:"from"`
+}
+
+func (m *NotifyMute) Table() string {
+	return "notify_mute"
+}
+
+func (m *NotifyMute) Mute(ctx context.Context, db *mongo.Database) error {
+	now := time.Now().Unix()
+	_, err := db.Collection(m.Table()).UpdateOne(ctx,
+		bson.M{"user_id": m.UserID, "from": m.From},
+		bson.M{
+			"$setOnInsert": bson.M{CreatedAtField: now},
+			"$set":         bson.M{UpdatedAtField: now},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (m *NotifyMute) Unmute(ctx context.Context, db *mongo.Database) error {
+	_, err := db.Collection(m.Table()).DeleteOne(ctx, bson.M{"user_id": m.UserID, "from": m.From})
+	return err
+}
+
+func (m *NotifyMute) IsMuted(ctx context.Context, db *mongo.Database) (bool, error) {
+	count, err := db.Collection(m.Table()).CountDocuments(ctx, bson.M{"user_id": m.UserID, "from": m.From})
+	return count > 0, err
+}
+
+// MutedUsers the users in userIDs who muted From
+func (m *NotifyMute) MutedUsers(ctx context.Context, db *mongo.Database, userIDs []primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	cursor, err := find(ctx, db, m, bson.M{"from": m.From, "user_id": bson.M{"$in": userIDs}})
+	if err != nil {
+		return nil, err
+	}
+	var list []*NotifyMute
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	muted := make(map[primitive.ObjectID]bool, len(list))
+	for _, v := range list {
+		muted[v.UserID] = true
+	}
+	return muted, nil
+}
+
+func (m *NotifyMute) List(ctx context.Context, db *mongo.Database) ([]*NotifyMute, error) {
+	cursor, err := find(ctx, db, m, bson.M{"user_id": m.UserID}, options.Find().SetSort(bson.M{"_id": -1}))
+	if err != nil {
+		return nil, err
+	}
+	list := []*NotifyMute{}
+	err = cursor.All(ctx, &list)
+	return list, err
+}
@@ -89,3 +89,29 @@ func (o *Organ) List(db *mongo.Database, conditions *ConditionsT) (*[]Organ, err
 	}
 	return &organs, nil
 }
+
+func (o *Organ) Create(ctx context.Context, db *mongo.Database) error {
+	res, err := db.Collection(o.Table()).InsertOne(ctx, o)
+	if err != nil {
+		return err
+	}
+	o.ID = res.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+// Update save the name, the avatar and the visibility, the key is never changed
+// since the notifications refer to it.
+func (o *Organ) Update(ctx context.Context, db *mongo.Database) error {
+	res, err := db.Collection(o.Table()).UpdateOne(ctx, bson.M{"_id": o.ID}, bson.M{"$set": bson.M{
+		"name":   o.Name,
+		"avatar": o.Avatar,
+		"isShow": o.IsShow,
+	}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PayNotifyAudit a pay notify callback rejected by the signature check
+type PayNotifyAudit struct {
+	DefaultModel `bson:",inline"`
+	Method       string `json:"method"    bson:"method"`
+	OrderID      string `json:"order_id"  bson:"order_id"`
+	TxID         string `json:"tx_id"     bson:"tx_id"`
+	TxStatus     string `json:"tx_status" bson:"tx_status"`
+	Query        string `json:"query"     bson:"query"`
+	RemoteIP     string `json:"remote_ip" bson:"remote_ip"`
+	Reason       string `json:"reason"    bson:"reason"`
+}
+
+func (m *PayNotifyAudit) Table() string {
+	return "pay_notify_audit"
+}
+
+func (m *PayNotifyAudit) Create(ctx context.Context, db *mongo.Database) error {
+	return create(ctx, db, m)
+}
@@ -0,0 +1,124 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PayOrderStatus is the state of an order waiting for the point system.
+//
+//	Submit -> Settling -> Success
+//	                   -> Failed
+//	Settling -> Submit (settle handler failed, retry later)
+type PayOrderStatus uint8
+
+const (
+	PayOrderSubmit PayOrderStatus = iota
+	PayOrderSettling
+	PayOrderSuccess
+	PayOrderFailed
+)
+
+// PayOrder tracks a payment submitted to the point system until its callback
+// arrives or the reconciler settles it.
+type PayOrder struct {
+	DefaultModel  `bson:",inline"`
+	Method        string         `json:"method"          bson:"method"`
+	OrderID       string         `json:"order_id"        bson:"order_id"`
+	TxID          string         `json:"tx_id"           bson:"tx_id"`
+	TxStatus      string         `json:"tx_status"       bson:"tx_status"`
+	Status        PayOrderStatus `json:"status"          bson:"status"`
+	Attempts      int            `json:"attempts"        bson:"attempts"`
+	LastCheckedOn int64          `json:"last_checked_on" bson:"last_checked_on"`
+}
+
+func (m *PayOrder) Table() string {
+	return "pay_order"
+}
+
+func (m *PayOrder) Create(ctx context.Context, db *mongo.Database) error {
+	m.Status = PayOrderSubmit
+	return create(ctx, db, m)
+}
+
+func (m *PayOrder) FindOne(ctx context.Context, db *mongo.Database, filter interface{}) error {
+	return findOne(ctx, db, m, filter)
+}
+
+// First load the order by method and order_id.
+func (m *PayOrder) First(ctx context.Context, db *mongo.Database) error {
+	return findOne(ctx, db, m, bson.M{"method": m.Method, "order_id": m.OrderID})
+}
+
+// SetTxID record the tx id returned by the point system.
+func (m *PayOrder) SetTxID(ctx context.Context, db *mongo.Database, txID string) error {
+	m.TxID = txID
+	return findAndUpdate(ctx, db, m,
+		bson.M{"method": m.Method, "order_id": m.OrderID},
+		bson.M{"$set": bson.M{"tx_id": txID, UpdatedAtField: time.Now().Unix()}},
+	)
+}
+
+// Transit move the order from one status to another. It returns mongo.ErrNoDocuments
+// when the order is not in the from status, so only one caller wins a transition.
+func (m *PayOrder) Transit(ctx context.Context, db *mongo.Database, from, to PayOrderStatus) error {
+	set := bson.M{"status": to, UpdatedAtField: time.Now().Unix()}
+	if m.TxID != "" {
+		set["tx_id"] = m.TxID
+	}
+	if m.TxStatus != "" {
+		set["tx_status"] = m.TxStatus
+	}
+	res := db.Collection(m.Table()).FindOneAndUpdate(ctx,
+		bson.M{"method": m.Method, "order_id": m.OrderID, "status": from},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	)
+	if res.Err() != nil {
+		return res.Err()
+	}
+	return res.Decode(m)
+}
+
+// Checked record a reconcile attempt.
+func (m *PayOrder) Checked(ctx context.Context, db *mongo.Database) error {
+	m.Attempts++
+	m.LastCheckedOn = time.Now().Unix()
+	return findAndUpdate(ctx, db, m,
+		bson.M{ID: m.ID, "status": PayOrderSubmit},
+		bson.M{"$inc": bson.M{"attempts": 1}, "$set": bson.M{"last_checked_on": m.LastCheckedOn}},
+	)
+}
+
+// ResetStale put orders stuck in settling (e.g. the node crashed while settling) back to submit.
+func (m *PayOrder) ResetStale(ctx context.Context, db *mongo.Database, before int64) (int64, error) {
+	res, err := db.Collection(m.Table()).UpdateMany(ctx,
+		bson.M{"status": PayOrderSettling, UpdatedAtField: bson.M{"$lt": before}},
+		bson.M{"$set": bson.M{"status": PayOrderSubmit, UpdatedAtField: time.Now().Unix()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// FindPending list orders still waiting for the point system which were created before the given time.
+func (m *PayOrder) FindPending(ctx context.Context, db *mongo.Database, before int64, limit int64) (list []*PayOrder, err error) {
+	filter := bson.M{
+		"status":       PayOrderSubmit,
+		CreatedAtField: bson.M{"$lt": before},
+	}
+	opts := options.Find().SetSort(bson.M{"last_checked_on": 1}).SetLimit(limit)
+	cursor, err := find(ctx, db, m, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	list = []*PayOrder{}
+	err = cursor.All(ctx, &list)
+	return
+}
@@ -24,6 +24,23 @@ const (
 	// PostVisitInvalid
 )
 
+// PinScope where the post is pinned
+type PinScope uint8
+
+const (
+	// PinPlatform on the home page of the platform, only the admins pin there
+	PinPlatform PinScope = iota
+	// PinDao on the page of the DAO of the post
+	PinDao
+)
+
+func (s PinScope) Field() string {
+	if s == PinDao {
+		return "dao_top"
+	}
+	return "is_top"
+}
+
 type PostMemberT uint8
 
 const (
@@ -33,6 +50,19 @@ const (
 	PostMember3
 )
 
+// Allow the member content is visible to the subscriber of the tier
+func (m PostMemberT) Allow(tier PostMemberT) bool {
+	return m == PostMemberNothing || tier >= m
+}
+
+// Subscribed the level a subscription asked for without a tier stands for, the lowest one
+func (m PostMemberT) Subscribed() PostMemberT {
+	if m == PostMemberNothing {
+		return PostMember1
+	}
+	return m
+}
+
 type Post struct {
 	ID              primitive.ObjectID `json:"id"                bson:"_id,omitempty"`
 	CreatedOn       int64              `json:"created_on"        bson:"created_on"`
@@ -50,6 +80,9 @@ type Post struct {
 	Member          PostMemberT        `json:"member"            bson:"member"`
 	Visibility      PostVisibleT       `json:"visibility"        bson:"visibility"`
 	IsTop           int                `json:"is_top"            bson:"is_top"`
+	TopExpiredOn    int64              `json:"top_expired_on"    bson:"top_expired_on"`
+	DaoTop          int                `json:"dao_top"           bson:"dao_top"`
+	DaoTopExpiredOn int64              `json:"dao_top_expired_on" bson:"dao_top_expired_on"`
 	IsEssence       int                `json:"is_essence"        bson:"is_essence"`
 	Tags            string             `json:"tags"              bson:"tags"`
 	Type            PostType           `json:"type"              bson:"type"`
@@ -60,12 +93,18 @@ type Post struct {
 	AuthorDaoId     primitive.ObjectID `json:"author_dao_id"     bson:"author_dao_id"`
 	RefId           primitive.ObjectID `json:"ref_id"            bson:"ref_id"`
 	RefType         PostRefType        `json:"ref_type"          bson:"ref_type"`
+	ScheduledOn     int64              `json:"scheduled_on"      bson:"scheduled_on"`
+	// PublishVisibility the visibility the draft goes out with, public when unset
+	PublishVisibility PostVisibleT `json:"publish_visibility,omitempty" bson:"publish_visibility,omitempty"`
+	// Gate the post is only for the holders of the token, the gate of the DAO applies otherwise
+	Gate *TokenGate `json:"gate,omitempty" bson:"gate,omitempty"`
 }
 
 type PostFormatted struct {
 	ID              primitive.ObjectID      `json:"id"`
 	CreatedOn       int64                   `json:"created_on"`
 	ModifiedOn      int64                   `json:"modified_on"`
+	Edited          bool                    `json:"edited"`
 	LatestRepliedOn int64                   `json:"latest_replied_on"`
 	DaoId           primitive.ObjectID      `json:"dao_id"`
 	Dao             *DaoFormatted           `json:"dao"`
@@ -81,6 +120,9 @@ type PostFormatted struct {
 	RefCount        int64                   `json:"ref_count"`
 	Visibility      PostVisibleT            `json:"visibility"`
 	IsTop           int                     `json:"is_top"`
+	TopExpiredOn    int64                   `json:"top_expired_on"`
+	DaoTop          int                     `json:"dao_top"`
+	DaoTopExpiredOn int64                   `json:"dao_top_expired_on"`
 	IsEssence       int                     `json:"is_essence"`
 	Tags            map[string]int8         `json:"tags"`
 	Type            PostType                `json:"type"`
@@ -93,6 +135,11 @@ type PostFormatted struct {
 	AuthorDao       *DaoFormatted           `json:"author_dao"`
 	RefId           primitive.ObjectID      `json:"ref_id"`
 	RefType         PostRefType             `json:"ref_type"`
+	ScheduledOn     int64                   `json:"scheduled_on"`
+	Gate            *TokenGate              `json:"gate,omitempty"`
+	Locked          bool                    `json:"locked"`
+	// PublishVisibility of the draft
+	PublishVisibility PostVisibleT `json:"publish_visibility,omitempty"`
 }
 
 func (p *Post) Table() string {
@@ -120,11 +167,16 @@ func (p *Post) Format() *PostFormatted {
 		RefCount:        p.RefCount,
 		Visibility:      p.Visibility,
 		IsTop:           p.IsTop,
+		TopExpiredOn:    p.TopExpiredOn,
+		DaoTop:          p.DaoTop,
+		DaoTopExpiredOn: p.DaoTopExpiredOn,
 		IsEssence:       p.IsEssence,
 		Tags:            tagsMap,
 		Type:            p.Type,
 		OrigType:        p.OrigType,
 		CreatedOn:       p.CreatedOn,
+		ModifiedOn:      p.ModifiedOn,
+		Edited:          p.IsEdited(),
 		OrigCreatedAt:   p.OrigCreatedAt,
 		AuthorId:        p.AuthorId,
 		AuthorDaoId:     p.AuthorDaoId,
@@ -132,9 +184,26 @@ func (p *Post) Format() *PostFormatted {
 		AuthorDao:       &DaoFormatted{},
 		RefId:           p.RefId,
 		RefType:         p.RefType,
+		ScheduledOn:     p.ScheduledOn,
+		Gate:            p.Gate,
+		// the drafts only
+		PublishVisibility: p.PublishVisibility,
 	}
 }
 
+// PublishedVisibility the visibility of the draft once it is published
+func (p *Post) PublishedVisibility() PostVisibleT {
+	if p.PublishVisibility == PostVisitDraft {
+		return PostVisitPublic
+	}
+	return p.PublishVisibility
+}
+
+// IsEdited the modified_on only moves when the author edits the post
+func (p *Post) IsEdited() bool {
+	return p.ModifiedOn > p.CreatedOn
+}
+
 func (p *Post) Create(ctx context.Context, db *mongo.Database) (*Post, error) {
 	now := time.Now().Unix()
 	p.CreatedOn = now
@@ -246,6 +315,46 @@ func (p *Post) List(db *mongo.Database, conditions *ConditionsT, offset, limit i
 	return posts, nil
 }
 
+// Pinned the post is pinned in the scope and when the pin expires, 0 never
+func (p *Post) Pinned(scope PinScope) (bool, int64) {
+	if scope == PinDao {
+		return p.DaoTop == 1, p.DaoTopExpiredOn
+	}
+	return p.IsTop == 1, p.TopExpiredOn
+}
+
+// SetPin pin the post in the scope until expiredOn, or unpin it
+func (p *Post) SetPin(scope PinScope, pinned bool, expiredOn int64) {
+	top := 0
+	if pinned {
+		top = 1
+	} else {
+		expiredOn = 0
+	}
+	if scope == PinDao {
+		p.DaoTop, p.DaoTopExpiredOn = top, expiredOn
+	} else {
+		p.IsTop, p.TopExpiredOn = top, expiredOn
+	}
+}
+
+// ListPinnedFirst the posts matching the query, the pinned ones in the scope first and then the latest
+func (p *Post) ListPinnedFirst(ctx context.Context, db *mongo.Database, query bson.M, scope PinScope, offset, limit int) ([]*Post, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: scope.Field(), Value: -1}, {Key: "created_on", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	cursor, err := db.Collection(p.Table()).Find(ctx, findQuery([]bson.M{query}), opts)
+	if err != nil {
+		return nil, err
+	}
+	posts := []*Post{}
+	if err = cursor.All(ctx, &posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
 func (p *Post) Count(db *mongo.Database, conditions *ConditionsT) (int64, error) {
 
 	var query bson.M
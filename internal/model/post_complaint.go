@@ -2,17 +2,58 @@ package model
 
 import (
 	"context"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+type ComplaintT uint8
+
+const (
+	ComplaintPending ComplaintT = iota
+	ComplaintResolved
+)
+
+// ComplaintAction the outcome of the complaints of a post
+type ComplaintAction string
+
+const (
+	ComplaintDismiss   ComplaintAction = "dismiss"
+	ComplaintHide      ComplaintAction = "hide"
+	ComplaintBlacklist ComplaintAction = "blacklist"
+	ComplaintWarn      ComplaintAction = "warn"
+	ComplaintSuspend   ComplaintAction = "suspend"
+)
+
+func (a ComplaintAction) Valid() bool {
+	switch a {
+	case ComplaintDismiss, ComplaintHide, ComplaintBlacklist, ComplaintWarn, ComplaintSuspend:
+		return true
+	}
+	return false
+}
+
 type PostComplaint struct {
 	DefaultModel `bson:",inline"`
 	Address      string             `json:"address"          bson:"address"`
 	PostID       primitive.ObjectID `json:"post_id"          bson:"post_id"`
 	Reason       string             `json:"reason"           bson:"reason"`
+	Status       ComplaintT         `json:"status"           bson:"status"`
+	Action       ComplaintAction    `json:"action,omitempty" bson:"action,omitempty"`
+	ResolvedBy   string             `json:"resolved_by"      bson:"resolved_by,omitempty"`
+	ResolvedOn   int64              `json:"resolved_on"      bson:"resolved_on,omitempty"`
+}
+
+// ComplaintGroup the pending complaints of a post
+type ComplaintGroup struct {
+	PostID    primitive.ObjectID `json:"post_id"    bson:"_id"`
+	Count     int64              `json:"count"      bson:"count"`
+	Reporters int64              `json:"reporters"  bson:"reporters"`
+	Reasons   []string           `json:"reasons"    bson:"reasons"`
+	FirstOn   int64              `json:"first_on"   bson:"first_on"`
+	LastOn    int64              `json:"last_on"    bson:"last_on"`
 }
 
 func (m *PostComplaint) Table() string {
@@ -36,3 +77,80 @@ func (m *PostComplaint) Get(ctx context.Context, db *mongo.Database) error {
 func (m *PostComplaint) FindOne(ctx context.Context, db *mongo.Database, filter interface{}) error {
 	return findOne(ctx, db, m, filter)
 }
+
+// pendingComplaint the complaints created before the moderation have no status
+func pendingComplaint() bson.M {
+	return bson.M{"status": bson.M{"$ne": ComplaintResolved}}
+}
+
+// Queue the posts with pending complaints, the most reported first
+func (m *PostComplaint) Queue(ctx context.Context, db *mongo.Database, offset, limit int) ([]*ComplaintGroup, int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: pendingComplaint()}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       "$post_id",
+			"count":     bson.M{"$sum": 1},
+			"reporters": bson.M{"$addToSet": "$address"},
+			"reasons":   bson.M{"$addToSet": "$reason"},
+			"first_on":  bson.M{"$min": "$" + CreatedAtField},
+			"last_on":   bson.M{"$max": "$" + CreatedAtField},
+		}}},
+		{{Key: "$set", Value: bson.M{"reporters": bson.M{"$size": "$reporters"}}}},
+		{{Key: "$facet", Value: bson.M{
+			"total": bson.A{bson.M{"$count": "count"}},
+			"list": bson.A{
+				bson.M{"$sort": bson.D{{Key: "reporters", Value: -1}, {Key: "last_on", Value: -1}, {Key: "_id", Value: 1}}},
+				bson.M{"$skip": offset},
+				bson.M{"$limit": limit},
+			},
+		}}},
+	}
+	cursor, err := db.Collection(m.Table()).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	var res []struct {
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+		List []*ComplaintGroup `bson:"list"`
+	}
+	if err = cursor.All(ctx, &res); err != nil {
+		return nil, 0, err
+	}
+	if len(res) == 0 || len(res[0].Total) == 0 {
+		return []*ComplaintGroup{}, 0, nil
+	}
+	return res[0].List, res[0].Total[0].Count, nil
+}
+
+// Pending the pending complaints of the post
+func (m *PostComplaint) Pending(ctx context.Context, db *mongo.Database) ([]*PostComplaint, error) {
+	filter := pendingComplaint()
+	filter["post_id"] = m.PostID
+	cursor, err := find(ctx, db, m, filter)
+	if err != nil {
+		return nil, err
+	}
+	list := []*PostComplaint{}
+	err = cursor.All(ctx, &list)
+	return list, err
+}
+
+// Resolve close the pending complaints of the post with the action
+func (m *PostComplaint) Resolve(ctx context.Context, db *mongo.Database, action ComplaintAction, by string) (int64, error) {
+	now := time.Now().Unix()
+	filter := pendingComplaint()
+	filter["post_id"] = m.PostID
+	res, err := db.Collection(m.Table()).UpdateMany(ctx, filter, bson.M{"$set": bson.M{
+		"status":       ComplaintResolved,
+		"action":       action,
+		"resolved_by":  by,
+		"resolved_on":  now,
+		UpdatedAtField: now,
+	}})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
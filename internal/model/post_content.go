@@ -9,7 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// Type, 1 title, 2 text paragraph, 3 picture address, 4 video address, 5 voice address, 6 link address, 7 attachment resource
+// Type, 1 title, 2 text paragraph, 3 picture address, 4 video address, 5 voice address, 6 link address, 7 attachment resource, 8 poll
 
 type PostContentT int
 
@@ -21,6 +21,7 @@ const (
 	CONTENT_TYPE_FAVOR
 	CONTENT_TYPE_AUDIO
 	CONTENT_TYPE_LINK
+	CONTENT_TYPE_POLL
 )
 
 var (
@@ -55,6 +56,8 @@ type PostContent struct {
 	Type    PostContentT       `json:"type"    bson:"type"`
 	Sort    int64              `json:"sort"    bson:"sort"`
 	IsDel   int                `json:"is_del"  bson:"is_del"`
+	// Poll of the CONTENT_TYPE_POLL content, the content is its question
+	Poll *Poll `json:"poll,omitempty" bson:"poll,omitempty"`
 }
 
 type PostContentFormatted struct {
@@ -64,6 +67,7 @@ type PostContentFormatted struct {
 	Content string             `json:"content"`
 	Type    PostContentT       `json:"type"`
 	Sort    int64              `json:"sort"`
+	Poll    *Poll              `json:"poll,omitempty"`
 }
 
 func (p *PostContent) Table() string {
@@ -116,6 +120,7 @@ func (p *PostContent) Format() *PostContentFormatted {
 		Content: p.Content,
 		Type:    p.Type,
 		Sort:    p.Sort,
+		Poll:    p.Poll.Format(),
 	}
 }
 
@@ -0,0 +1,134 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"favor-dao-backend/pkg/util"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Poll the poll of a CONTENT_TYPE_POLL content, the counts are kept next to the options
+type Poll struct {
+	Options  []*PollOption `json:"options"  bson:"options"`
+	Multiple bool          `json:"multiple" bson:"multiple"`
+	// MembersOnly only the subscribers of the DAO of the post vote
+	MembersOnly bool  `json:"members_only" bson:"members_only"`
+	CloseOn     int64 `json:"close_on"     bson:"close_on"`
+	Closed      bool  `json:"closed"       bson:"closed"`
+	Voters      int64 `json:"voters"       bson:"voters"`
+	// Voted the options chosen by the viewer
+	Voted []int `json:"voted,omitempty" bson:"-"`
+}
+
+type PollOption struct {
+	Text  string `json:"text"  bson:"text"`
+	Votes int64  `json:"votes" bson:"votes"`
+}
+
+// IsClosed the poll is closed by its job or its close time is passed
+func (p *Poll) IsClosed() bool {
+	return p.Closed || (p.CloseOn > 0 && time.Now().Unix() >= p.CloseOn)
+}
+
+// Format a copy for the viewer, the viewer's choices are not shared
+func (p *Poll) Format() *Poll {
+	if p == nil {
+		return nil
+	}
+	poll := *p
+	poll.Closed = p.IsClosed()
+	poll.Voted = nil
+	return &poll
+}
+
+// ClosePoll mark the poll of the content closed
+func (p *PostContent) ClosePoll(ctx context.Context, db *mongo.Database) error {
+	filter := bson.M{"_id": p.ID, "type": CONTENT_TYPE_POLL, "is_del": 0}
+	res, err := db.Collection(p.Table()).UpdateOne(ctx, filter, bson.M{"$set": bson.M{"poll.closed": true}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// PollVote the vote of a wallet on a poll, a wallet votes once on each poll
+type PollVote struct {
+	DefaultModel `bson:",inline"`
+	ContentID    primitive.ObjectID `json:"content_id" bson:"content_id"`
+	PostID       primitive.ObjectID `json:"post_id"    bson:"post_id"`
+	Address      string             `json:"address"    bson:"address"`
+	Options      []int              `json:"options"    bson:"options"`
+}
+
+func (m *PollVote) Table() string {
+	return "post_poll_vote"
+}
+
+// Cast keep the vote and count it on the poll at once, the unique index refuses the second
+// vote of the wallet and a closed poll matches nothing.
+func (m *PollVote) Cast(ctx context.Context, db *mongo.Database) error {
+	return util.MongoTransaction(ctx, db, func(ctx context.Context) error {
+		if err := create(ctx, db, m); err != nil {
+			return err
+		}
+		inc := bson.M{"poll.voters": 1}
+		for _, i := range m.Options {
+			inc[fmt.Sprintf("poll.options.%d.votes", i)] = 1
+		}
+		filter := bson.M{"_id": m.ContentID, "type": CONTENT_TYPE_POLL, "is_del": 0, "poll.closed": false}
+		res, err := db.Collection(new(PostContent).Table()).UpdateOne(ctx, filter, bson.M{"$inc": inc})
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount == 0 {
+			return mongo.ErrNoDocuments
+		}
+		return nil
+	})
+}
+
+// FindByContents the votes of the address on the polls of the contents
+func (m *PollVote) FindByContents(ctx context.Context, db *mongo.Database, contentIDs []primitive.ObjectID) ([]*PollVote, error) {
+	cursor, err := find(ctx, db, m, bson.M{"address": m.Address, "content_id": bson.M{"$in": contentIDs}})
+	if err != nil {
+		return nil, err
+	}
+	list := []*PollVote{}
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// FillPollVoted set the choices of the address on the polls among the contents
+func FillPollVoted(ctx context.Context, db *mongo.Database, address string, contents []*PostContentFormatted) error {
+	if address == "" {
+		return nil
+	}
+	polls := make(map[primitive.ObjectID]*Poll)
+	ids := make([]primitive.ObjectID, 0)
+	for _, content := range contents {
+		if content.Poll != nil {
+			polls[content.ID] = content.Poll
+			ids = append(ids, content.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	votes, err := (&PollVote{Address: address}).FindByContents(ctx, db, ids)
+	if err != nil {
+		return err
+	}
+	for _, vote := range votes {
+		polls[vote.ContentID].Voted = vote.Options
+	}
+	return nil
+}
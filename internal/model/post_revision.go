@@ -0,0 +1,50 @@
+package model
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PostRevision a version of the post replaced by an edit, the live version is the post itself
+type PostRevision struct {
+	DefaultModel `bson:",inline"`
+	PostID       primitive.ObjectID `json:"post_id"  bson:"post_id"`
+	Address      string             `json:"address"  bson:"address"`
+	// Version starts at 1 with the version published first
+	Version  int            `json:"version"  bson:"version"`
+	Tags     string         `json:"tags"     bson:"tags"`
+	Contents []*PostContent `json:"contents" bson:"contents"`
+	// EditedOn the time the version was published or edited
+	EditedOn int64 `json:"edited_on" bson:"edited_on"`
+}
+
+func (m *PostRevision) Table() string {
+	return "post_revision"
+}
+
+func (m *PostRevision) Create(ctx context.Context, db *mongo.Database) error {
+	return create(ctx, db, m)
+}
+
+// Count the revisions of the post
+func (m *PostRevision) Count(ctx context.Context, db *mongo.Database) (int64, error) {
+	return db.Collection(m.Table()).CountDocuments(ctx, bson.M{"post_id": m.PostID})
+}
+
+// List the revisions of the post, the latest first
+func (m *PostRevision) List(ctx context.Context, db *mongo.Database, offset, limit int) ([]*PostRevision, error) {
+	opts := options.Find().SetSort(bson.M{"version": -1}).SetSkip(int64(offset)).SetLimit(int64(limit))
+	cursor, err := find(ctx, db, m, bson.M{"post_id": m.PostID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	list := []*PostRevision{}
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,43 @@
+package model
+
+import "testing"
+
+func TestPost_SetPin(t *testing.T) {
+	p := &Post{}
+	p.SetPin(PinDao, true, 100)
+	if pinned, expiredOn := p.Pinned(PinDao); !pinned || expiredOn != 100 {
+		t.Errorf("dao pin = %v %d, want true 100", pinned, expiredOn)
+	}
+	if pinned, _ := p.Pinned(PinPlatform); pinned {
+		t.Error("the dao pin must not pin on the platform")
+	}
+
+	p.SetPin(PinPlatform, true, 0)
+	if pinned, expiredOn := p.Pinned(PinPlatform); !pinned || expiredOn != 0 {
+		t.Errorf("platform pin = %v %d, want true 0", pinned, expiredOn)
+	}
+
+	p.SetPin(PinDao, false, 200)
+	if pinned, expiredOn := p.Pinned(PinDao); pinned || expiredOn != 0 {
+		t.Errorf("dao unpin = %v %d, want false 0", pinned, expiredOn)
+	}
+	if p.IsTop != 1 {
+		t.Error("the dao unpin must keep the platform pin")
+	}
+}
+
+func TestPost_PublishedVisibility(t *testing.T) {
+	tests := []struct {
+		publish, want PostVisibleT
+	}{
+		{PostVisitDraft, PostVisitPublic},
+		{PostVisitPublic, PostVisitPublic},
+		{PostVisitPrivate, PostVisitPrivate},
+	}
+	for _, tt := range tests {
+		p := &Post{Visibility: PostVisitDraft, PublishVisibility: tt.publish}
+		if got := p.PublishedVisibility(); got != tt.want {
+			t.Errorf("draft for %d published as %d, want %d", tt.publish, got, tt.want)
+		}
+	}
+}
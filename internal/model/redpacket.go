@@ -6,6 +6,7 @@ import (
 
 	"favor-dao-backend/pkg/convert"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -31,6 +32,9 @@ type Redpacket struct {
 	RefundTxID   string        `json:"refund_tx_id" bson:"refund_tx_id"`
 	RefundStatus PayStatus     `json:"refund_status" bson:"refund_status"`
 	IsTimeout    bool          `json:"is_timeout" bson:"is_timeout"`
+	// DaoID the redpacket is sent in the DAO, its banned addresses can not claim it. Zero on
+	// the redpackets sent outside of a DAO.
+	DaoID primitive.ObjectID `json:"dao_id,omitempty" bson:"dao_id,omitempty"`
 }
 
 type RedpacketSendFormatted struct {
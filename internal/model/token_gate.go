@@ -0,0 +1,42 @@
+package model
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type TokenStandard string
+
+const (
+	TokenERC20  TokenStandard = "erc20"
+	TokenERC721 TokenStandard = "erc721"
+)
+
+// TokenGate the content is only for the holders of at least Threshold of the token
+type TokenGate struct {
+	Contract string        `json:"contract" bson:"contract"`
+	Standard TokenStandard `json:"standard" bson:"standard"`
+	// Threshold in the smallest unit of an ERC-20, the count of the NFTs of an ERC-721
+	Threshold string `json:"threshold" bson:"threshold"`
+}
+
+// MinBalance the threshold, false when it is not a positive integer
+func (g *TokenGate) MinBalance() (*big.Int, bool) {
+	threshold, ok := new(big.Int).SetString(g.Threshold, 10)
+	if !ok || threshold.Sign() <= 0 {
+		return nil, false
+	}
+	return threshold, true
+}
+
+func (g *TokenGate) Valid() bool {
+	if g.Standard != TokenERC20 && g.Standard != TokenERC721 {
+		return false
+	}
+	if !common.IsHexAddress(g.Contract) {
+		return false
+	}
+	_, ok := g.MinBalance()
+	return ok
+}
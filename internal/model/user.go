@@ -15,6 +15,12 @@ var (
 	ErrDuplicateNickname = errors.New("user nickname duplicate")
 )
 
+const (
+	UserRoleAdmin     = "admin"
+	UserRoleModerator = "moderator"
+	UserRoleFinance   = "finance"
+)
+
 type User struct {
 	ID         primitive.ObjectID `json:"id"               bson:"_id,omitempty"`
 	CreatedOn  int64              `json:"created_on"       bson:"created_on"`
@@ -47,6 +53,19 @@ func (m *User) Format() *UserFormatted {
 	}
 }
 
+// HasRole the admin holds every role
+func (m *User) HasRole(roles ...string) bool {
+	if m.Role == UserRoleAdmin {
+		return true
+	}
+	for _, role := range roles {
+		if m.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
 func (m *User) Table() string {
 	return "d_user"
 }
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SanctionT uint8
+
+const (
+	// SanctionSuspend the user can not post, comment, claim redpackets or create DAOs until it expires
+	SanctionSuspend SanctionT = iota + 1
+	// SanctionShadowBan the content of the user is only visible to the user
+	SanctionShadowBan
+)
+
+func (t SanctionT) Valid() bool {
+	return t == SanctionSuspend || t == SanctionShadowBan
+}
+
+// UserSanction the platform wide sanction of an address, one of each type at most
+type UserSanction struct {
+	DefaultModel `bson:",inline"`
+	Address      string    `json:"address"    bson:"address"`
+	Type         SanctionT `json:"type"       bson:"type"`
+	Reason       string    `json:"reason"     bson:"reason"`
+	By           string    `json:"by"         bson:"by"`
+	// ExpiredOn 0 forever
+	ExpiredOn int64 `json:"expired_on" bson:"expired_on"`
+}
+
+func (m *UserSanction) Table() string {
+	return "user_sanction"
+}
+
+// Impose create the sanction or replace the one of the same type
+func (m *UserSanction) Impose(ctx context.Context, db *mongo.Database) error {
+	now := time.Now().Unix()
+	_, err := db.Collection(m.Table()).UpdateOne(ctx,
+		bson.M{"address": m.Address, "type": m.Type},
+		bson.M{
+			"$setOnInsert": bson.M{CreatedAtField: now},
+			"$set": bson.M{
+				"reason":       m.Reason,
+				"by":           m.By,
+				"expired_on":   m.ExpiredOn,
+				UpdatedAtField: now,
+			},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func activeSanctionFilter() bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"expired_on": 0},
+		bson.M{"expired_on": bson.M{"$gt": time.Now().Unix()}},
+	}}
+}
+
+// Active the sanction of the type in force, mongo.ErrNoDocuments when there is none
+func (m *UserSanction) Active(ctx context.Context, db *mongo.Database) error {
+	filter := activeSanctionFilter()
+	filter["address"] = m.Address
+	filter["type"] = m.Type
+	return findOne(ctx, db, m, filter)
+}
+
+// ActiveList the sanctions in force of the address
+func (m *UserSanction) ActiveList(ctx context.Context, db *mongo.Database) ([]*UserSanction, error) {
+	filter := activeSanctionFilter()
+	filter["address"] = m.Address
+	cursor, err := find(ctx, db, m, filter)
+	if err != nil {
+		return nil, err
+	}
+	var list []*UserSanction
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ActiveAddresses the addresses under a sanction of the type in force
+func (m *UserSanction) ActiveAddresses(ctx context.Context, db *mongo.Database) ([]string, error) {
+	filter := activeSanctionFilter()
+	filter["type"] = m.Type
+	res, err := db.Collection(m.Table()).Distinct(ctx, "address", filter)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]string, 0, len(res))
+	for _, v := range res {
+		if address, ok := v.(string); ok {
+			list = append(list, address)
+		}
+	}
+	return list, nil
+}
+
+// FindPage the sanctions in force of the type, the latest first
+func (m *UserSanction) FindPage(ctx context.Context, db *mongo.Database, offset, limit int) ([]*UserSanction, int64, error) {
+	filter := activeSanctionFilter()
+	if m.Type != 0 {
+		filter["type"] = m.Type
+	}
+	total, err := db.Collection(m.Table()).CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().SetSort(bson.M{UpdatedAtField: -1}).SetSkip(int64(offset)).SetLimit(int64(limit))
+	cursor, err := find(ctx, db, m, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	list := []*UserSanction{}
+	if err = cursor.All(ctx, &list); err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// Lift it returns mongo.ErrNoDocuments when there is no such sanction
+func (m *UserSanction) Lift(ctx context.Context, db *mongo.Database) error {
+	res, err := db.Collection(m.Table()).DeleteOne(ctx, bson.M{"address": m.Address, "type": m.Type})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package model
+
+import "testing"
+
+func TestUser_HasRole(t *testing.T) {
+	tests := []struct {
+		role  string
+		roles []string
+		want  bool
+	}{
+		{UserRoleAdmin, []string{UserRoleFinance}, true},
+		{UserRoleAdmin, nil, true},
+		{UserRoleModerator, []string{UserRoleModerator}, true},
+		{UserRoleModerator, []string{UserRoleFinance, UserRoleModerator}, true},
+		{UserRoleFinance, []string{UserRoleModerator}, false},
+		{UserRoleFinance, []string{UserRoleAdmin}, false},
+		{"", []string{UserRoleModerator}, false},
+	}
+	for _, tt := range tests {
+		user := &User{Role: tt.role}
+		if got := user.HasRole(tt.roles...); got != tt.want {
+			t.Errorf("role %q HasRole(%v) = %v, want %v", tt.role, tt.roles, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/internal/service"
+	"favor-dao-backend/pkg/app"
+	"favor-dao-backend/pkg/convert"
+	"favor-dao-backend/pkg/errcode"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func AdminGetOrgans(c *gin.Context) {
+	response := app.NewResponse(c)
+	list, e := service.ListOrgans(c.Request.Context())
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponseList(list, int64(len(list)))
+}
+
+func AdminCreateOrgan(c *gin.Context) {
+	param := service.OrganCreationReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	organ, e := service.CreateOrgan(c.Request.Context(), param)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(organ)
+}
+
+func AdminUpdateOrgan(c *gin.Context) {
+	param := service.OrganUpdateReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("organ_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	organ, e := service.UpdateOrgan(c.Request.Context(), id, param)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(organ)
+}
+
+func AdminGetBlacklist(c *gin.Context) {
+	response := app.NewResponse(c)
+	offset, limit := app.GetPageOffset(c)
+	blockModel := model.BlockModel(convert.StrTo(c.Query("model")).MustInt())
+	list, total, e := service.ListBlacklist(c.Request.Context(), blockModel, offset, limit)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponseList(list, total)
+}
+
+func AdminAddBlacklist(c *gin.Context) {
+	param := service.BlacklistReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	user, _ := userFrom(c)
+	blacklist, e := service.AddBlacklist(c.Request.Context(), user, param)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(blacklist)
+}
+
+func AdminDeleteBlacklist(c *gin.Context) {
+	response := app.NewResponse(c)
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	if e := service.DeleteBlacklist(c.Request.Context(), id); e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(nil)
+}
+
+func AdminGetRedpacketOrders(c *gin.Context) {
+	param := service.AdminOrderQueryReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	offset, limit := app.GetPageOffset(c)
+	list, total, e := service.ListRedpacketOrders(c.Request.Context(), param, offset, limit)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponseList(list, total)
+}
+
+func AdminGetSubscribeOrders(c *gin.Context) {
+	param := service.AdminOrderQueryReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	offset, limit := app.GetPageOffset(c)
+	list, total, e := service.ListSubscribeOrders(c.Request.Context(), param, offset, limit)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponseList(list, total)
+}
+
+func AdminCancelUser(c *gin.Context) {
+	response := app.NewResponse(c)
+	user, _ := userFrom(c)
+	if e := service.ForceCancellation(user, c.Param("address")); e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(nil)
+}
+
+func AdminReindexSearch(c *gin.Context) {
+	param := service.SearchReindexReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	if e := service.ReindexSearch(param); e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(nil)
+}
+
+func AdminPinPost(c *gin.Context) {
+	param := service.PostPinReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	postID, err := primitive.ObjectIDFromHex(c.Param("post_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	post, e := service.PinPlatformPost(postID, true, param.Duration)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(post.Format())
+}
+
+func AdminUnpinPost(c *gin.Context) {
+	response := app.NewResponse(c)
+	postID, err := primitive.ObjectIDFromHex(c.Param("post_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	if _, e := service.PinPlatformPost(postID, false, 0); e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(nil)
+}
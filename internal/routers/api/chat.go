@@ -29,3 +29,20 @@ func GetChatGroups(c *gin.Context) {
 
 	response.ToResponseList(resp, int64(len(resp)))
 }
+
+func KickChatMember(c *gin.Context) {
+	param := service.ChatKickReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	user, _ := userFrom(c)
+	if e := service.KickChatMember(c.Request.Context(), user, param); e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(nil)
+}
@@ -21,8 +21,12 @@ func GetPostComments(c *gin.Context) {
 		return
 	}
 
+	var viewer string
+	if user, ok := userFrom(c); ok {
+		viewer = user.Address
+	}
 	offset, limit := app.GetPageOffset(c)
-	contents, totalRows, err := service.GetPostComments(postId, "_id", 1, offset, limit)
+	contents, totalRows, err := service.GetPostComments(viewer, postId, "_id", 1, offset, limit)
 
 	if err != nil {
 		logrus.Errorf("service.GetPostComments err: %v\n", err)
@@ -47,8 +51,8 @@ func CreatePostComment(c *gin.Context) {
 	comment, err := service.CreatePostComment(address.(string), param)
 
 	if err != nil {
-		if err == errcode.MaxCommentCount {
-			response.ToErrorResponse(errcode.MaxCommentCount)
+		if e, ok := err.(*errcode.Error); ok {
+			response.ToErrorResponse(e)
 		} else {
 			logrus.Errorf("service.CreatePostComment err: %v\n", err)
 			response.ToErrorResponse(errcode.CreateCommentFailed)
@@ -77,8 +81,8 @@ func DeletePostComment(c *gin.Context) {
 		return
 	}
 
-	if user.(*model.User).Address != comment.Address {
-		response.ToErrorResponse(errcode.NoPermission)
+	if e := service.CheckDeleteComment(user.(*model.User), comment.PostID, comment.Address); e != nil {
+		response.ToErrorResponse(e)
 		return
 	}
 
@@ -107,6 +111,10 @@ func CreatePostCommentReply(c *gin.Context) {
 	comment, err := service.CreatePostCommentReply(param.CommentID, param.Content, user.(*model.User).Address)
 	if err != nil {
 		logrus.Errorf("service.CreatePostCommentReply err: %v\n", err)
+		if e, ok := err.(*errcode.Error); ok {
+			response.ToErrorResponse(e)
+			return
+		}
 		response.ToErrorResponse(errcode.CreateReplyFailed)
 		return
 	}
@@ -134,8 +142,16 @@ func DeletePostCommentReply(c *gin.Context) {
 	}
 
 	if user.(*model.User).Address != reply.Address {
-		response.ToErrorResponse(errcode.NoPermission)
-		return
+		comment, err := service.GetPostComment(reply.CommentID)
+		if err != nil {
+			logrus.Errorf("service.GetPostComment err: %v\n", err)
+			response.ToErrorResponse(errcode.GetCommentFailed)
+			return
+		}
+		if e := service.CheckDeleteComment(user.(*model.User), comment.PostID, reply.Address); e != nil {
+			response.ToErrorResponse(e)
+			return
+		}
 	}
 
 	// 执行删除
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	chatModel "favor-dao-backend/internal/model/chat"
+	"favor-dao-backend/internal/service"
+	"favor-dao-backend/pkg/app"
+	"favor-dao-backend/pkg/errcode"
+	"favor-dao-backend/pkg/hub"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	chatWriteWait  = 10 * time.Second
+	chatPongWait   = 60 * time.Second
+	chatPingPeriod = 30 * time.Second
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// the session token authenticates the socket, any origin is accepted like the rest api
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+func GetConversations(c *gin.Context) {
+	response := app.NewResponse(c)
+	user, _ := userFrom(c)
+
+	offset, limit := app.GetPageOffset(c)
+	list, err := service.ListConversations(c.Request.Context(), user.Address, offset, limit)
+	if err != nil {
+		logrus.Errorf("service.ListConversations err: %v", err)
+		response.ToErrorResponse(errcode.GetChatMessagesFailed)
+		return
+	}
+	response.ToResponseList(list, int64(len(list)))
+}
+
+func OpenConversation(c *gin.Context) {
+	param := service.ConversationReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	user, _ := userFrom(c)
+
+	conv, err := service.OpenConversation(c.Request.Context(), user.Address, param)
+	if err != nil {
+		if e, ok := err.(*errcode.Error); ok {
+			response.ToErrorResponse(e)
+			return
+		}
+		response.ToErrorResponse(errcode.ServerError.WithDetails(err.Error()))
+		return
+	}
+	response.ToResponse(conv)
+}
+
+func GetChatMessages(c *gin.Context) {
+	response := app.NewResponse(c)
+	convID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams)
+		return
+	}
+	var before primitive.ObjectID
+	if c.Query("before") != "" {
+		before, err = primitive.ObjectIDFromHex(c.Query("before"))
+		if err != nil {
+			response.ToErrorResponse(errcode.InvalidParams)
+			return
+		}
+	}
+	user, _ := userFrom(c)
+
+	list, err := service.ListChatMessages(c.Request.Context(), user.Address, convID, before, app.GetPageSize(c))
+	if err != nil {
+		if e, ok := err.(*errcode.Error); ok {
+			response.ToErrorResponse(e)
+			return
+		}
+		logrus.Errorf("service.ListChatMessages err: %v", err)
+		response.ToErrorResponse(errcode.GetChatMessagesFailed)
+		return
+	}
+	response.ToResponseList(list, int64(len(list)))
+}
+
+func SendChatMessage(c *gin.Context) {
+	param := service.ChatMessageReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	convID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams)
+		return
+	}
+	user, _ := userFrom(c)
+
+	msg, err := service.SendChatMessage(c.Request.Context(), user.Address, convID, param)
+	if err != nil {
+		if e, ok := err.(*errcode.Error); ok {
+			response.ToErrorResponse(e)
+			return
+		}
+		logrus.Errorf("service.SendChatMessage err: %v", err)
+		response.ToErrorResponse(errcode.SendChatMessageFailed)
+		return
+	}
+	response.ToResponse(msg)
+}
+
+func ReadConversation(c *gin.Context) {
+	param := service.ChatReadReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	convID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams)
+		return
+	}
+	user, _ := userFrom(c)
+
+	read, err := service.ReadConversation(c.Request.Context(), user.Address, convID, param)
+	if err != nil {
+		if e, ok := err.(*errcode.Error); ok {
+			response.ToErrorResponse(e)
+			return
+		}
+		response.ToErrorResponse(errcode.ServerError.WithDetails(err.Error()))
+		return
+	}
+	response.ToResponse(read)
+}
+
+// chatFrame is sent by the client over the websocket
+type chatFrame struct {
+	Type           string                `json:"type"`
+	Seq            int64                 `json:"seq"`
+	ConversationID string                `json:"conversation_id"`
+	MessageType    chatModel.MessageType `json:"message_type"`
+	Content        string                `json:"content"`
+	MessageID      string                `json:"message_id"`
+}
+
+// chatReply answers a frame with the same seq
+type chatReply struct {
+	Type string      `json:"type"`
+	Seq  int64       `json:"seq"`
+	Code int         `json:"code,omitempty"`
+	Msg  string      `json:"msg,omitempty"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+// ChatWebsocket push the chat events to the client, and accepts join/leave/send/read frames.
+func ChatWebsocket(c *gin.Context) {
+	user, _ := userFrom(c)
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logrus.Errorf("wsUpgrader.Upgrade err: %v", err)
+		return
+	}
+	sub := service.SubscribeChat(user.Address)
+	defer sub.Close()
+
+	replies := make(chan *chatReply, 16)
+	done := make(chan struct{})
+	quit := make(chan struct{})
+	go func() {
+		defer close(done)
+		readChatFrames(c, conn, sub, user.Address, replies, quit)
+	}()
+
+	ticker := time.NewTicker(chatPingPeriod)
+	defer func() {
+		ticker.Stop()
+		close(quit)
+		conn.Close()
+	}()
+	for {
+		var err error
+		select {
+		case <-done:
+			return
+		case payload := <-sub.C:
+			conn.SetWriteDeadline(time.Now().Add(chatWriteWait))
+			err = conn.WriteMessage(websocket.TextMessage, payload)
+		case reply := <-replies:
+			conn.SetWriteDeadline(time.Now().Add(chatWriteWait))
+			err = conn.WriteJSON(reply)
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(chatWriteWait))
+			err = conn.WriteMessage(websocket.PingMessage, nil)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func readChatFrames(c *gin.Context, conn *websocket.Conn, sub *hub.Subscription, address string, replies chan<- *chatReply, quit <-chan struct{}) {
+	conn.SetReadLimit(8192)
+	conn.SetReadDeadline(time.Now().Add(chatPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(chatPongWait))
+	})
+	ctx := c.Request.Context()
+	reply := func(r *chatReply) bool {
+		select {
+		case replies <- r:
+			return true
+		case <-quit:
+			return false
+		}
+	}
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var frame chatFrame
+		if err = json.Unmarshal(raw, &frame); err != nil {
+			if !reply(&chatReply{Type: "error", Code: errcode.InvalidParams.Code(), Msg: err.Error()}) {
+				return
+			}
+			continue
+		}
+		if frame.Type == "ping" {
+			if !reply(&chatReply{Type: "pong", Seq: frame.Seq}) {
+				return
+			}
+			continue
+		}
+		convID, err := primitive.ObjectIDFromHex(frame.ConversationID)
+		if err != nil {
+			if !reply(&chatReply{Type: "error", Seq: frame.Seq, Code: errcode.InvalidParams.Code(), Msg: "conversation_id"}) {
+				return
+			}
+			continue
+		}
+		var data interface{}
+		switch frame.Type {
+		case "join":
+			err = service.JoinChatRoom(ctx, sub, address, convID)
+		case "leave":
+			service.LeaveChatRoom(sub, convID)
+		case "send":
+			data, err = service.SendChatMessage(ctx, address, convID, service.ChatMessageReq{
+				Type:    frame.MessageType,
+				Content: frame.Content,
+			})
+		case "read":
+			data, err = service.ReadConversation(ctx, address, convID, service.ChatReadReq{MessageID: frame.MessageID})
+		default:
+			err = errcode.InvalidParams.WithDetails("type " + strconv.Quote(frame.Type))
+		}
+		r := &chatReply{Type: "ack", Seq: frame.Seq, Data: data}
+		if err != nil {
+			r = &chatReply{Type: "error", Seq: frame.Seq, Code: errcode.ServerError.Code(), Msg: err.Error()}
+			if e, ok := err.(*errcode.Error); ok {
+				r.Code, r.Msg = e.Code(), e.Msg()
+			}
+		}
+		if !reply(r) {
+			return
+		}
+	}
+}
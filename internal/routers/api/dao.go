@@ -68,6 +68,10 @@ func CreateDao(c *gin.Context) {
 			response.ToErrorResponse(errcode.DaoNameDuplication)
 			return
 		}
+		if e, ok := err.(*errcode.Error); ok {
+			response.ToErrorResponse(e)
+			return
+		}
 		logrus.Errorf("service.CreateDao err: %v\n", err)
 		response.ToErrorResponse(errcode.CreateDaoFailed)
 		return
@@ -86,8 +90,8 @@ func UpdateDao(c *gin.Context) {
 		return
 	}
 
-	userAddress, _ := c.Get("address")
-	err := service.UpdateDao(userAddress.(string), param)
+	user, _ := userFrom(c)
+	err := service.UpdateDao(user, param)
 
 	if err != nil {
 		response.ToErrorResponse(err)
@@ -121,7 +125,9 @@ func GetDaoList(c *gin.Context) {
 	list, err := service.GetDaoList(&service.DaoListReq{
 		Conditions: model.ConditionsT{
 			"query": bson.M{
-				"type": model.DaoWithURL,
+				"type":        model.DaoWithURL,
+				"is_del":      0,
+				"archived_on": bson.M{"$not": bson.M{"$gt": 0}},
 			},
 			"ORDER": bson.M{"_id": -1},
 		},
@@ -189,7 +195,11 @@ func ActionDaoBookmark(c *gin.Context) {
 	logrus.Debugf("ActionDaoBookmark service.GetDaoBookmark: %s", time.Since(start))
 	if err != nil {
 		// create follow
-		err = joinDao(address.(string), param.DaoID, token)
+		if e := service.CheckDaoJoinable(address.(string), param.DaoID); e != nil {
+			response.ToErrorResponse(e)
+			return
+		}
+		err = service.JoinDao(address.(string), param.DaoID, token)
 		logrus.Debugf("ActionDaoBookmark service.CreateDaoBookmark: %s", time.Since(start))
 		status = true
 	} else {
@@ -211,6 +221,10 @@ func ActionDaoBookmark(c *gin.Context) {
 
 	if err != nil {
 		logrus.Errorf("api.ActionDaoBookmark err: %s", err)
+		if errors.Is(err, model.ErrDaoBanned) {
+			response.ToErrorResponse(errcode.DaoMemberBanned)
+			return
+		}
 		response.ToErrorResponse(errcode.NoPermission)
 		return
 	}
@@ -220,21 +234,6 @@ func ActionDaoBookmark(c *gin.Context) {
 	})
 }
 
-func joinDao(address string, daoID string, token string) error {
-	_, err := service.CreateDaoBookmark(address, daoID, func(ctx context.Context, dao *model.Dao) (string, error) {
-		gid, err := service.JoinOrLeaveGroup(ctx, dao.ID.Hex(), true, token)
-		if err != nil {
-			return "", err
-		}
-		_, err = service.PushDaoToSearch(dao)
-		if err != nil {
-			return "", err
-		}
-		return gid, err
-	})
-	return err
-}
-
 func SubDao(c *gin.Context) {
 	response := app.NewResponse(c)
 	daoId := c.Param("dao_id")
@@ -244,7 +243,7 @@ func SubDao(c *gin.Context) {
 		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
 		return
 	}
-	param := service.AuthByWalletRequest{}
+	param := service.SubDaoReq{}
 	valid, errs := app.BindAndValid(c, &param)
 	if !valid {
 		logrus.Errorf("app.BindAndValid errs: %v", errs)
@@ -252,12 +251,12 @@ func SubDao(c *gin.Context) {
 		return
 	}
 	guessMessage := fmt.Sprintf("%s subscribe DAO at %d", param.WalletAddr, param.Timestamp)
-	ok, err := service.VerifySignMessage(c.Request.Context(), &param, guessMessage)
+	ok, err := service.VerifySignMessage(c.Request.Context(), &param.AuthByWalletRequest, guessMessage)
 	if err != nil || !ok {
 		response.ToErrorResponse(errcode.InvalidWalletSignature)
 		return
 	}
-	if service.CheckSubscribeDAO(param.WalletAddr, daoID) {
+	if service.CheckSubscribeTier(param.WalletAddr, daoID, param.Tier) {
 		response.ToErrorResponse(errcode.AlreadySubscribedDAO)
 		return
 	}
@@ -265,20 +264,66 @@ func SubDao(c *gin.Context) {
 		response.ToErrorResponse(e)
 		return
 	}
-	_, status, err := service.SubDao(c.Request.Context(), daoID, param.WalletAddr)
+	if e := service.CheckDaoWritable(daoID); e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	_, status, err := service.SubDao(c.Request.Context(), daoID, param.WalletAddr, param.Tier, param.AutoRenew)
 	if err != nil {
 		logrus.Errorf("service.SubDao err: %v\n", err)
+		if e, ok := err.(*errcode.Error); ok {
+			response.ToErrorResponse(e)
+			return
+		}
 		response.ToErrorResponse(errcode.SubscribeDAO.WithDetails(err.Error()))
 		return
 	}
-	// join DAO
-	_, err = service.GetDaoBookmark(param.WalletAddr, daoId)
+	// join DAO, or ask to join a private one
+	join, e := service.JoinSubscribedDao(c.Request.Context(), param.WalletAddr, c.GetHeader("X-Session-Token"), daoID)
+	if e != nil {
+		logrus.Errorf("after subsribe DAO, service.JoinSubscribedDao err: %s", e)
+	}
+	response.ToResponse(gin.H{
+		"status": status,
+		"join":   join,
+	})
+}
+
+func RefundSubDao(c *gin.Context) {
+	response := app.NewResponse(c)
+	orderID, err := primitive.ObjectIDFromHex(c.Param("order_id"))
 	if err != nil {
-		token := c.GetHeader("X-Session-Token")
-		err = joinDao(param.WalletAddr, daoId, token)
-		if err != nil {
-			logrus.Errorf("after subsribe DAO, joinDao err: %s", err)
+		logrus.Errorf("order_id parase err: %v\n", err)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	param := service.SubRefundReq{}
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	user, _ := userFrom(c)
+	if param.WalletAddr != user.Address {
+		response.ToErrorResponse(errcode.InvalidWalletSignature)
+		return
+	}
+	guessMessage := fmt.Sprintf("%s refund subscription %s at %d", param.WalletAddr, orderID.Hex(), param.Timestamp)
+	ok, err := service.VerifySignMessage(c.Request.Context(), &param.AuthByWalletRequest, guessMessage)
+	if err != nil || !ok {
+		response.ToErrorResponse(errcode.InvalidWalletSignature)
+		return
+	}
+	status, err := service.RefundSubDao(c.Request.Context(), user, orderID, param.Reason)
+	if err != nil {
+		logrus.Errorf("service.RefundSubDao err: %v\n", err)
+		if e, ok := err.(*errcode.Error); ok {
+			response.ToErrorResponse(e)
+			return
 		}
+		response.ToErrorResponse(errcode.SubscribeRefundFailed.WithDetails(err.Error()))
+		return
 	}
 	response.ToResponse(gin.H{
 		"status": status,
@@ -306,3 +351,455 @@ func BlockDAO(c *gin.Context) {
 	}
 	response.ToResponse(nil)
 }
+
+func GetDaoRoles(c *gin.Context) {
+	response := app.NewResponse(c)
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	resp, e := service.GetDaoRoles(user.Address, daoID)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(resp)
+}
+
+func SetDaoRole(c *gin.Context) {
+	param := service.DaoRoleReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	if e := service.SetDaoRole(user, daoID, param); e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(nil)
+}
+
+func SetDaoGrants(c *gin.Context) {
+	param := service.DaoGrantsReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	if e := service.SetDaoGrants(user, daoID, param); e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(nil)
+}
+
+func InitDaoTransfer(c *gin.Context) {
+	param := service.DaoTransferReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	if param.WalletAddr != user.Address {
+		response.ToErrorResponse(errcode.InvalidWalletSignature)
+		return
+	}
+	guessMessage := service.DaoTransferMessage(param.WalletAddr, daoID, param.To, param.Timestamp)
+	ok, err := service.VerifySignMessage(c.Request.Context(), &param.AuthByWalletRequest, guessMessage)
+	if err != nil || !ok {
+		response.ToErrorResponse(errcode.InvalidWalletSignature)
+		return
+	}
+	transfer, e := service.InitDaoTransfer(c.Request.Context(), user, daoID, param)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(transfer)
+}
+
+func AcceptDaoTransfer(c *gin.Context) {
+	param := service.DaoTransferAcceptReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	if param.WalletAddr != user.Address {
+		response.ToErrorResponse(errcode.InvalidWalletSignature)
+		return
+	}
+	guessMessage := service.DaoTransferAcceptMessage(param.WalletAddr, daoID, param.Timestamp)
+	ok, err := service.VerifySignMessage(c.Request.Context(), &param.AuthByWalletRequest, guessMessage)
+	if err != nil || !ok {
+		response.ToErrorResponse(errcode.InvalidWalletSignature)
+		return
+	}
+	transfer, e := service.AcceptDaoTransfer(c.Request.Context(), user, daoID, param)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(transfer)
+}
+
+func CancelDaoTransfer(c *gin.Context) {
+	response := app.NewResponse(c)
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	if e := service.CancelDaoTransfer(c.Request.Context(), user, daoID); e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(nil)
+}
+
+func GetDaoTransfer(c *gin.Context) {
+	response := app.NewResponse(c)
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	transfer, e := service.GetDaoTransfer(c.Request.Context(), user, daoID)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(transfer)
+}
+
+func GetDaoTransfers(c *gin.Context) {
+	response := app.NewResponse(c)
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	list, e := service.GetDaoTransfers(c.Request.Context(), user, daoID)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(list)
+}
+
+func JoinDao(c *gin.Context) {
+	param := service.DaoJoinReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	status, e := service.RequestJoinDao(c.Request.Context(), user, c.GetHeader("X-Session-Token"), daoID, param)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(gin.H{
+		"status": status,
+	})
+}
+
+func GetDaoJoinRequests(c *gin.Context) {
+	response := app.NewResponse(c)
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	offset, limit := app.GetPageOffset(c)
+	list, total, e := service.ListDaoJoinRequests(c.Request.Context(), user, daoID, offset, limit)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponseList(list, total)
+}
+
+func ReviewDaoJoin(c *gin.Context) {
+	param := service.DaoJoinReviewReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	requestID, err := primitive.ObjectIDFromHex(c.Param("request_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	if e := service.ReviewDaoJoin(c.Request.Context(), user, daoID, requestID, param); e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(nil)
+}
+
+func CreateDaoInvite(c *gin.Context) {
+	param := service.DaoInviteReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	invite, e := service.CreateDaoInvite(c.Request.Context(), user, daoID, param)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(invite)
+}
+
+func GetDaoInvites(c *gin.Context) {
+	response := app.NewResponse(c)
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	list, e := service.ListDaoInvites(c.Request.Context(), user, daoID)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(list)
+}
+
+func RevokeDaoInvite(c *gin.Context) {
+	response := app.NewResponse(c)
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	if e := service.RevokeDaoInvite(c.Request.Context(), user, daoID, c.Param("code")); e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(nil)
+}
+
+func GetDaoMembers(c *gin.Context) {
+	response := app.NewResponse(c)
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	offset, limit := app.GetPageOffset(c)
+	list, total, e := service.ListDaoMembers(c.Request.Context(), user, daoID, c.Query("keyword"), offset, limit)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponseList(list, total)
+}
+
+func KickDaoMember(c *gin.Context) {
+	param := service.DaoKickReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	if e := service.KickDaoMember(c.Request.Context(), user, daoID, param); e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(nil)
+}
+
+func GetDaoBans(c *gin.Context) {
+	response := app.NewResponse(c)
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	list, e := service.ListDaoBans(c.Request.Context(), user, daoID)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(list)
+}
+
+func UnbanDaoMember(c *gin.Context) {
+	response := app.NewResponse(c)
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	if e := service.UnbanDaoMember(c.Request.Context(), user, daoID, c.Param("address")); e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(nil)
+}
+
+func GetDaoStats(c *gin.Context) {
+	param := service.DaoStatsReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	stats, e := service.GetDaoStats(c.Request.Context(), user, daoID, param)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(stats)
+}
+
+func ArchiveDao(c *gin.Context) {
+	response := app.NewResponse(c)
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	dao, e := service.ArchiveDao(c.Request.Context(), user, daoID)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(dao.Format())
+}
+
+func UnarchiveDao(c *gin.Context) {
+	response := app.NewResponse(c)
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	dao, e := service.UnarchiveDao(c.Request.Context(), user, daoID)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(dao.Format())
+}
+
+func DeleteDao(c *gin.Context) {
+	response := app.NewResponse(c)
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	if e := service.DeleteDao(c.Request.Context(), user, daoID); e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(nil)
+}
+
+func RestoreDao(c *gin.Context) {
+	response := app.NewResponse(c)
+	daoID, err := primitive.ObjectIDFromHex(c.Param("dao_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	dao, e := service.RestoreDao(c.Request.Context(), user, daoID)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(dao.Format())
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/internal/service"
+	"favor-dao-backend/pkg/app"
+	"favor-dao-backend/pkg/convert"
+	"favor-dao-backend/pkg/errcode"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func GetComplaintQueue(c *gin.Context) {
+	response := app.NewResponse(c)
+	user, _ := userFrom(c)
+	offset, limit := app.GetPageOffset(c)
+	list, total, e := service.GetModerationQueue(c.Request.Context(), user, offset, limit)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponseList(list, total)
+}
+
+func GetPostComplaints(c *gin.Context) {
+	response := app.NewResponse(c)
+	postID, err := primitive.ObjectIDFromHex(c.Param("post_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	list, e := service.GetPostComplaints(c.Request.Context(), postID)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponseList(list, int64(len(list)))
+}
+
+func ResolveComplaints(c *gin.Context) {
+	param := service.ComplaintResolveReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	postID, err := primitive.ObjectIDFromHex(c.Param("post_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	user, _ := userFrom(c)
+	if e := service.ResolveComplaints(c.Request.Context(), user, postID, param); e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(nil)
+}
+
+func GetSanctions(c *gin.Context) {
+	response := app.NewResponse(c)
+	offset, limit := app.GetPageOffset(c)
+	typ := model.SanctionT(convert.StrTo(c.Query("type")).MustInt())
+	list, total, e := service.ListSanctions(c.Request.Context(), typ, offset, limit)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponseList(list, total)
+}
+
+func ImposeSanction(c *gin.Context) {
+	param := service.SanctionReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	user, _ := userFrom(c)
+	sanction, e := service.ImposeSanction(c.Request.Context(), user, c.Param("address"), param)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(sanction)
+}
+
+func LiftSanction(c *gin.Context) {
+	response := app.NewResponse(c)
+	typ := model.SanctionT(convert.StrTo(c.Param("type")).MustInt())
+	if e := service.LiftSanction(c.Request.Context(), c.Param("address"), typ); e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponse(nil)
+}
@@ -1,11 +1,15 @@
 package api
 
 import (
+	"io"
+	"time"
+
 	"favor-dao-backend/internal/model"
 	"favor-dao-backend/internal/service"
 	"favor-dao-backend/pkg/app"
 	"favor-dao-backend/pkg/errcode"
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -143,3 +147,120 @@ func PutNotifyRead(c *gin.Context) {
 	}
 	response.ToResponse(b)
 }
+
+func GetNotifyMutes(c *gin.Context) {
+	response := app.NewResponse(c)
+	user, _ := userFrom(c)
+	list, err := service.ListNotifyMutes(user.ID)
+	if err != nil {
+		response.ToErrorResponse(err)
+		return
+	}
+	response.ToResponseList(list, int64(len(list)))
+}
+
+func MuteNotify(c *gin.Context) {
+	response := app.NewResponse(c)
+	fromId, err := primitive.ObjectIDFromHex(c.Param("fromId"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams)
+		return
+	}
+	user, _ := userFrom(c)
+	if err1 := service.MuteNotify(user.ID, fromId); err1 != nil {
+		response.ToErrorResponse(err1)
+		return
+	}
+	response.ToResponse(true)
+}
+
+func UnmuteNotify(c *gin.Context) {
+	response := app.NewResponse(c)
+	fromId, err := primitive.ObjectIDFromHex(c.Param("fromId"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams)
+		return
+	}
+	user, _ := userFrom(c)
+	if err1 := service.UnmuteNotify(user.ID, fromId); err1 != nil {
+		response.ToErrorResponse(err1)
+		return
+	}
+	response.ToResponse(true)
+}
+
+// NotifyStream push the notification events, over websocket when upgraded or as server-sent events.
+func NotifyStream(c *gin.Context) {
+	user, _ := userFrom(c)
+	if c.IsWebsocket() {
+		notifyWebsocket(c, user.ID)
+		return
+	}
+	sub := service.SubscribeNotify(user.ID)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	ticker := time.NewTicker(chatPingPeriod)
+	defer ticker.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case payload := <-sub.C:
+			c.SSEvent("message", string(payload))
+		case <-ticker.C:
+			// keep the proxies from closing an idle stream
+			_, _ = io.WriteString(w, ": ping\n\n")
+		}
+		return true
+	})
+}
+
+func notifyWebsocket(c *gin.Context, userID primitive.ObjectID) {
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logrus.Errorf("wsUpgrader.Upgrade err: %v", err)
+		return
+	}
+	sub := service.SubscribeNotify(userID)
+	defer sub.Close()
+
+	// the stream is push only, reading keeps the pongs and the close frame flowing
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn.SetReadDeadline(time.Now().Add(chatPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(chatPongWait))
+		})
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(chatPingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+	for {
+		var err error
+		select {
+		case <-done:
+			return
+		case payload := <-sub.C:
+			conn.SetWriteDeadline(time.Now().Add(chatWriteWait))
+			err = conn.WriteMessage(websocket.TextMessage, payload)
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(chatWriteWait))
+			err = conn.WriteMessage(websocket.PingMessage, nil)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
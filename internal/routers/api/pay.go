@@ -1,9 +1,12 @@
 package api
 
 import (
+	"errors"
+
 	"favor-dao-backend/internal/service"
 	"favor-dao-backend/pkg/app"
 	"favor-dao-backend/pkg/errcode"
+	"favor-dao-backend/pkg/pointSystem"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
@@ -25,3 +28,29 @@ func PayNotify(c *gin.Context) {
 	}
 	response.ToResponse(nil)
 }
+
+func PayTrade(c *gin.Context) {
+	response := app.NewResponse(c)
+	param := service.PayTradeReq{}
+	err := c.ShouldBindQuery(&param)
+	if err != nil {
+		logrus.Errorf("app.BindAndValid err: %s", err)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	if param.TxID == "" && (param.BindOrder == "" || param.Channel == "") {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails("tx_id or bind_order with channel is required"))
+		return
+	}
+	trade, err := service.PayTrade(c.Request.Context(), param)
+	if err != nil {
+		if errors.Is(err, pointSystem.ErrTradeNotFound) {
+			response.ToErrorResponse(errcode.NotFound)
+			return
+		}
+		logrus.Errorf("service.PayTrade err: %s", err)
+		response.ToErrorResponse(errcode.ServerError.WithDetails(err.Error()))
+		return
+	}
+	response.ToResponse(trade)
+}
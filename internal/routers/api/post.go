@@ -85,6 +85,7 @@ func GetPostList(c *gin.Context) {
 			q.BlockPostIDs = service.GetBlockPostIDs(user)
 		}
 		q.BlockDaoIDs = append(q.BlockDaoIDs, service.GetBlacklistDAOs()...)
+		q.BlockDaoIDs = append(q.BlockDaoIDs, service.GetHiddenDaoIDs(user)...)
 		q.BlockPostIDs = append(q.BlockPostIDs, service.GetBlacklistPosts()...)
 	}
 
@@ -114,6 +115,8 @@ func GetFocusPostList(c *gin.Context) {
 	q.DaoIDs = daoIds
 
 	q.BlockPostIDs = service.GetBlockPostIDs(user)
+	// the retweets of the private DAOs not followed
+	q.BlockDaoIDs = service.GetHiddenDaoIDs(user)
 
 	// only public
 	posts, totalRows, err := service.GetPostListFromSearch(user, q, offset, limit)
@@ -149,6 +152,14 @@ func GetPost(c *gin.Context) {
 		response.ToErrorResponse(errcode.ServerError.WithDetails(err.Error()))
 		return
 	}
+	if postFormatted.Address != userAddress && postFormatted.Visibility == model.PostVisitDraft {
+		response.ToErrorResponse(errcode.NotFound)
+		return
+	}
+	if postFormatted.Address != userAddress && service.IsShadowBanned(postFormatted.Address) {
+		response.ToErrorResponse(errcode.NotFound)
+		return
+	}
 	postFormatted = service.FilterMemberContent(user, postFormatted)
 
 	response.ToResponse(postFormatted)
@@ -165,7 +176,7 @@ func CreatePost(c *gin.Context) {
 	}
 
 	user, _ := userFrom(c)
-	e := service.CheckIsMyDAO(user.Address, param.DaoId)
+	e := service.CheckDaoAllow(user, &core.Action{Act: core.ActCreatePublicTweet, DaoID: param.DaoId})
 	if e != nil {
 		response.ToErrorResponse(e)
 		return
@@ -177,6 +188,14 @@ func CreatePost(c *gin.Context) {
 			response.ToErrorResponse(errcode.UserHasRetweeted)
 			return
 		}
+		if errors.Is(err, service.ErrScheduleTime) {
+			response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+			return
+		}
+		if e, ok := err.(*errcode.Error); ok {
+			response.ToErrorResponse(e)
+			return
+		}
 		logrus.Errorf("service.CreatePost err: %v\n", err)
 		response.ToErrorResponse(errcode.CreatePostFailed)
 		return
@@ -185,6 +204,115 @@ func CreatePost(c *gin.Context) {
 	response.ToResponse(post)
 }
 
+func EditPost(c *gin.Context) {
+	param := service.PostEditReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	postID, err := primitive.ObjectIDFromHex(c.Param("post_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+
+	user, _ := userFrom(c)
+	post, e := service.EditPost(user, postID, param)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+
+	response.ToResponse(post)
+}
+
+func GetDraftPosts(c *gin.Context) {
+	response := app.NewResponse(c)
+	user, _ := userFrom(c)
+	offset, limit := app.GetPageOffset(c)
+	posts, total, err := service.ListDrafts(user, offset, limit)
+	if err != nil {
+		logrus.Errorf("service.ListDrafts err: %v\n", err)
+		response.ToErrorResponse(errcode.GetPostFailed)
+		return
+	}
+	response.ToResponseList(posts, total)
+}
+
+func PublishPost(c *gin.Context) {
+	param := service.PostPublishReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	postID, err := primitive.ObjectIDFromHex(param.ID)
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+
+	user, _ := userFrom(c)
+	post, e := service.PublishDraft(user, postID, param.ScheduledOn)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+
+	response.ToResponse(post)
+}
+
+func VotePoll(c *gin.Context) {
+	param := service.PollVoteReq{}
+	response := app.NewResponse(c)
+	valid, errs := app.BindAndValid(c, &param)
+	if !valid {
+		logrus.Errorf("app.BindAndValid errs: %v", errs)
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(errs.Errors()...))
+		return
+	}
+	contentID, err := primitive.ObjectIDFromHex(param.ContentID)
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+
+	user, _ := userFrom(c)
+	poll, e := service.VotePoll(user, contentID, param.Options)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+
+	response.ToResponse(poll)
+}
+
+func GetPostRevisions(c *gin.Context) {
+	response := app.NewResponse(c)
+	postID, err := primitive.ObjectIDFromHex(c.Param("post_id"))
+	if err != nil {
+		response.ToErrorResponse(errcode.InvalidParams.WithDetails(err.Error()))
+		return
+	}
+	var userAddress string
+	user, _ := userFrom(c)
+	if user != nil {
+		userAddress = user.Address
+	}
+	offset, limit := app.GetPageOffset(c)
+	list, total, e := service.ListPostRevisions(userAddress, postID, offset, limit)
+	if e != nil {
+		response.ToErrorResponse(e)
+		return
+	}
+	response.ToResponseList(list, total)
+}
+
 func DeletePost(c *gin.Context) {
 	response := app.NewResponse(c)
 	id := c.Query("id")
@@ -390,27 +518,15 @@ func StickPost(c *gin.Context) {
 		response.ToErrorResponse(errcode.GetPostFailed)
 		return
 	}
-	postFormatted, err := service.GetPost("", postId)
-	if err != nil {
-		logrus.Errorf("service.GetPost err: %v\n", err)
-		response.ToErrorResponse(errcode.GetPostFailed)
-		return
-	}
-	user, _ := c.Get("address")
-	e := service.CheckIsMyDAO(user.(string), postFormatted.DaoId)
+	user, _ := userFrom(c)
+	status, e := service.StickPost(user, postId, param.Duration)
 	if e != nil {
 		response.ToErrorResponse(e)
 		return
 	}
-	err = service.StickPost(postId)
-	if err != nil {
-		logrus.Errorf("service.StickPost err: %v\n", err)
-		response.ToErrorResponse(errcode.LockPostFailed)
-		return
-	}
 
 	response.ToResponse(gin.H{
-		"top_status": 1 - postFormatted.IsTop,
+		"top_status": status,
 	})
 }
 
@@ -255,8 +255,11 @@ func GetDaoPosts(c *gin.Context) {
 		q.Type = core.AllQueryPostType
 	}
 	q.Visibility = []model.PostVisibleT{model.PostVisitPublic, model.PostVisitPrivate}
+	q.Pins = model.PinDao
 	my, _ := userFrom(c)
 	offset, limit := app.GetPageOffset(c)
+	// the private DAOs show their posts only to the followers
+	q.BlockDaoIDs = service.GetHiddenDaoIDs(my)
 
 	// Contains dao private when query dao it's me
 	posts, totalRows, err := service.GetPostListFromSearch(my, q, offset, limit)
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"favor-dao-backend/internal/middleware"
+	"favor-dao-backend/internal/model"
 	"favor-dao-backend/internal/routers/api"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -27,7 +28,10 @@ func NewRouter() *gin.Engine {
 
 	r.POST("/auth/login", api.Login)
 
-	r.Group("/pay").Use(middleware.AllowHost()).GET("/notify", api.PayNotify)
+	payApi := r.Group("/pay").Use(middleware.AllowHost())
+	{
+		payApi.GET("/notify", middleware.PayNotifySign(), api.PayNotify)
+	}
 
 	noAuthApi := r.Group("/").Use(middleware.Session())
 	{
@@ -42,6 +46,7 @@ func NewRouter() *gin.Engine {
 
 		noAuthApi.GET("/post", api.GetPost)
 		noAuthApi.GET("/post/comments", api.GetPostComments)
+		noAuthApi.GET("/post/revisions/:post_id", api.GetPostRevisions)
 
 		noAuthApi.POST("/post/view", api.PostView)
 		noAuthApi.GET("/post/view", api.GetPostView)
@@ -55,6 +60,9 @@ func NewRouter() *gin.Engine {
 
 	authApi := r.Group("/").Use(middleware.Login())
 	{
+		// the suspended users can not post, comment, claim redpackets or create DAOs
+		unsuspended := middleware.Unsuspended()
+
 		// user
 		authApi.DELETE("/account", api.DeleteAccount)
 		authApi.GET("/user/info", api.GetUserInfo)
@@ -73,8 +81,11 @@ func NewRouter() *gin.Engine {
 		authApi.GET("/user/stars", api.GetUserStars)
 		authApi.GET("/posts/focus", api.GetFocusPostList)
 
-		authApi.POST("/post", api.CreatePost)
+		authApi.POST("/post", unsuspended, api.CreatePost)
 		authApi.DELETE("/post", api.DeletePost)
+		authApi.PUT("/post/:post_id", unsuspended, api.EditPost)
+		authApi.GET("/post/drafts", api.GetDraftPosts)
+		authApi.POST("/post/publish", unsuspended, api.PublishPost)
 
 		authApi.GET("/post/star", api.GetPostStar)
 		authApi.POST("/post/star", api.PostStar)
@@ -85,14 +96,19 @@ func NewRouter() *gin.Engine {
 		authApi.POST("/post/visibility", api.VisiblePost)
 		authApi.POST("/post/block/:post_id", api.BlockPost)
 		authApi.POST("/post/complaint", api.ComplaintPost)
+		authApi.POST("/post/poll/vote", unsuspended, api.VotePoll)
 
-		authApi.POST("/post/comment", api.CreatePostComment)
+		authApi.POST("/post/comment", unsuspended, api.CreatePostComment)
 		authApi.DELETE("/post/comment", api.DeletePostComment)
-		authApi.POST("/post/comment/reply", api.CreatePostCommentReply)
+		authApi.POST("/post/comment/reply", unsuspended, api.CreatePostCommentReply)
 		authApi.DELETE("/post/comment/reply", api.DeletePostCommentReply)
 
 		// notify
 		authApi.GET("/notify/group", api.NotifyGroupList)
+		authApi.GET("/notify/stream", api.NotifyStream)
+		authApi.GET("/notify/mutes", api.GetNotifyMutes)
+		authApi.PUT("/notify/mute/:fromId", api.MuteNotify)
+		authApi.DELETE("/notify/mute/:fromId", api.UnmuteNotify)
 		authApi.GET("/notify/:fromId", api.NotifyByFrom)
 		authApi.GET("/notify/sys/:organId", api.NotifySys)
 		authApi.GET("/notify/unread/:fromId", api.NotifyUnread)
@@ -102,7 +118,7 @@ func NewRouter() *gin.Engine {
 
 		// red packet
 		authApi.POST("/redpacket", api.CreateRedpacket)
-		authApi.POST("/redpacket/:redpacket_id", api.ClaimRedpacket)
+		authApi.POST("/redpacket/:redpacket_id", unsuspended, api.ClaimRedpacket)
 		authApi.GET("/redpacket/:redpacket_id", api.RedpacketInfo)
 		authApi.GET("/redpacket/claims/:redpacket_id", api.RedpacketClaimList)
 		authApi.GET("/redpacket/stats/claims", api.RedpacketStatsClaims)
@@ -113,15 +129,82 @@ func NewRouter() *gin.Engine {
 		// dao
 		authApi.GET("/daos", api.GetDaos)
 		authApi.GET("/dao/my", api.GetMyDaoList)
-		authApi.POST("/dao", api.CreateDao)
+		authApi.POST("/dao", unsuspended, api.CreateDao)
 		authApi.PUT("/dao", api.UpdateDao)
 		authApi.GET("/dao/bookmark", api.GetDaoBookmark)
 		authApi.POST("/dao/bookmark", api.ActionDaoBookmark)
 		authApi.POST("/dao/sub/:dao_id", api.SubDao)
+		authApi.POST("/dao/refund/:order_id", api.RefundSubDao)
 		authApi.POST("/dao/block/:dao_id", api.BlockDAO)
+		authApi.GET("/dao/roles/:dao_id", api.GetDaoRoles)
+		authApi.PUT("/dao/role/:dao_id", api.SetDaoRole)
+		authApi.PUT("/dao/grants/:dao_id", api.SetDaoGrants)
+		authApi.GET("/dao/transfer/:dao_id", api.GetDaoTransfer)
+		authApi.GET("/dao/transfers/:dao_id", api.GetDaoTransfers)
+		authApi.POST("/dao/transfer/:dao_id", api.InitDaoTransfer)
+		authApi.POST("/dao/transfer/:dao_id/accept", api.AcceptDaoTransfer)
+		authApi.DELETE("/dao/transfer/:dao_id", api.CancelDaoTransfer)
+		authApi.POST("/dao/join/:dao_id", api.JoinDao)
+		authApi.GET("/dao/joins/:dao_id", api.GetDaoJoinRequests)
+		authApi.PUT("/dao/join/:dao_id/:request_id", api.ReviewDaoJoin)
+		authApi.POST("/dao/invite/:dao_id", api.CreateDaoInvite)
+		authApi.GET("/dao/invites/:dao_id", api.GetDaoInvites)
+		authApi.DELETE("/dao/invite/:dao_id/:code", api.RevokeDaoInvite)
+		authApi.GET("/dao/members/:dao_id", api.GetDaoMembers)
+		authApi.POST("/dao/kick/:dao_id", api.KickDaoMember)
+		authApi.GET("/dao/bans/:dao_id", api.GetDaoBans)
+		authApi.DELETE("/dao/ban/:dao_id/:address", api.UnbanDaoMember)
+		authApi.GET("/dao/:dao_id/stats", api.GetDaoStats)
+		authApi.POST("/dao/archive/:dao_id", api.ArchiveDao)
+		authApi.DELETE("/dao/archive/:dao_id", api.UnarchiveDao)
+		authApi.DELETE("/dao/:dao_id", api.DeleteDao)
+		authApi.POST("/dao/restore/:dao_id", api.RestoreDao)
 
 		// chat
 		authApi.GET("/chat/groups", api.GetChatGroups)
+		authApi.POST("/chat/group/kick", api.KickChatMember)
+		authApi.GET("/chat/conversations", api.GetConversations)
+		authApi.POST("/chat/conversation", api.OpenConversation)
+		authApi.GET("/chat/conversation/:id/messages", api.GetChatMessages)
+		authApi.POST("/chat/conversation/:id/message", api.SendChatMessage)
+		authApi.PUT("/chat/conversation/:id/read", api.ReadConversation)
+		authApi.GET("/chat/ws", api.ChatWebsocket)
+	}
+
+	adminApi := r.Group("/admin").Use(middleware.Login())
+	{
+		moderator := middleware.Role(model.UserRoleModerator)
+		finance := middleware.Role(model.UserRoleFinance)
+		admin := middleware.Role(model.UserRoleAdmin)
+
+		// moderation
+		adminApi.GET("/complaints", moderator, api.GetComplaintQueue)
+		adminApi.GET("/complaints/:post_id", moderator, api.GetPostComplaints)
+		adminApi.POST("/complaints/:post_id/resolve", moderator, api.ResolveComplaints)
+		adminApi.GET("/blacklist", moderator, api.AdminGetBlacklist)
+		adminApi.POST("/blacklist", moderator, api.AdminAddBlacklist)
+		adminApi.DELETE("/blacklist/:id", moderator, api.AdminDeleteBlacklist)
+		adminApi.GET("/sanctions", moderator, api.GetSanctions)
+		adminApi.POST("/sanction/:address", moderator, api.ImposeSanction)
+		adminApi.DELETE("/sanction/:address/:type", moderator, api.LiftSanction)
+
+		// orders
+		adminApi.GET("/orders/redpackets", finance, api.AdminGetRedpacketOrders)
+		adminApi.GET("/orders/subscriptions", finance, api.AdminGetSubscribeOrders)
+		adminApi.GET("/pay/trade", finance, api.PayTrade)
+
+		// organ
+		adminApi.GET("/organs", admin, api.AdminGetOrgans)
+		adminApi.POST("/organ", admin, api.AdminCreateOrgan)
+		adminApi.PUT("/organ/:organ_id", admin, api.AdminUpdateOrgan)
+
+		// platform pins
+		adminApi.POST("/post/pin/:post_id", admin, api.AdminPinPost)
+		adminApi.DELETE("/post/pin/:post_id", admin, api.AdminUnpinPost)
+
+		// maintenance
+		adminApi.DELETE("/user/:address", admin, api.AdminCancelUser)
+		adminApi.POST("/search/reindex", admin, api.AdminReindexSearch)
 	}
 
 	// test := r.Group("/test")
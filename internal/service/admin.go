@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/errcode"
+	"github.com/hibiken/asynq"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	TypeUserCancellation = "user:cancellation"
+	TypeSearchReindex    = "search:reindex"
+
+	// searchReindexTimeout every post and DAO is pushed again, it takes a while
+	searchReindexTimeout = 6 * time.Hour
+)
+
+type OrganCreationReq struct {
+	Key    string `json:"key"    binding:"required,max=64"`
+	Name   string `json:"name"   binding:"required,max=64"`
+	Avatar string `json:"avatar" binding:"max=512"`
+	IsShow bool   `json:"isShow"`
+}
+
+type OrganUpdateReq struct {
+	Name   *string `json:"name"   binding:"omitempty,min=1,max=64"`
+	Avatar *string `json:"avatar" binding:"omitempty,max=512"`
+	IsShow *bool   `json:"isShow"`
+}
+
+type BlacklistReq struct {
+	BlockId primitive.ObjectID `json:"block_id" binding:"required"`
+	Model   model.BlockModel   `json:"model"`
+}
+
+type AdminOrderQueryReq struct {
+	Address string `form:"address"`
+	DaoID   string `form:"dao_id"`
+	// Status of the order, all when empty
+	Status *int `form:"status"`
+}
+
+type SearchReindexReq struct {
+	Posts bool `json:"posts"`
+	Daos  bool `json:"daos"`
+}
+
+func ListOrgans(ctx context.Context) ([]model.Organ, *errcode.Error) {
+	organs, err := (&model.Organ{}).List(conf.MustMongoDB(), &model.ConditionsT{
+		"query": bson.M{},
+		"ORDER": bson.M{"_id": -1},
+	})
+	if err != nil {
+		logrus.Errorf("organ.List err:%s", err)
+		return nil, errcode.GetOrganFailed
+	}
+	if organs == nil {
+		return []model.Organ{}, nil
+	}
+	return *organs, nil
+}
+
+func CreateOrgan(ctx context.Context, param OrganCreationReq) (*model.Organ, *errcode.Error) {
+	organ := &model.Organ{
+		Key:    param.Key,
+		Name:   param.Name,
+		Avatar: param.Avatar,
+		IsShow: param.IsShow,
+	}
+	if err := organ.Create(ctx, conf.MustMongoDB()); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errcode.OrganKeyDuplication
+		}
+		logrus.Errorf("organ.Create key:%s err:%s", param.Key, err)
+		return nil, errcode.ServerError
+	}
+	return organ, nil
+}
+
+func UpdateOrgan(ctx context.Context, id primitive.ObjectID, param OrganUpdateReq) (*model.Organ, *errcode.Error) {
+	db := conf.MustMongoDB()
+	organ, err := (&model.Organ{ID: id}).Get(ctx, db)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errcode.NoExistOrgan
+		}
+		logrus.Errorf("organ.Get id:%s err:%s", id.Hex(), err)
+		return nil, errcode.GetOrganFailed
+	}
+	if param.Name != nil {
+		organ.Name = *param.Name
+	}
+	if param.Avatar != nil {
+		organ.Avatar = *param.Avatar
+	}
+	if param.IsShow != nil {
+		organ.IsShow = *param.IsShow
+	}
+	if err = organ.Update(ctx, db); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errcode.NoExistOrgan
+		}
+		logrus.Errorf("organ.Update id:%s err:%s", id.Hex(), err)
+		return nil, errcode.ServerError
+	}
+	return organ, nil
+}
+
+// ListBlacklist the global blacklist of the model, the latest first
+func ListBlacklist(ctx context.Context, blockModel model.BlockModel, offset, limit int) ([]*model.Blacklist, int64, *errcode.Error) {
+	list, total, err := (&model.Blacklist{}).FindList(ctx, conf.MustMongoDB(), bson.M{"model": blockModel}, offset, limit)
+	if err != nil {
+		logrus.Errorf("blacklist.FindList model:%d err:%s", blockModel, err)
+		return nil, 0, errcode.ServerError
+	}
+	return list, total, nil
+}
+
+// AddBlacklist hide the post or the DAO from everyone, it is idempotent
+func AddBlacklist(ctx context.Context, admin *model.User, param BlacklistReq) (*model.Blacklist, *errcode.Error) {
+	switch param.Model {
+	case model.BlockModelPost:
+		if _, err := ds.GetPostByID(param.BlockId); err != nil {
+			return nil, errcode.GetPostFailed
+		}
+	case model.BlockModelDAO:
+		if _, err := ds.GetDao(&model.Dao{ID: param.BlockId}); err != nil {
+			return nil, errcode.NoExistDao
+		}
+	default:
+		return nil, errcode.InvalidParams.WithDetails("unknown model")
+	}
+	db := conf.MustMongoDB()
+	blacklist := &model.Blacklist{}
+	err := blacklist.FindOne(ctx, db, bson.M{"block_id": param.BlockId, "model": param.Model})
+	if err == nil {
+		return blacklist, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		logrus.Errorf("blacklist.FindOne block_id:%s err:%s", param.BlockId.Hex(), err)
+		return nil, errcode.ServerError
+	}
+	blacklist = &model.Blacklist{Address: admin.Address, BlockId: param.BlockId, Model: param.Model}
+	if err = blacklist.Create(ctx, db); err != nil {
+		logrus.Errorf("blacklist.Create block_id:%s err:%s", param.BlockId.Hex(), err)
+		return nil, errcode.ServerError
+	}
+	return blacklist, nil
+}
+
+func DeleteBlacklist(ctx context.Context, id primitive.ObjectID) *errcode.Error {
+	blacklist := &model.Blacklist{}
+	blacklist.ID = id
+	if err := blacklist.Delete(ctx, conf.MustMongoDB()); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errcode.NoExistBlacklist
+		}
+		logrus.Errorf("blacklist.Delete id:%s err:%s", id.Hex(), err)
+		return errcode.ServerError
+	}
+	return nil
+}
+
+func orderFilter(param AdminOrderQueryReq, statusField string) (bson.M, *errcode.Error) {
+	filter := bson.M{}
+	if param.Address != "" {
+		filter["address"] = param.Address
+	}
+	if param.DaoID != "" {
+		daoID, err := primitive.ObjectIDFromHex(param.DaoID)
+		if err != nil {
+			return nil, errcode.InvalidParams.WithDetails(err.Error())
+		}
+		filter["dao_id"] = daoID
+	}
+	if param.Status != nil {
+		filter[statusField] = *param.Status
+	}
+	return filter, nil
+}
+
+// ListRedpacketOrders the redpackets of every user, the latest first
+func ListRedpacketOrders(ctx context.Context, param AdminOrderQueryReq, offset, limit int) ([]*model.RedpacketSendFormatted, int64, *errcode.Error) {
+	filter, e := orderFilter(param, "pay_status")
+	if e != nil {
+		return nil, 0, e
+	}
+	rrd := model.Redpacket{}
+	total := rrd.Count(ctx, conf.MustMongoDB(), filter)
+	list := rrd.FindList(ctx, conf.MustMongoDB(), filter, limit, offset)
+	return list, total, nil
+}
+
+// ListSubscribeOrders the DAO subscriptions of every user, the latest first
+func ListSubscribeOrders(ctx context.Context, param AdminOrderQueryReq, offset, limit int) ([]*model.DaoSubscribe, int64, *errcode.Error) {
+	filter, e := orderFilter(param, "status")
+	if e != nil {
+		return nil, 0, e
+	}
+	list, total, err := (&model.DaoSubscribe{}).FindPage(ctx, conf.MustMongoDB(), filter, offset, limit)
+	if err != nil {
+		logrus.Errorf("daoSubscribe.FindPage err:%s", err)
+		return nil, 0, errcode.ServerError
+	}
+	return list, total, nil
+}
+
+type UserCancellationPayload struct {
+	Address string
+}
+
+func NewUserCancellationTask(address string) *asynq.Task {
+	payload, _ := json.Marshal(UserCancellationPayload{Address: address})
+	return asynq.NewTask(TypeUserCancellation, payload)
+}
+
+func HandleUserCancellationTask(ctx context.Context, t *asynq.Task) error {
+	var p UserCancellationPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v", err)
+	}
+	return Cancellation(p.Address)
+}
+
+// ForceCancellation delete the user and all its content in the background, like -del-address
+func ForceCancellation(admin *model.User, address string) *errcode.Error {
+	if address == admin.Address {
+		return errcode.NoPermission.WithDetails("can not cancel yourself")
+	}
+	if _, err := ds.GetUserByAddress(address); err != nil {
+		return errcode.NoExistUserAddress
+	}
+	_, err := queue.Enqueue(NewUserCancellationTask(address), asynq.Queue(PostQueue), asynq.Unique(time.Hour))
+	if err != nil && !errors.Is(err, asynq.ErrDuplicateTask) {
+		logrus.Errorf("user cancellation %s enqueue failed: %v", address, err)
+		return errcode.ServerError
+	}
+	return nil
+}
+
+func NewSearchReindexTask(param SearchReindexReq) *asynq.Task {
+	payload, _ := json.Marshal(param)
+	return asynq.NewTask(TypeSearchReindex, payload)
+}
+
+func HandleSearchReindexTask(ctx context.Context, t *asynq.Task) error {
+	var p SearchReindexReq
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v", err)
+	}
+	if p.Posts {
+		PushPostsToSearch()
+	}
+	if p.Daos {
+		PushDAOsToSearch()
+	}
+	return nil
+}
+
+// ReindexSearch push the posts and the DAOs to the search again in the background, like -push-search.
+// Only one reindex runs at a time.
+func ReindexSearch(param SearchReindexReq) *errcode.Error {
+	if !param.Posts && !param.Daos {
+		param.Posts, param.Daos = true, true
+	}
+	_, err := queue.Enqueue(NewSearchReindexTask(param), asynq.Queue(PostQueue),
+		asynq.Timeout(searchReindexTimeout), asynq.Unique(searchReindexTimeout), asynq.MaxRetry(0))
+	if err != nil {
+		if errors.Is(err, asynq.ErrDuplicateTask) {
+			return errcode.SearchReindexRunning
+		}
+		logrus.Errorf("search reindex enqueue failed: %v", err)
+		return errcode.ServerError
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/core"
 	"favor-dao-backend/internal/model"
 	"favor-dao-backend/pkg/errcode"
 	"go.mongodb.org/mongo-driver/bson"
@@ -27,9 +28,14 @@ type ReplyDelReq struct {
 	ID primitive.ObjectID `json:"id" binding:"required"`
 }
 
-func GetPostComments(postID primitive.ObjectID, sort string, sortVal, offset, limit int) ([]*model.CommentFormatted, int64, error) {
+func GetPostComments(viewer string, postID primitive.ObjectID, sort string, sortVal, offset, limit int) ([]*model.CommentFormatted, int64, error) {
+	query := bson.M{"post_id": postID}
+	shadowed := GetShadowBannedAddresses(viewer)
+	if len(shadowed) > 0 {
+		query["address"] = bson.M{"$nin": shadowed}
+	}
 	conditions := &model.ConditionsT{
-		"query": bson.M{"post_id": postID},
+		"query": query,
 		"ORDER": bson.M{sort: sortVal},
 	}
 	comments, err := ds.GetComments(conditions, offset, limit)
@@ -59,6 +65,19 @@ func GetPostComments(postID primitive.ObjectID, sort string, sortVal, offset, li
 	if err != nil {
 		return nil, 0, err
 	}
+	if len(shadowed) > 0 {
+		hidden := make(map[string]bool, len(shadowed))
+		for _, address := range shadowed {
+			hidden[address] = true
+		}
+		visible := replies[:0]
+		for _, reply := range replies {
+			if !hidden[reply.Address] {
+				visible = append(visible, reply)
+			}
+		}
+		replies = visible
+	}
 
 	commentsFormatted := make([]*model.CommentFormatted, len(comments))
 	for i, comment := range comments {
@@ -110,6 +129,12 @@ func CreatePostComment(address string, param CommentCreationReq) (comment *model
 	if post.CommentCount >= conf.AppSetting.MaxCommentCount {
 		return nil, errcode.MaxCommentCount
 	}
+	if e := CheckDaoBanned(post.DaoId, address); e != nil {
+		return nil, e
+	}
+	if e := CheckDaoWritable(post.DaoId); e != nil {
+		return nil, e
+	}
 
 	comment = &model.Comment{
 		PostID:  post.ID,
@@ -193,6 +218,12 @@ func CreatePostCommentReply(commentID primitive.ObjectID, content string, addres
 	if post, err = createPostPreHandler(commentID); err != nil {
 		return nil, err
 	}
+	if e := CheckDaoBanned(post.DaoId, address); e != nil {
+		return nil, e
+	}
+	if e := CheckDaoWritable(post.DaoId); e != nil {
+		return nil, e
+	}
 
 	// 创建评论
 	reply := &model.CommentReply{
@@ -253,3 +284,15 @@ func DeletePostCommentReply(reply *model.CommentReply) error {
 
 	return nil
 }
+
+// CheckDeleteComment the author or a role of the DAO granted to delete comments
+func CheckDeleteComment(user *model.User, postID primitive.ObjectID, author string) *errcode.Error {
+	if user.Address == author {
+		return nil
+	}
+	post, err := ds.GetPostByID(postID)
+	if err != nil {
+		return errcode.GetPostFailed
+	}
+	return CheckDaoAllow(user, &core.Action{Act: core.ActDeleteComment, DaoID: post.DaoId})
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/model"
+	chatModel "favor-dao-backend/internal/model/chat"
+	"favor-dao-backend/pkg/errcode"
+	"favor-dao-backend/pkg/hub"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	ChatEventMessage = "message"
+	ChatEventRead    = "read"
+)
+
+type ConversationReq struct {
+	// To start a direct conversation with the address
+	To string `json:"to"`
+	// DaoID open the room of the DAO
+	DaoID string `json:"dao_id"`
+}
+
+type ChatMessageReq struct {
+	Type    chatModel.MessageType `json:"type"`
+	Content string                `json:"content" binding:"required,max=4096"`
+}
+
+type ChatReadReq struct {
+	MessageID string `json:"message_id" binding:"required"`
+}
+
+type ConversationFormatted struct {
+	*chatModel.Conversation
+	Unread      int64                    `json:"unread"`
+	LastMessage *chatModel.Message       `json:"last_message"`
+	Reads       []*chatModel.ReadReceipt `json:"reads,omitempty"`
+	Dao         *model.DaoFormatted      `json:"dao,omitempty"`
+}
+
+// ChatEvent is pushed to the websocket of the members
+type ChatEvent struct {
+	Type           string                 `json:"type"`
+	ConversationID string                 `json:"conversation_id"`
+	Message        *chatModel.Message     `json:"message,omitempty"`
+	Read           *chatModel.ReadReceipt `json:"read,omitempty"`
+}
+
+func chatUserKey(address string) string {
+	return "user:" + strings.ToLower(address)
+}
+
+func chatRoomKey(id primitive.ObjectID) string {
+	return "room:" + id.Hex()
+}
+
+// SubscribeChat the events of the direct conversations of the address, rooms are joined later.
+func SubscribeChat(address string) *hub.Subscription {
+	return chatHub.Subscribe(64, chatUserKey(address))
+}
+
+func OpenConversation(ctx context.Context, address string, param ConversationReq) (*chatModel.Conversation, error) {
+	db := conf.MustMongoDB()
+	if param.DaoID != "" {
+		daoID, err := primitive.ObjectIDFromHex(param.DaoID)
+		if err != nil {
+			return nil, errcode.InvalidParams.WithDetails(err.Error())
+		}
+		if _, err = ds.GetDao(&model.Dao{ID: daoID}); err != nil {
+			return nil, errcode.NoExistDao
+		}
+		if !CheckJoinedDAO(address, daoID) {
+			return nil, errcode.NotConversationMember
+		}
+		conv := &chatModel.Conversation{Type: chatModel.ConversationDao, Key: chatModel.DaoKey(daoID), DaoID: daoID}
+		return conv, conv.FirstOrCreate(ctx, db)
+	}
+	if param.To == "" || strings.EqualFold(param.To, address) {
+		return nil, errcode.InvalidParams.WithDetails("to")
+	}
+	if _, err := ds.GetUserByAddress(param.To); err != nil {
+		return nil, errcode.NoExistUserAddress
+	}
+	conv := &chatModel.Conversation{
+		Type:    chatModel.ConversationDirect,
+		Key:     chatModel.DirectKey(address, param.To),
+		Members: []string{strings.ToLower(address), strings.ToLower(param.To)},
+	}
+	return conv, conv.FirstOrCreate(ctx, db)
+}
+
+// GetConversation load the conversation the address is a member of
+func GetConversation(ctx context.Context, address string, id primitive.ObjectID) (*chatModel.Conversation, error) {
+	conv := &chatModel.Conversation{ID: id}
+	err := conv.Get(ctx, conf.MustMongoDB())
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, errcode.NoExistConversation
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch conv.Type {
+	case chatModel.ConversationDao:
+		// the followers of the DAO are the members of its room
+		if !CheckJoinedDAO(address, conv.DaoID) {
+			return nil, errcode.NotConversationMember
+		}
+	default:
+		if !conv.IsMember(address) {
+			return nil, errcode.NotConversationMember
+		}
+	}
+	return conv, nil
+}
+
+func ListConversations(ctx context.Context, address string, offset, limit int) ([]*ConversationFormatted, error) {
+	db := conf.MustMongoDB()
+	list, err := (&chatModel.Conversation{}).List(ctx, db, address, GetDaoBookmarkListByAddress(address), offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*ConversationFormatted, 0, len(list))
+	for _, conv := range list {
+		item := &ConversationFormatted{Conversation: conv}
+		read := &chatModel.ReadReceipt{ConversationID: conv.ID, Address: strings.ToLower(address)}
+		if err = read.First(ctx, db); err != nil {
+			return nil, err
+		}
+		item.Unread, err = (&chatModel.Message{ConversationID: conv.ID}).CountUnread(ctx, db, read.Address, read.LastReadID)
+		if err != nil {
+			return nil, err
+		}
+		if !conv.LastMessageID.IsZero() {
+			msg := &chatModel.Message{ID: conv.LastMessageID}
+			if msg.Get(ctx, db) == nil {
+				item.LastMessage = msg
+			}
+		}
+		if conv.Type == chatModel.ConversationDao {
+			if dao, e := ds.GetDao(&model.Dao{ID: conv.DaoID}); e == nil {
+				item.Dao = dao.Format()
+			}
+		} else {
+			// read status of the peer
+			item.Reads, _ = (&chatModel.ReadReceipt{ConversationID: conv.ID}).List(ctx, db)
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+func ListChatMessages(ctx context.Context, address string, id, before primitive.ObjectID, limit int) ([]*chatModel.Message, error) {
+	if _, err := GetConversation(ctx, address, id); err != nil {
+		return nil, err
+	}
+	return (&chatModel.Message{ConversationID: id}).List(ctx, conf.MustMongoDB(), before, limit)
+}
+
+func SendChatMessage(ctx context.Context, address string, id primitive.ObjectID, param ChatMessageReq) (*chatModel.Message, error) {
+	// frames of the websocket are not validated by the binding
+	if param.Content == "" || len(param.Content) > 4096 {
+		return nil, errcode.InvalidParams.WithDetails("content")
+	}
+	conv, err := GetConversation(ctx, address, id)
+	if err != nil {
+		return nil, err
+	}
+	db := conf.MustMongoDB()
+	msg := &chatModel.Message{
+		ConversationID: conv.ID,
+		From:           strings.ToLower(address),
+		Type:           param.Type,
+		Content:        param.Content,
+	}
+	if err = msg.Create(ctx, db); err != nil {
+		return nil, err
+	}
+	if err = conv.Touch(ctx, db, msg); err != nil {
+		logrus.Errorf("conversation.Touch id:%s err:%s", conv.ID.Hex(), err)
+	}
+	// the sender has read its own message
+	read := &chatModel.ReadReceipt{ConversationID: conv.ID, Address: msg.From, LastReadID: msg.ID}
+	if err = read.Read(ctx, db); err != nil {
+		logrus.Errorf("readReceipt.Read id:%s err:%s", conv.ID.Hex(), err)
+	}
+	publishChatEvent(ctx, conv, &ChatEvent{
+		Type:           ChatEventMessage,
+		ConversationID: conv.ID.Hex(),
+		Message:        msg,
+	})
+	return msg, nil
+}
+
+func ReadConversation(ctx context.Context, address string, id primitive.ObjectID, param ChatReadReq) (*chatModel.ReadReceipt, error) {
+	conv, err := GetConversation(ctx, address, id)
+	if err != nil {
+		return nil, err
+	}
+	msgID, err := primitive.ObjectIDFromHex(param.MessageID)
+	if err != nil {
+		return nil, errcode.InvalidParams.WithDetails(err.Error())
+	}
+	read := &chatModel.ReadReceipt{ConversationID: conv.ID, Address: strings.ToLower(address), LastReadID: msgID}
+	if err = read.Read(ctx, conf.MustMongoDB()); err != nil {
+		return nil, err
+	}
+	publishChatEvent(ctx, conv, &ChatEvent{
+		Type:           ChatEventRead,
+		ConversationID: conv.ID.Hex(),
+		Read:           read,
+	})
+	return read, nil
+}
+
+// publishChatEvent deliver the event to the members of a direct conversation or to whoever joined the room
+func publishChatEvent(ctx context.Context, conv *chatModel.Conversation, event *ChatEvent) {
+	keys := []string{chatRoomKey(conv.ID)}
+	if conv.Type == chatModel.ConversationDirect {
+		keys = keys[:0]
+		for _, member := range conv.Members {
+			keys = append(keys, chatUserKey(member))
+		}
+	}
+	for _, key := range keys {
+		if err := chatHub.PublishJSON(ctx, key, event); err != nil {
+			logrus.Errorf("chatHub.Publish key:%s err:%s", key, err)
+		}
+	}
+}
+
+// JoinChatRoom subscribe the events of the room, only the followers of the DAO can join.
+func JoinChatRoom(ctx context.Context, sub *hub.Subscription, address string, id primitive.ObjectID) error {
+	conv, err := GetConversation(ctx, address, id)
+	if err != nil {
+		return err
+	}
+	if conv.Type == chatModel.ConversationDao {
+		sub.Add(chatRoomKey(conv.ID))
+	}
+	return nil
+}
+
+func LeaveChatRoom(sub *hub.Subscription, id primitive.ObjectID) {
+	sub.Remove(chatRoomKey(id))
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"reflect"
 	"strings"
 	"time"
 
@@ -17,6 +18,7 @@ import (
 	notify1 "favor-dao-backend/pkg/notify"
 	"favor-dao-backend/pkg/pointSystem"
 	"favor-dao-backend/pkg/psub"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
@@ -33,6 +35,12 @@ type DaoCreationReq struct {
 	Avatar       string            `json:"avatar"`
 	Banner       string            `json:"banner"`
 	Price        string            `json:"price"`
+	Tiers        []model.DaoTier   `json:"tiers"`
+	Type         model.DaoType     `json:"type"`
+	// HomePage the external site of the DaoWithURL
+	HomePage string `json:"home_page"`
+	// Gate the posts of the DAO are only for the holders of the token
+	Gate *model.TokenGate `json:"gate"`
 }
 
 type DaoUpdateReq struct {
@@ -44,6 +52,25 @@ type DaoUpdateReq struct {
 	Avatar       string             `json:"avatar"`
 	Banner       string             `json:"banner"`
 	Price        string             `json:"price"`
+	// Tiers unchanged when not set, an empty list drops them
+	Tiers []model.DaoTier `json:"tiers"`
+	// Type unchanged when not set, the home page is dropped with DaoDefault
+	Type     *model.DaoType `json:"type"`
+	HomePage string         `json:"home_page"`
+	// Gate unchanged when not set, dropped with an empty contract
+	Gate *model.TokenGate `json:"gate"`
+}
+
+type SubRefundReq struct {
+	AuthByWalletRequest `json:",inline"`
+	Reason              string `json:"reason"`
+}
+
+type SubDaoReq struct {
+	AuthByWalletRequest `json:",inline"`
+	// Tier to subscribe, the lowest tier by default
+	Tier      model.PostMemberT `json:"tier"`
+	AutoRenew bool              `json:"auto_renew"`
 }
 
 type DaoFollowReq struct {
@@ -75,6 +102,17 @@ func CreateDao(_ *gin.Context, userAddress string, param DaoCreationReq, chatAct
 		Banner:       param.Banner,
 		Price:        param.Price,
 		FollowCount:  1, // default owner joined
+		Type:         param.Type,
+		HomePage:     param.HomePage,
+	}
+	if e := checkDaoHomePage(param.Type, param.HomePage); e != nil {
+		return nil, e
+	}
+	if param.Gate != nil {
+		if e := checkTokenGate(param.Gate); e != nil {
+			return nil, e
+		}
+		dao.Gate = param.Gate
 	}
 	if param.Price == "" {
 		dao.Price = "10000" // default subscribe price
@@ -84,6 +122,13 @@ func CreateDao(_ *gin.Context, userAddress string, param DaoCreationReq, chatAct
 			return nil, err
 		}
 	}
+	if len(param.Tiers) > 0 {
+		if e := checkDaoTiers(param.Tiers); e != nil {
+			return nil, e
+		}
+		dao.Tiers = param.Tiers
+		dao.Price = param.Tiers[0].Price
+	}
 	res, err := ds.CreateDao(dao, chatAction)
 	if err != nil {
 		return nil, err
@@ -101,15 +146,11 @@ func CreateDao(_ *gin.Context, userAddress string, param DaoCreationReq, chatAct
 	if err != nil {
 		logrus.Warnf("%s when create, push dao to search err: %v", userAddress, err)
 	}
+	enqueueDaoPreview(dao)
 
 	return res.Format(), nil
 }
 
-func DeleteDao(_ *gin.Context, daoId string) error {
-	id, _ := primitive.ObjectIDFromHex(daoId)
-	return ds.DeleteDao(&model.Dao{ID: id})
-}
-
 func GetDaoBookmarkList(userAddress string, q *core.QueryReq, offset, limit int) (list []*model.DaoFormatted, total int64) {
 	list = ds.GetDaoBookmarkList(userAddress, q, offset, limit)
 	if len(list) > 0 {
@@ -140,14 +181,19 @@ func GetDaoBookmarkIDsByAddress(address string) []string {
 	return daoIds
 }
 
-func UpdateDao(userAddress string, param DaoUpdateReq) (e *errcode.Error) {
+func UpdateDao(user *model.User, param DaoUpdateReq) (e *errcode.Error) {
+	if e = CheckDaoAllow(user, &core.Action{Act: core.ActEditDao, DaoID: param.Id}); e != nil {
+		return e
+	}
 	dao, err := ds.GetDao(&model.Dao{ID: param.Id})
 	if err != nil {
 		return errcode.NoExistDao
 	}
-	if dao.Address != userAddress {
+	// the settings grant does not cover what the subscribers pay and hold, that stays with the owner
+	if dao.Address != user.Address && daoPricingChanged(dao, &param) {
 		return errcode.NoPermission
 	}
+	userAddress := user.Address
 	tags := tagsFrom(param.Tags)
 	change := false
 	changeChat := false
@@ -182,10 +228,63 @@ func UpdateDao(userAddress string, param DaoUpdateReq) (e *errcode.Error) {
 		dao.Price = param.Price
 		change = true
 	}
+	if len(param.Tiers) > 0 {
+		if e = checkDaoTiers(param.Tiers); e != nil {
+			return e
+		}
+		dao.Tiers = param.Tiers
+		dao.Price = param.Tiers[0].Price
+		change = true
+	} else if param.Tiers != nil && len(dao.Tiers) > 0 {
+		// an empty list goes back to the single tier at the price
+		dao.Tiers = nil
+		change = true
+	}
 	if dao.Visibility != param.Visibility {
 		dao.Visibility = param.Visibility
 		change = true
 	}
+	changeHomePage := false
+	if param.Type != nil || param.HomePage != "" {
+		typ, homePage := dao.Type, dao.HomePage
+		if param.Type != nil {
+			typ = *param.Type
+		}
+		if param.HomePage != "" {
+			homePage = param.HomePage
+		}
+		if typ == model.DaoDefault {
+			homePage = ""
+		}
+		if e = checkDaoHomePage(typ, homePage); e != nil {
+			return e
+		}
+		if typ != dao.Type || homePage != dao.HomePage {
+			changeHomePage = homePage != dao.HomePage
+			dao.Type = typ
+			dao.HomePage = homePage
+			if changeHomePage {
+				dao.Preview = nil
+			}
+			change = true
+		}
+	}
+	if param.Gate != nil {
+		if param.Gate.Contract == "" {
+			if dao.Gate != nil {
+				dao.Gate = nil
+				change = true
+			}
+		} else {
+			if e = checkTokenGate(param.Gate); e != nil {
+				return e
+			}
+			if dao.Gate == nil || *dao.Gate != *param.Gate {
+				dao.Gate = param.Gate
+				change = true
+			}
+		}
+	}
 	if !change {
 		return errcode.DAONothingChange
 	}
@@ -223,6 +322,52 @@ func UpdateDao(userAddress string, param DaoUpdateReq) (e *errcode.Error) {
 		logrus.Warnf("%s when update, push dao to search err: %v", userAddress, err)
 		return errcode.ServerError.WithDetails(err.Error())
 	}
+	if changeHomePage {
+		enqueueDaoPreview(dao)
+	}
+	return nil
+}
+
+// daoPricingChanged the update touches the price, the tiers or the gate of the DAO, the values sent back
+// unchanged do not count
+func daoPricingChanged(dao *model.Dao, param *DaoUpdateReq) bool {
+	if param.Price != "" && param.Price != dao.Price {
+		return true
+	}
+	if param.Tiers != nil && !reflect.DeepEqual(param.Tiers, dao.Tiers) && (len(param.Tiers) > 0 || len(dao.Tiers) > 0) {
+		return true
+	}
+	if param.Gate != nil {
+		if param.Gate.Contract == "" {
+			return dao.Gate != nil
+		}
+		if dao.Gate == nil || !param.Gate.Valid() {
+			return true
+		}
+		// compared the way checkTokenGate stores it
+		g := *param.Gate
+		threshold, _ := g.MinBalance()
+		g.Contract, g.Threshold = common.HexToAddress(g.Contract).Hex(), threshold.String()
+		return g != *dao.Gate
+	}
+	return false
+}
+
+// checkDaoTiers the levels are ascending from PostMember1 to PostMember3
+func checkDaoTiers(tiers []model.DaoTier) *errcode.Error {
+	last := model.PostMemberNothing
+	for _, t := range tiers {
+		if t.Level <= last || t.Level > model.PostMember3 {
+			return errcode.InvalidDaoTiers.WithDetails("invalid level")
+		}
+		if _, err := convert.StrTo(t.Price).BigInt(); err != nil {
+			return errcode.InvalidDaoTiers.WithDetails("invalid price")
+		}
+		if t.Days < 0 {
+			return errcode.InvalidDaoTiers.WithDetails("invalid days")
+		}
+		last = t.Level
+	}
 	return nil
 }
 
@@ -268,9 +413,11 @@ func GetDaoFormatted(user, daoId string) (*model.DaoFormatted, error) {
 	if out.Address == user {
 		out.IsJoined = true
 		out.IsSubscribed = true
+		out.SubscribedTier = model.PostMember3
 	} else {
 		out.IsJoined = CheckJoinedDAO(user, id)
-		out.IsSubscribed = CheckSubscribeDAO(user, id)
+		out.SubscribedTier = GetSubscribeTier(user, id)
+		out.IsSubscribed = out.SubscribedTier != model.PostMemberNothing
 	}
 
 	out.LastPosts = []*model.PostFormatted{}
@@ -327,6 +474,10 @@ func DeleteDaoBookmark(book *model.DaoBookmark, chatAction func(context.Context,
 func PushDaoToSearch(dao *model.Dao) (bool, error) {
 	contentFormatted := dao.Name + "\n"
 	contentFormatted += dao.Introduction + "\n"
+	if dao.Preview != nil {
+		contentFormatted += dao.Preview.Title + "\n"
+		contentFormatted += dao.Preview.Description + "\n"
+	}
 
 	tagMaps := map[string]int8{}
 	for _, tag := range strings.Split(dao.Tags, ",") {
@@ -359,6 +510,8 @@ func PushDaoToSearch(dao *model.Dao) (bool, error) {
 		"created_on":        dao.CreatedOn,
 		"modified_on":       dao.ModifiedOn,
 		"latest_replied_on": time.Now().Unix(),
+		"home_page":         dao.HomePage,
+		"preview":           dao.Preview,
 	}}
 
 	return ts.AddDocuments(data, dao.ID.Hex())
@@ -402,21 +555,19 @@ func GetDaoList(req *DaoListReq) ([]*model.Dao, error) {
 	return posts, err
 }
 
-func CheckIsMyDAO(address string, daoID primitive.ObjectID) *errcode.Error {
-	dao, err := ds.GetDao(&model.Dao{ID: daoID})
-	if err != nil {
-		return errcode.NoExistDao
-	}
-	if dao.Address != address {
-		return errcode.NoPermission
-	}
-	return nil
-}
-
 func CheckSubscribeDAO(address string, daoID primitive.ObjectID) bool {
 	return ds.IsSubscribeDAO(address, daoID)
 }
 
+// CheckSubscribeTier the address has an active subscription which unlocks the tier
+func CheckSubscribeTier(address string, daoID primitive.ObjectID, tier model.PostMemberT) bool {
+	return ds.GetSubscribeTier(address, daoID) >= tier.Subscribed()
+}
+
+func GetSubscribeTier(address string, daoID primitive.ObjectID) model.PostMemberT {
+	return ds.GetSubscribeTier(address, daoID)
+}
+
 func CheckJoinedDAO(address string, daoID primitive.ObjectID) bool {
 	return ds.IsJoinedDAO(address, daoID)
 }
@@ -433,7 +584,7 @@ func CheckDAOUser(daoID primitive.ObjectID) *errcode.Error {
 	return nil
 }
 
-func SubDao(ctx context.Context, daoID primitive.ObjectID, address string) (txID string, status core.DaoSubscribeT, err error) {
+func SubDao(ctx context.Context, daoID primitive.ObjectID, address string, tier model.PostMemberT, autoRenew bool) (txID string, status core.DaoSubscribeT, err error) {
 	var (
 		oid    string
 		notify *psub.Notify
@@ -447,37 +598,63 @@ func SubDao(ctx context.Context, daoID primitive.ObjectID, address string) (txID
 	var toAddress string
 	var price float64
 
-	// check old subscribe
+	// the pending order of another tier is not this one
+	dao, err := ds.GetDao(&model.Dao{ID: daoID})
+	if err != nil {
+		err = errcode.NoExistDao
+		return
+	}
+	found, err := dao.FindTier(tier)
+	if err != nil {
+		err = errcode.NoExistDaoTier
+		return
+	}
+	tier = found.Level
+
+	// check pending subscribe
 	sub := model.DaoSubscribe{}
-	err = sub.FindOne(ctx, conf.MustMongoDB(), bson.M{"address": address, "dao_id": daoID})
+	err = sub.FindOne(ctx, conf.MustMongoDB(), bson.M{"address": address, "dao_id": daoID, "tier": tier, "status": model.DaoSubscribeSubmit})
 	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
 		return
 	}
 	if err != nil {
 		// ErrNoDocuments
 		// create order
-		err = ds.SubscribeDAO(address, daoID, func(ctx context.Context, orderID string, dao *model.Dao) error {
+		newSub := &model.DaoSubscribe{
+			Address:   address,
+			DaoID:     daoID,
+			Tier:      tier,
+			AutoRenew: autoRenew,
+		}
+		err = ds.SubscribeDAO(newSub, func(ctx context.Context, orderID string, dao *model.Dao) error {
 			oid = orderID
+			err = createPayOrder(ctx, PayMethodSubDao, orderID)
+			if err != nil {
+				return err
+			}
 			// sub order
-			notify, err = pubsub.NewSubscribe(orderID)
+			notify, err = pubsub.NewSubscribe(payNotifyKey(PayMethodSubDao, orderID))
 			if err != nil {
 				return err
 			}
 			toAddress = dao.Address
-			price = convert.StrTo(dao.Price).MustFloat64() / 1000
+			price = convert.StrTo(newSub.PayAmount).MustFloat64() / 1000
 			// pay
 			txID, err = point.Pay(ctx, pointSystem.PayRequest{
 				FromObject: address,
 				ToSubject:  toAddress,
-				Amount:     dao.Price,
+				Amount:     newSub.PayAmount,
 				Comment:    "",
-				Channel:    "sub_dao",
-				ReturnURI:  conf.PointSetting.Callback + "/pay/notify?method=sub_dao&order_id=" + orderID,
+				Channel:    PayMethodSubDao,
+				ReturnURI:  payReturnURI(PayMethodSubDao, orderID),
 				BindOrder:  orderID,
 			})
 			return err
 		})
 		if err != nil {
+			if errors.Is(err, model.ErrNoSuchDaoTier) {
+				err = errcode.NoExistDaoTier
+			}
 			return
 		}
 		e := ds.UpdateSubscribeDAOTxID(oid, txID)
@@ -485,6 +662,7 @@ func SubDao(ctx context.Context, daoID primitive.ObjectID, address string) (txID
 			logrus.Errorf("ds.UpdateSubscribeDAOTxID order_id:%s tx_id:%s err:%s", oid, txID, e)
 			// When an error occurs, wait for the callback to fix the txID again
 		}
+		setPayOrderTxID(ctx, PayMethodSubDao, oid, txID)
 	} else {
 		txID = sub.TxID
 		status = sub.Status
@@ -493,14 +671,16 @@ func SubDao(ctx context.Context, daoID primitive.ObjectID, address string) (txID
 		}
 		// sub order
 		oid = sub.ID.Hex()
-		notify, _ = pubsub.NewSubscribe(oid)
+		notify, _ = pubsub.NewSubscribe(payNotifyKey(PayMethodSubDao, oid))
 	}
 	// wait pay notify
-	select {
-	case <-ctx.Done():
-		err = ctx.Err()
-	case val := <-notify.Ch:
-		status = val.(core.DaoSubscribeT)
+	var txStatus string
+	txStatus, err = waitPayNotify(ctx, notify)
+	if err == nil {
+		status = core.DaoSubscribeFailed
+		if txStatus == TxCompleted {
+			status = core.DaoSubscribeSuccess
+		}
 		dao := &model.Dao{
 			ID: daoID,
 		}
@@ -569,6 +749,152 @@ func UpdateSubscribeDAO(orderID, txID string, status model.DaoSubscribeT) error
 	return ds.UpdateSubscribeDAO(orderID, txID, status)
 }
 
+// RefundSubDao pay the subscription back from the DAO to the subscriber. The DAO owner or an admin
+// can refund within conf.ExternalAppSetting.SubscribeRefundWindow after the order.
+func RefundSubDao(ctx context.Context, operator *model.User, orderID primitive.ObjectID, reason string) (status model.PayStatus, err error) {
+	db := conf.MustMongoDB()
+	sub := &model.DaoSubscribe{}
+	err = sub.FindOne(ctx, db, bson.M{"_id": orderID})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return status, errcode.SubscribeNoRefund
+	}
+	if err != nil {
+		return
+	}
+	dao, err := ds.GetDao(&model.Dao{ID: sub.DaoID})
+	if err != nil {
+		return status, errcode.NoExistDao
+	}
+	if operator.Address != dao.Address && !operator.HasRole(model.UserRoleFinance) {
+		return status, errcode.NoPermission
+	}
+	if time.Since(time.Unix(sub.CreatedAt, 0)) > conf.ExternalAppSetting.SubscribeRefundWindow {
+		return status, errcode.SubscribeRefundExpire
+	}
+	err = sub.StartRefund(ctx, db, operator.Address, reason)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// not paid, refunding or refunded
+		return status, errcode.SubscribeNoRefund
+	}
+	if err != nil {
+		return
+	}
+	oid := sub.RefundOrder
+	notify, err := pubsub.NewSubscribe(payNotifyKey(PayMethodRefundSubDao, oid))
+	if err != nil {
+		return
+	}
+	defer notify.Cancel()
+
+	err = createPayOrder(ctx, PayMethodRefundSubDao, oid)
+	if err != nil {
+		return
+	}
+	txID, err := point.Pay(ctx, pointSystem.PayRequest{
+		FromObject: dao.Address,
+		ToSubject:  sub.Address,
+		Amount:     sub.PayAmount,
+		Comment:    reason,
+		Channel:    PayMethodRefundSubDao,
+		ReturnURI:  payReturnURI(PayMethodRefundSubDao, oid),
+		BindOrder:  oid,
+	})
+	if err != nil {
+		// the reconciler fails the refund when the trade never reached the point system
+		return
+	}
+	if e := sub.SetRefundTxID(ctx, db, txID); e != nil {
+		logrus.Errorf("daoSubscribe.SetRefundTxID refund_order:%s tx_id:%s err:%s", oid, txID, e)
+	}
+	setPayOrderTxID(ctx, PayMethodRefundSubDao, oid, txID)
+
+	txStatus, err := waitPayNotify(ctx, notify)
+	if err != nil {
+		return
+	}
+	if txStatus == TxCompleted {
+		return model.PaySuccess, nil
+	}
+	return model.PayFailed, nil
+}
+
+func eventRefundSubDAO(notify PayCallbackParam) error {
+	var status model.PayStatus
+	switch notify.TxStatus {
+	case TxCompleted:
+		status = model.PaySuccess
+	case TxRollback, TxCancelled:
+		status = model.PayFailed
+	default:
+		return nil
+	}
+	ctx := context.Background()
+	sub := &model.DaoSubscribe{}
+	err := sub.FindOne(ctx, conf.MustMongoDB(), bson.M{"refund_order": notify.OrderId})
+	if err != nil {
+		logrus.Errorf("refund_sub_dao on notify: daoSubscribe.FindOne refund_order:%s err:%s", notify.OrderId, err)
+		return err
+	}
+	err = sub.SettleRefund(ctx, conf.MustMongoDB(), notify.TxID, status)
+	if err != nil {
+		logrus.Errorf("refund_sub_dao on notify: daoSubscribe.SettleRefund tx_status:%s tx_id:%s refund_order:%s err:%s", notify.TxStatus, notify.TxID, notify.OrderId, err)
+		return err
+	}
+	if status == model.PaySuccess {
+		notifySubDaoRefund(ctx, sub)
+	}
+	return nil
+}
+
+func notifySubDaoRefund(ctx context.Context, sub *model.DaoSubscribe) {
+	dao, err := ds.GetDao(&model.Dao{ID: sub.DaoID})
+	if err != nil {
+		logrus.Errorf("refund_sub_dao notify: get dao err:%s", err)
+		return
+	}
+	owner, err := ds.GetUserByAddress(dao.Address)
+	if err != nil {
+		logrus.Errorf("refund_sub_dao notify: get user err:%s", err)
+		return
+	}
+	user, err := ds.GetUserByAddress(sub.Address)
+	if err != nil {
+		logrus.Errorf("refund_sub_dao notify: get user err:%s", err)
+		return
+	}
+	price := convert.StrTo(sub.PayAmount).MustFloat64() / 1000
+	content := fmt.Sprintf("Your subscription to %s dao was refunded, received %f FavT", dao.Name, price)
+	notifyRequest := notify1.PushNotifyRequest{
+		IsSave:    true,
+		NetWorkId: conf.ExternalAppSetting.NetworkID,
+		Region:    conf.ExternalAppSetting.Region,
+		Title:     "Transaction",
+		Content:   content,
+		From:      "transaction",
+		FromType:  model.ORANGE,
+		To:        user.ID.Hex(),
+	}
+	err = notifyGateway.Notify(ctx, notifyRequest)
+	if err != nil {
+		logrus.Errorf("refund subscription err:%s", err)
+	}
+	content = fmt.Sprintf("Refunded %f FavT to %s(%s) for the subscription of your dao %s", price, user.Nickname, user.Address, dao.Name)
+	notifyRequest = notify1.PushNotifyRequest{
+		IsSave:    true,
+		NetWorkId: conf.ExternalAppSetting.NetworkID,
+		Region:    conf.ExternalAppSetting.Region,
+		Title:     "Transaction",
+		Content:   content,
+		From:      "transaction",
+		FromType:  model.ORANGE,
+		To:        owner.ID.Hex(),
+	}
+	err = notifyGateway.Notify(ctx, notifyRequest)
+	if err != nil {
+		logrus.Errorf("refund subscription err:%s", err)
+	}
+}
+
 func BlockDAO(user *model.User, id primitive.ObjectID) error {
 	_, err := ds.GetDao(&model.Dao{ID: id})
 	if err != nil {
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/errcode"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CheckDaoWritable the archived DAO takes no posts, comments, subscriptions or members
+func CheckDaoWritable(daoID primitive.ObjectID) *errcode.Error {
+	if daoID.IsZero() {
+		return nil
+	}
+	dao, err := ds.GetDao(&model.Dao{ID: daoID})
+	if err != nil {
+		// the existence is checked by the caller
+		return nil
+	}
+	if dao.IsArchived() {
+		return errcode.DaoArchived
+	}
+	return nil
+}
+
+// ArchiveDao the owner makes the DAO read only, the content stays readable but the DAO
+// is hidden from the recommend and its chat group is frozen.
+func ArchiveDao(ctx context.Context, user *model.User, daoID primitive.ObjectID) (*model.Dao, *errcode.Error) {
+	dao, err := ds.GetDao(&model.Dao{ID: daoID})
+	if err != nil {
+		return nil, errcode.NoExistDao
+	}
+	if dao.Address != user.Address {
+		return nil, errcode.NoPermission
+	}
+	if dao.IsArchived() {
+		return dao, nil
+	}
+	if err = dao.Archive(ctx, conf.MustMongoDB()); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		logrus.Errorf("dao.Archive dao_id:%s err:%s", daoID.Hex(), err)
+		return nil, errcode.ArchiveDaoFailed
+	}
+	if err = FreezeChatGroup(ctx, daoID.Hex(), true); err != nil {
+		logrus.Errorf("FreezeChatGroup dao_id:%s err:%s", daoID.Hex(), err)
+	}
+	return dao, nil
+}
+
+func UnarchiveDao(ctx context.Context, user *model.User, daoID primitive.ObjectID) (*model.Dao, *errcode.Error) {
+	dao, err := ds.GetDao(&model.Dao{ID: daoID})
+	if err != nil {
+		return nil, errcode.NoExistDao
+	}
+	if dao.Address != user.Address {
+		return nil, errcode.NoPermission
+	}
+	if !dao.IsArchived() {
+		return dao, nil
+	}
+	if err = dao.Unarchive(ctx, conf.MustMongoDB()); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		logrus.Errorf("dao.Unarchive dao_id:%s err:%s", daoID.Hex(), err)
+		return nil, errcode.ArchiveDaoFailed
+	}
+	if err = FreezeChatGroup(ctx, daoID.Hex(), false); err != nil {
+		logrus.Errorf("FreezeChatGroup dao_id:%s err:%s", daoID.Hex(), err)
+	}
+	return dao, nil
+}
+
+// DeleteDao the owner soft deletes the DAO, it is taken out of the search with its posts
+// and can be restored within conf.ExternalAppSetting.DaoRestoreWindow.
+func DeleteDao(ctx context.Context, user *model.User, daoID primitive.ObjectID) *errcode.Error {
+	dao, err := ds.GetDao(&model.Dao{ID: daoID})
+	if err != nil {
+		return errcode.NoExistDao
+	}
+	if dao.Address != user.Address {
+		return errcode.NoPermission
+	}
+	if err = ds.DeleteDao(dao); err != nil {
+		logrus.Errorf("ds.DeleteDao dao_id:%s err:%s", daoID.Hex(), err)
+		return errcode.DeleteDaoFailed
+	}
+	if err = FreezeChatGroup(ctx, daoID.Hex(), true); err != nil {
+		logrus.Errorf("FreezeChatGroup dao_id:%s err:%s", daoID.Hex(), err)
+	}
+	if err = DeleteSearchDao(dao); err != nil {
+		logrus.Warnf("delete dao %s from search err: %v", daoID.Hex(), err)
+	}
+	ids, err := dao.PostIDs(ctx, conf.MustMongoDB())
+	if err != nil {
+		logrus.Errorf("dao.PostIDs dao_id:%s err:%s", daoID.Hex(), err)
+		return nil
+	}
+	if len(ids) > 0 {
+		if err = DeleteSearchPost(&model.Post{ID: ids[0]}, ids[1:]...); err != nil {
+			logrus.Warnf("delete posts of dao %s from search err: %v", daoID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// RestoreDao the owner undoes the soft delete within the grace period, the DAO and its posts
+// are pushed to the search again.
+func RestoreDao(ctx context.Context, user *model.User, daoID primitive.ObjectID) (*model.Dao, *errcode.Error) {
+	dao, err := ds.GetDao(&model.Dao{ID: daoID, IsDel: 1})
+	if err != nil {
+		return nil, errcode.NoExistDao
+	}
+	if dao.Address != user.Address {
+		return nil, errcode.NoPermission
+	}
+	if dao.IsDel == 0 {
+		return dao, nil
+	}
+	db := conf.MustMongoDB()
+	since := time.Now().Add(-conf.ExternalAppSetting.DaoRestoreWindow).Unix()
+	if err = dao.Restore(ctx, db, since); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errcode.DaoRestoreExpired
+		}
+		logrus.Errorf("dao.Restore dao_id:%s err:%s", daoID.Hex(), err)
+		return nil, errcode.ServerError
+	}
+	if !dao.IsArchived() {
+		if err = FreezeChatGroup(ctx, daoID.Hex(), false); err != nil {
+			logrus.Errorf("FreezeChatGroup dao_id:%s err:%s", daoID.Hex(), err)
+		}
+	}
+	if _, err = PushDaoToSearch(dao); err != nil {
+		logrus.Warnf("dao restore, push dao %s to search err: %v", daoID.Hex(), err)
+	}
+	ids, err := dao.PostIDs(ctx, db)
+	if err != nil {
+		logrus.Errorf("dao.PostIDs dao_id:%s err:%s", daoID.Hex(), err)
+		return dao, nil
+	}
+	for _, id := range ids {
+		post, err := ds.GetPostByID(id)
+		if err != nil {
+			continue
+		}
+		PushPostToSearch(post)
+	}
+	return dao, nil
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/core"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/errcode"
+	notify1 "favor-dao-backend/pkg/notify"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	DaoJoinStatusJoined  = "joined"
+	DaoJoinStatusPending = "pending"
+)
+
+type DaoJoinReq struct {
+	Message string `json:"message" binding:"max=500"`
+	// Code the invite code, the holder joins without review
+	Code string `json:"code"`
+}
+
+type DaoJoinReviewReq struct {
+	Approve bool `json:"approve"`
+}
+
+type DaoInviteReq struct {
+	MaxUses int64 `json:"max_uses" binding:"required,min=1,max=10000"`
+	// Hours the code lasts
+	Hours int64 `json:"hours"    binding:"required,min=1,max=720"`
+}
+
+// JoinDao follow the DAO and join its chat group with the auth token of the address
+func JoinDao(address, daoID, token string) error {
+	_, err := CreateDaoBookmark(address, daoID, func(ctx context.Context, dao *model.Dao) (string, error) {
+		gid, err := JoinOrLeaveGroup(ctx, dao.ID.Hex(), true, token)
+		if err != nil {
+			return "", err
+		}
+		_, err = PushDaoToSearch(dao)
+		if err != nil {
+			return "", err
+		}
+		return gid, err
+	})
+	return err
+}
+
+// CheckDaoJoinable the private DAO is joined by a join request or an invite code
+func CheckDaoJoinable(address, daoID string) *errcode.Error {
+	dao, err := GetDao(daoID)
+	if err != nil {
+		return errcode.NoExistDao
+	}
+	if dao.IsArchived() {
+		return errcode.DaoArchived
+	}
+	if joinNeedsReview(dao, address) {
+		return errcode.DaoJoinNeedApproval
+	}
+	return nil
+}
+
+// GetHiddenDaoIDs the private DAOs the user does not follow, their posts are hidden from the user
+func GetHiddenDaoIDs(user *model.User) []string {
+	ids, err := (&model.Dao{}).PrivateIDs(context.TODO(), conf.MustMongoDB())
+	if err != nil {
+		logrus.Errorf("dao.PrivateIDs err:%s", err)
+		return nil
+	}
+	joined := map[string]bool{}
+	if user != nil {
+		for _, id := range GetDaoBookmarkIDsByAddress(user.Address) {
+			joined[id] = true
+		}
+	}
+	hidden := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !joined[id.Hex()] {
+			hidden = append(hidden, id.Hex())
+		}
+	}
+	return hidden
+}
+
+// joinNeedsReview anyone but the owner gets into a private DAO by an invite code or a reviewed request
+func joinNeedsReview(dao *model.Dao, address string) bool {
+	return dao.Visibility == model.DaoVisitPrivate && dao.Address != address
+}
+
+// RequestJoinDao join a public DAO at once, a private one with a valid invite code
+// or after the join request is approved.
+func RequestJoinDao(ctx context.Context, user *model.User, token string, daoID primitive.ObjectID, param DaoJoinReq) (string, *errcode.Error) {
+	dao, err := ds.GetDao(&model.Dao{ID: daoID})
+	if err != nil {
+		return "", errcode.NoExistDao
+	}
+	if ds.IsJoinedDAO(user.Address, daoID) {
+		return DaoJoinStatusJoined, nil
+	}
+	if dao.IsArchived() {
+		return "", errcode.DaoArchived
+	}
+	if e := CheckDaoBanned(daoID, user.Address); e != nil {
+		return "", e
+	}
+	db := conf.MustMongoDB()
+	if !joinNeedsReview(dao, user.Address) {
+		if err = JoinDao(user.Address, daoID.Hex(), token); err != nil {
+			logrus.Errorf("JoinDao dao_id:%s address:%s err:%s", daoID.Hex(), user.Address, err)
+			return "", errcode.DaoJoinFailed
+		}
+		return DaoJoinStatusJoined, nil
+	}
+
+	if param.Code != "" {
+		invite := &model.DaoInvite{DaoID: daoID, Code: param.Code}
+		if err = invite.Use(ctx, db); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return "", errcode.InvalidDaoInvite
+			}
+			logrus.Errorf("daoInvite.Use dao_id:%s err:%s", daoID.Hex(), err)
+			return "", errcode.ServerError
+		}
+		if err = JoinDao(user.Address, daoID.Hex(), token); err != nil {
+			logrus.Errorf("JoinDao dao_id:%s address:%s err:%s", daoID.Hex(), user.Address, err)
+			if err = invite.Release(ctx, db); err != nil {
+				logrus.Errorf("daoInvite.Release dao_id:%s err:%s", daoID.Hex(), err)
+			}
+			return "", errcode.DaoJoinFailed
+		}
+		return DaoJoinStatusJoined, nil
+	}
+
+	req := &model.DaoJoinRequest{DaoID: daoID, Address: user.Address}
+	err = req.Pending(ctx, db)
+	if err == nil {
+		return DaoJoinStatusPending, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		logrus.Errorf("daoJoinRequest.Pending dao_id:%s address:%s err:%s", daoID.Hex(), user.Address, err)
+		return "", errcode.ServerError
+	}
+	req.Message = param.Message
+	req.Status = model.DaoJoinPending
+	if err = req.Create(ctx, db); err != nil {
+		logrus.Errorf("daoJoinRequest.Create dao_id:%s address:%s err:%s", daoID.Hex(), user.Address, err)
+		return "", errcode.DaoJoinFailed
+	}
+	return DaoJoinStatusPending, nil
+}
+
+// JoinSubscribedDao the subscriber follows the DAO the way a join does, a private DAO gets
+// a join request for its owner to review.
+func JoinSubscribedDao(ctx context.Context, address, token string, daoID primitive.ObjectID) (string, *errcode.Error) {
+	user, err := ds.GetUserByAddress(address)
+	if err != nil {
+		return "", errcode.NoExistUserAddress
+	}
+	return RequestJoinDao(ctx, user, token, daoID, DaoJoinReq{Message: "Subscribed to the DAO"})
+}
+
+func ListDaoJoinRequests(ctx context.Context, operator *model.User, daoID primitive.ObjectID, offset, limit int) ([]*model.DaoJoinRequest, int64, *errcode.Error) {
+	if e := CheckDaoAllow(operator, &core.Action{Act: core.ActApproveJoin, DaoID: daoID}); e != nil {
+		return nil, 0, e
+	}
+	list, total, err := (&model.DaoJoinRequest{DaoID: daoID, Status: model.DaoJoinPending}).List(ctx, conf.MustMongoDB(), offset, limit)
+	if err != nil {
+		logrus.Errorf("daoJoinRequest.List dao_id:%s err:%s", daoID.Hex(), err)
+		return nil, 0, errcode.ServerError
+	}
+	return list, total, nil
+}
+
+// ReviewDaoJoin approve or reject the request, the approved address joins the DAO and its chat group.
+// An approved request is only closed once the requester joined, a failed join leaves it pending.
+func ReviewDaoJoin(ctx context.Context, operator *model.User, daoID, requestID primitive.ObjectID, param DaoJoinReviewReq) *errcode.Error {
+	if e := CheckDaoAllow(operator, &core.Action{Act: core.ActApproveJoin, DaoID: daoID}); e != nil {
+		return e
+	}
+	db := conf.MustMongoDB()
+	req := &model.DaoJoinRequest{DaoID: daoID}
+	req.ID = requestID
+	if err := req.Get(ctx, db); err != nil || req.Status != model.DaoJoinPending {
+		return errcode.NoExistDaoJoinRequest
+	}
+	dao, err := ds.GetDao(&model.Dao{ID: daoID})
+	if err != nil {
+		return errcode.NoExistDao
+	}
+	user, err := ds.GetUserByAddress(req.Address)
+	if err != nil {
+		return errcode.NoExistUserAddress
+	}
+	if !param.Approve {
+		if e := reviewDaoJoin(ctx, req, model.DaoJoinRejected, operator.Address); e != nil {
+			return e
+		}
+		notifyDaoJoin(ctx, dao, user, fmt.Sprintf("Your request to join the dao %s was rejected", dao.Name))
+		return nil
+	}
+	if !ds.IsJoinedDAO(req.Address, daoID) {
+		// the chat group is joined on behalf of the requester
+		token, err := chat.AuthToken(ctx, req.Address)
+		if err == nil {
+			err = JoinDao(req.Address, daoID.Hex(), token)
+		}
+		if err != nil {
+			logrus.Errorf("approve join dao_id:%s address:%s err:%s", daoID.Hex(), req.Address, err)
+			if errors.Is(err, model.ErrDaoBanned) {
+				return errcode.DaoMemberBanned
+			}
+			return errcode.DaoJoinFailed
+		}
+	}
+	if e := reviewDaoJoin(ctx, req, model.DaoJoinApproved, operator.Address); e != nil {
+		return e
+	}
+	notifyDaoJoin(ctx, dao, user, fmt.Sprintf("Your request to join the dao %s was approved", dao.Name))
+	return nil
+}
+
+func reviewDaoJoin(ctx context.Context, req *model.DaoJoinRequest, status model.DaoJoinT, by string) *errcode.Error {
+	if err := req.Review(ctx, conf.MustMongoDB(), status, by); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errcode.NoExistDaoJoinRequest
+		}
+		logrus.Errorf("daoJoinRequest.Review _id:%s err:%s", req.ID.Hex(), err)
+		return errcode.ServerError
+	}
+	return nil
+}
+
+// CreateDaoInvite only the owner mints the invite codes
+func CreateDaoInvite(ctx context.Context, operator *model.User, daoID primitive.ObjectID, param DaoInviteReq) (*model.DaoInvite, *errcode.Error) {
+	dao, err := ds.GetDao(&model.Dao{ID: daoID})
+	if err != nil {
+		return nil, errcode.NoExistDao
+	}
+	if dao.Address != operator.Address {
+		return nil, errcode.NoPermission
+	}
+	b := make([]byte, 8)
+	if _, err = rand.Read(b); err != nil {
+		return nil, errcode.ServerError
+	}
+	invite := &model.DaoInvite{
+		DaoID:     daoID,
+		Code:      hex.EncodeToString(b),
+		CreatedBy: operator.Address,
+		MaxUses:   param.MaxUses,
+		ExpiredOn: time.Now().Add(time.Duration(param.Hours) * time.Hour).Unix(),
+	}
+	if err = invite.Create(ctx, conf.MustMongoDB()); err != nil {
+		logrus.Errorf("daoInvite.Create dao_id:%s err:%s", daoID.Hex(), err)
+		return nil, errcode.ServerError
+	}
+	return invite, nil
+}
+
+func ListDaoInvites(ctx context.Context, operator *model.User, daoID primitive.ObjectID) ([]*model.DaoInvite, *errcode.Error) {
+	dao, err := ds.GetDao(&model.Dao{ID: daoID})
+	if err != nil {
+		return nil, errcode.NoExistDao
+	}
+	if dao.Address != operator.Address {
+		return nil, errcode.NoPermission
+	}
+	list, err := (&model.DaoInvite{DaoID: daoID}).List(ctx, conf.MustMongoDB())
+	if err != nil {
+		logrus.Errorf("daoInvite.List dao_id:%s err:%s", daoID.Hex(), err)
+		return nil, errcode.ServerError
+	}
+	return list, nil
+}
+
+func RevokeDaoInvite(ctx context.Context, operator *model.User, daoID primitive.ObjectID, code string) *errcode.Error {
+	dao, err := ds.GetDao(&model.Dao{ID: daoID})
+	if err != nil {
+		return errcode.NoExistDao
+	}
+	if dao.Address != operator.Address {
+		return errcode.NoPermission
+	}
+	err = (&model.DaoInvite{DaoID: daoID, Code: code}).Delete(ctx, conf.MustMongoDB())
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errcode.InvalidDaoInvite
+		}
+		logrus.Errorf("daoInvite.Delete dao_id:%s err:%s", daoID.Hex(), err)
+		return errcode.ServerError
+	}
+	return nil
+}
+
+func notifyDaoJoin(ctx context.Context, dao *model.Dao, to *model.User, content string) {
+	err := notifyGateway.Notify(ctx, notify1.PushNotifyRequest{
+		IsSave:    true,
+		NetWorkId: conf.ExternalAppSetting.NetworkID,
+		Region:    conf.ExternalAppSetting.Region,
+		Title:     "DAO join",
+		Content:   content,
+		From:      dao.ID.Hex(),
+		FromType:  model.DAO_TYPE,
+		To:        to.ID.Hex(),
+	})
+	if err != nil {
+		logrus.Errorf("dao join notify to:%s err:%s", to.Address, err)
+	}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/errcode"
+	"favor-dao-backend/pkg/pointSystem"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestJoinNeedsReview(t *testing.T) {
+	public := &model.Dao{Address: "owner", Visibility: model.DaoVisitPublic}
+	private := &model.Dao{Address: "owner", Visibility: model.DaoVisitPrivate}
+	tests := []struct {
+		name    string
+		dao     *model.Dao
+		address string
+		want    bool
+	}{
+		{"public", public, "subscriber", false},
+		{"public owner", public, "owner", false},
+		// a subscription does not open a private DAO, its owner reviews the request
+		{"private", private, "subscriber", true},
+		{"private owner", private, "owner", false},
+	}
+	for _, tt := range tests {
+		if got := joinNeedsReview(tt.dao, tt.address); got != tt.want {
+			t.Errorf("%s: joinNeedsReview = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReviewDaoJoin(t *testing.T) {
+	setupTestEnv(t, pointSystem.FakeOptions{})
+	ctx := context.Background()
+	db := conf.MustMongoDB()
+	owner := createTestUser(t, "owner")
+	d := createTestDao(t, owner, model.DaoVisitPrivate, "60")
+
+	for _, approve := range []bool{true, false} {
+		requester := createTestUser(t, "requester")
+		token, err := chat.AuthToken(ctx, requester.Address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		status, e := RequestJoinDao(ctx, requester, token, d.ID, DaoJoinReq{Message: "hi"})
+		if e != nil || status != DaoJoinStatusPending {
+			t.Fatalf("request to join the private DAO: %s %v, want pending", status, e)
+		}
+		if ds.IsJoinedDAO(requester.Address, d.ID) {
+			t.Fatal("joined before the review")
+		}
+		req := &model.DaoJoinRequest{DaoID: d.ID, Address: requester.Address}
+		if err = req.Pending(ctx, db); err != nil {
+			t.Fatalf("no pending request: %s", err)
+		}
+		if req.Message != "hi" {
+			t.Errorf("request message %q, want hi", req.Message)
+		}
+		// asked again while pending
+		if status, e = RequestJoinDao(ctx, requester, token, d.ID, DaoJoinReq{}); e != nil || status != DaoJoinStatusPending {
+			t.Errorf("request again: %s %v, want pending", status, e)
+		}
+
+		if e = ReviewDaoJoin(ctx, requester, d.ID, req.ID, DaoJoinReviewReq{Approve: true}); e == nil {
+			t.Error("the requester reviewed its own request")
+		}
+		if e = ReviewDaoJoin(ctx, owner, d.ID, req.ID, DaoJoinReviewReq{Approve: approve}); e != nil {
+			t.Fatal(e)
+		}
+		want := model.DaoJoinRejected
+		if approve {
+			want = model.DaoJoinApproved
+		}
+		if err = req.Get(ctx, db); err != nil {
+			t.Fatal(err)
+		}
+		if req.Status != want || req.ReviewedBy != owner.Address {
+			t.Errorf("approve %v: request %d by %s, want %d by the owner", approve, req.Status, req.ReviewedBy, want)
+		}
+		if got := ds.IsJoinedDAO(requester.Address, d.ID); got != approve {
+			t.Errorf("approve %v: joined %v", approve, got)
+		}
+		if e = ReviewDaoJoin(ctx, owner, d.ID, req.ID, DaoJoinReviewReq{Approve: approve}); e == nil {
+			t.Error("the request is reviewed twice")
+		}
+	}
+}
+
+func TestRequestJoinDaoInvite(t *testing.T) {
+	setupTestEnv(t, pointSystem.FakeOptions{})
+	ctx := context.Background()
+	db := conf.MustMongoDB()
+	owner, requester := createTestUser(t, "owner"), createTestUser(t, "requester")
+	d := createTestDao(t, owner, model.DaoVisitPrivate, "60")
+	invite := &model.DaoInvite{
+		DaoID:     d.ID,
+		Code:      primitive.NewObjectID().Hex(),
+		CreatedBy: owner.Address,
+		MaxUses:   1,
+		ExpiredOn: time.Now().Add(time.Hour).Unix(),
+	}
+	if err := invite.Create(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+	uses := func() int64 {
+		m := &model.DaoInvite{}
+		if err := db.Collection(m.Table()).FindOne(ctx, bson.M{"_id": invite.ID}).Decode(m); err != nil {
+			t.Fatal(err)
+		}
+		return m.Uses
+	}
+	token, err := chat.AuthToken(ctx, requester.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	param := DaoJoinReq{Code: invite.Code}
+
+	// the use is released when the join fails
+	ts = &fakeSearch{err: errors.New("search is down")}
+	if _, e := RequestJoinDao(ctx, requester, token, d.ID, param); e != errcode.DaoJoinFailed {
+		t.Fatalf("join with the search down: %v, want %v", e, errcode.DaoJoinFailed)
+	}
+	if n := uses(); n != 0 {
+		t.Errorf("invite used %d times after the failed join, want 0", n)
+	}
+	if ds.IsJoinedDAO(requester.Address, d.ID) {
+		t.Error("joined by a failed join")
+	}
+
+	ts = &fakeSearch{}
+	status, e := RequestJoinDao(ctx, requester, token, d.ID, param)
+	if e != nil || status != DaoJoinStatusJoined {
+		t.Fatalf("join with the invite: %s %v, want joined", status, e)
+	}
+	if n := uses(); n != 1 {
+		t.Errorf("invite used %d times, want 1", n)
+	}
+	if !ds.IsJoinedDAO(requester.Address, d.ID) {
+		t.Error("not joined with the invite")
+	}
+	if err = (&model.DaoJoinRequest{DaoID: d.ID, Address: requester.Address}).Pending(ctx, db); err == nil {
+		t.Error("a join request is left by the invite")
+	}
+
+	// the code is used up
+	other := createTestUser(t, "other")
+	if _, e = RequestJoinDao(ctx, other, token, d.ID, param); e != errcode.InvalidDaoInvite {
+		t.Errorf("join with the used up invite: %v, want %v", e, errcode.InvalidDaoInvite)
+	}
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/errcode"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type DaoKickReq struct {
+	Address string `json:"address"  binding:"required"`
+	Reason  string `json:"reason"   binding:"max=500"`
+	// Ban keeps the address from joining again
+	Ban bool `json:"ban"`
+	// Duration of the ban in seconds, 0 forever
+	Duration int64 `json:"duration" binding:"min=0"`
+}
+
+type DaoMemberFormatted struct {
+	Address  string        `json:"address"`
+	Nickname string        `json:"nickname"`
+	Avatar   string        `json:"avatar"`
+	Role     model.DaoRole `json:"role"`
+	JoinedOn int64         `json:"joined_on"`
+	// Tier of the active subscription, 0 not subscribed
+	Tier       model.PostMemberT `json:"tier"`
+	Subscribed bool              `json:"subscribed"`
+}
+
+// CheckDaoBanned the address is banned from the DAO
+func CheckDaoBanned(daoID primitive.ObjectID, address string) *errcode.Error {
+	banned, err := (&model.DaoBan{DaoID: daoID, Address: address}).IsBanned(context.TODO(), conf.MustMongoDB())
+	if err != nil {
+		logrus.Errorf("daoBan.IsBanned dao_id:%s address:%s err:%s", daoID.Hex(), address, err)
+		return errcode.ServerError
+	}
+	if banned {
+		return errcode.DaoMemberBanned
+	}
+	return nil
+}
+
+// ListDaoMembers the followers of the DAO, only seen by the followers
+func ListDaoMembers(ctx context.Context, viewer *model.User, daoID primitive.ObjectID, keyword string, offset, limit int) ([]*DaoMemberFormatted, int64, *errcode.Error) {
+	dao, err := ds.GetDao(&model.Dao{ID: daoID})
+	if err != nil {
+		return nil, 0, errcode.NoExistDao
+	}
+	db := conf.MustMongoDB()
+	if role, _ := dao.RoleOf(ctx, db, viewer.Address); role == model.DaoRoleNone {
+		return nil, 0, errcode.NoPermission
+	}
+	followers, total, err := (&model.DaoBookmark{DaoID: daoID}).ListFollowers(ctx, db, keyword, offset, limit)
+	if err != nil {
+		logrus.Errorf("daoBookmark.ListFollowers dao_id:%s err:%s", daoID.Hex(), err)
+		return nil, 0, errcode.ServerError
+	}
+	list := make([]*DaoMemberFormatted, 0, len(followers))
+	for _, f := range followers {
+		item := &DaoMemberFormatted{
+			Address:  f.Address,
+			JoinedOn: f.CreatedOn,
+		}
+		if len(f.Users) > 0 {
+			item.Nickname = f.Users[0].Nickname
+			item.Avatar = f.Users[0].Avatar
+		}
+		item.Role, _ = dao.RoleOf(ctx, db, f.Address)
+		item.Tier = ds.GetSubscribeTier(f.Address, daoID)
+		item.Subscribed = item.Tier != model.PostMemberNothing
+		list = append(list, item)
+	}
+	return list, total, nil
+}
+
+// KickDaoMember the owner removes the follower from the DAO and its chat group, with a ban
+// the address can not join again until the ban expires.
+func KickDaoMember(ctx context.Context, operator *model.User, daoID primitive.ObjectID, param DaoKickReq) *errcode.Error {
+	dao, err := ds.GetDao(&model.Dao{ID: daoID})
+	if err != nil {
+		return errcode.NoExistDao
+	}
+	if dao.Address != operator.Address || param.Address == dao.Address {
+		return errcode.NoPermission
+	}
+	// a follower is kicked, anyone can be banned ahead
+	book, err := GetDaoBookmark(param.Address, daoID.Hex())
+	follower := err == nil
+	if !follower && !param.Ban {
+		return errcode.NoExistDaoMember
+	}
+	db := conf.MustMongoDB()
+	if param.Ban {
+		ban := &model.DaoBan{
+			DaoID:    daoID,
+			Address:  param.Address,
+			Reason:   param.Reason,
+			BannedBy: operator.Address,
+		}
+		if param.Duration > 0 {
+			ban.ExpiredOn = time.Now().Unix() + param.Duration
+		}
+		if err = ban.Ban(ctx, db); err != nil {
+			logrus.Errorf("daoBan.Ban dao_id:%s address:%s err:%s", daoID.Hex(), param.Address, err)
+			return errcode.KickDaoMemberFailed
+		}
+	}
+	// the role goes with the membership
+	if err = (&model.DaoMember{DaoID: daoID, Address: param.Address}).Delete(ctx, db); err != nil {
+		logrus.Errorf("daoMember.Delete dao_id:%s address:%s err:%s", daoID.Hex(), param.Address, err)
+		return errcode.KickDaoMemberFailed
+	}
+	if !follower {
+		// not a follower, the ban alone is enough
+		return nil
+	}
+	err = DeleteDaoBookmark(book, func(ctx context.Context, dao *model.Dao) (string, error) {
+		gid, err := KickGroupMembers(ctx, dao.ID.Hex(), param.Address)
+		if err != nil {
+			return "", err
+		}
+		_, err = PushDaoToSearch(dao)
+		return gid, err
+	})
+	if err != nil {
+		logrus.Errorf("kick dao member dao_id:%s address:%s err:%s", daoID.Hex(), param.Address, err)
+		return errcode.KickDaoMemberFailed
+	}
+	return nil
+}
+
+func ListDaoBans(ctx context.Context, operator *model.User, daoID primitive.ObjectID) ([]*model.DaoBan, *errcode.Error) {
+	dao, err := ds.GetDao(&model.Dao{ID: daoID})
+	if err != nil {
+		return nil, errcode.NoExistDao
+	}
+	if dao.Address != operator.Address {
+		return nil, errcode.NoPermission
+	}
+	list, err := (&model.DaoBan{DaoID: daoID}).List(ctx, conf.MustMongoDB())
+	if err != nil {
+		logrus.Errorf("daoBan.List dao_id:%s err:%s", daoID.Hex(), err)
+		return nil, errcode.ServerError
+	}
+	return list, nil
+}
+
+func UnbanDaoMember(ctx context.Context, operator *model.User, daoID primitive.ObjectID, address string) *errcode.Error {
+	dao, err := ds.GetDao(&model.Dao{ID: daoID})
+	if err != nil {
+		return errcode.NoExistDao
+	}
+	if dao.Address != operator.Address {
+		return errcode.NoPermission
+	}
+	err = (&model.DaoBan{DaoID: daoID, Address: address}).Unban(ctx, conf.MustMongoDB())
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errcode.NotFound
+		}
+		logrus.Errorf("daoBan.Unban dao_id:%s address:%s err:%s", daoID.Hex(), address, err)
+		return errcode.ServerError
+	}
+	return nil
+}
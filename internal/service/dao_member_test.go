@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/errcode"
+	"favor-dao-backend/pkg/pointSystem"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestKickDaoMember(t *testing.T) {
+	setupTestEnv(t, pointSystem.FakeOptions{})
+	ctx := context.Background()
+	db := conf.MustMongoDB()
+	owner, admin, stranger := createTestUser(t, "owner"), createTestUser(t, "admin"), createTestUser(t, "stranger")
+	d := createTestDao(t, owner, model.DaoVisitPublic, "60")
+	if err := JoinDao(admin.Address, d.ID.Hex(), ""); err != nil {
+		t.Fatal(err)
+	}
+	role := &model.DaoMember{DaoID: d.ID, Address: admin.Address, Role: model.DaoRoleAdmin, GrantedBy: owner.Address}
+	if err := role.SetRole(ctx, db); err != nil {
+		t.Fatal(err)
+	}
+
+	if e := KickDaoMember(ctx, owner, d.ID, DaoKickReq{Address: admin.Address}); e != nil {
+		t.Fatal(e)
+	}
+	if ds.IsJoinedDAO(admin.Address, d.ID) {
+		t.Error("the kicked member still follows the DAO")
+	}
+	if err := (&model.DaoMember{DaoID: d.ID, Address: admin.Address}).First(ctx, db); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Errorf("the role of the kicked member is left: %v", err)
+	}
+
+	// no role record is made up for an address not in the DAO
+	if e := KickDaoMember(ctx, owner, d.ID, DaoKickReq{Address: stranger.Address}); e != errcode.NoExistDaoMember {
+		t.Errorf("kick a stranger: %v, want %v", e, errcode.NoExistDaoMember)
+	}
+	if e := KickDaoMember(ctx, owner, d.ID, DaoKickReq{Address: stranger.Address, Ban: true}); e != nil {
+		t.Fatalf("ban a stranger ahead: %v", e)
+	}
+	if err := (&model.DaoMember{DaoID: d.ID, Address: stranger.Address}).First(ctx, db); !errors.Is(err, mongo.ErrNoDocuments) {
+		t.Errorf("a role record is made for the banned stranger: %v", err)
+	}
+	if e := CheckDaoBanned(d.ID, stranger.Address); e != errcode.DaoMemberBanned {
+		t.Errorf("the stranger is not banned: %v", e)
+	}
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/errcode"
+	"favor-dao-backend/pkg/opengraph"
+	"github.com/hibiken/asynq"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	daoHomePageMaxLen  = 2048
+	daoPreviewTimeout  = 10 * time.Second
+	daoPreviewMaxBatch = 100
+)
+
+var previewClient = opengraph.NewClient(daoPreviewTimeout)
+
+// checkDaoHomePage the DaoWithURL links to an http(s) home page, the default DAO has none
+func checkDaoHomePage(typ model.DaoType, homePage string) *errcode.Error {
+	switch typ {
+	case model.DaoDefault:
+		if homePage != "" {
+			return errcode.InvalidDaoHomePage.WithDetails("only the DAO with url has a home page")
+		}
+		return nil
+	case model.DaoWithURL:
+	default:
+		return errcode.InvalidDaoHomePage.WithDetails("unknown DAO type")
+	}
+	if homePage == "" || len(homePage) > daoHomePageMaxLen {
+		return errcode.InvalidDaoHomePage
+	}
+	u, err := url.Parse(homePage)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return errcode.InvalidDaoHomePage
+	}
+	return nil
+}
+
+type DaoPreviewPayload struct {
+	// DaoID the DAO to fetch, the stale previews are refreshed when zero
+	DaoID primitive.ObjectID
+}
+
+const TypeDaoPreview = "dao:preview"
+
+func NewDaoPreviewTask(daoID primitive.ObjectID) *asynq.Task {
+	payload, _ := json.Marshal(DaoPreviewPayload{DaoID: daoID})
+	return asynq.NewTask(TypeDaoPreview, payload)
+}
+
+// enqueueDaoPreview fetch the preview of the new home page in the background
+func enqueueDaoPreview(dao *model.Dao) {
+	if dao.Type != model.DaoWithURL || dao.HomePage == "" {
+		return
+	}
+	_, err := queue.Enqueue(NewDaoPreviewTask(dao.ID), asynq.Queue(PostQueue))
+	if err != nil {
+		logrus.Errorf("dao preview %s enqueue failed: %v", dao.ID.Hex(), err)
+	}
+}
+
+func HandleDaoPreviewTask(ctx context.Context, t *asynq.Task) error {
+	var p DaoPreviewPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v", err)
+	}
+	if !p.DaoID.IsZero() {
+		dao, err := ds.GetDao(&model.Dao{ID: p.DaoID})
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return nil
+			}
+			return err
+		}
+		return fetchDaoPreview(ctx, dao)
+	}
+
+	before := time.Now().Add(-conf.ExternalAppSetting.DaoPreviewTTL).Unix()
+	list, err := (&model.Dao{}).FindStalePreviews(ctx, conf.MustMongoDB(), before, daoPreviewMaxBatch)
+	if err != nil {
+		return err
+	}
+	for _, dao := range list {
+		if err = fetchDaoPreview(ctx, dao); err != nil {
+			logrus.Errorf("fetchDaoPreview dao_id:%s err:%s", dao.ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// fetchDaoPreview cache the OpenGraph metadata of the home page in the DAO. A page which
+// can not be fetched is cached empty too, it is tried again when the preview is stale.
+func fetchDaoPreview(ctx context.Context, dao *model.Dao) error {
+	if dao.Type != model.DaoWithURL || dao.HomePage == "" {
+		return nil
+	}
+	preview := &model.DaoPreview{URL: dao.HomePage, FetchedOn: time.Now().Unix()}
+	meta, err := opengraph.Fetch(ctx, previewClient, dao.HomePage)
+	if err != nil {
+		logrus.Warnf("fetch preview of dao %s from %s err: %v", dao.ID.Hex(), dao.HomePage, err)
+	} else {
+		preview.Title = meta.Title
+		preview.Description = meta.Description
+		preview.Image = meta.Image
+		preview.SiteName = meta.SiteName
+	}
+	err = dao.SetPreview(ctx, conf.MustMongoDB(), preview)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// the home page was changed, its own task fetches it
+			return nil
+		}
+		return err
+	}
+	if _, err = PushDaoToSearch(dao); err != nil {
+		logrus.Warnf("dao preview, push dao %s to search err: %v", dao.ID.Hex(), err)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/core"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/errcode"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DaoRoleReq struct {
+	Address string        `json:"address" binding:"required"`
+	Role    model.DaoRole `json:"role"    binding:"required"`
+}
+
+type DaoGrantsReq struct {
+	Grants map[model.DaoRole][]model.DaoPermission `json:"grants" binding:"required"`
+}
+
+type ChatKickReq struct {
+	DaoID   primitive.ObjectID `json:"dao_id"  binding:"required"`
+	Address string             `json:"address" binding:"required"`
+}
+
+type DaoRolesResp struct {
+	Owner   string                                  `json:"owner"`
+	Members []*model.DaoMember                      `json:"members"`
+	Grants  map[model.DaoRole][]model.DaoPermission `json:"grants"`
+	// Role of the viewer
+	Role model.DaoRole `json:"role"`
+}
+
+// CheckDaoAllow the user holds a role of the DAO which is granted the act
+func CheckDaoAllow(user *model.User, action *core.Action) *errcode.Error {
+	if user == nil {
+		return errcode.NoPermission
+	}
+	dao, err := ds.GetDao(&model.Dao{ID: action.DaoID})
+	if err != nil {
+		return errcode.NoExistDao
+	}
+	if dao.IsArchived() && action.Act.IsCreate() {
+		return errcode.DaoArchived
+	}
+	if !ams.IsAllow(user, action) {
+		return errcode.NoPermission
+	}
+	return nil
+}
+
+func GetDaoRole(address string, daoID primitive.ObjectID) model.DaoRole {
+	dao, err := ds.GetDao(&model.Dao{ID: daoID})
+	if err != nil {
+		return model.DaoRoleNone
+	}
+	role, _ := dao.RoleOf(context.Background(), conf.MustMongoDB(), address)
+	return role
+}
+
+func GetDaoRoles(address string, daoID primitive.ObjectID) (*DaoRolesResp, *errcode.Error) {
+	dao, err := ds.GetDao(&model.Dao{ID: daoID})
+	if err != nil {
+		return nil, errcode.NoExistDao
+	}
+	ctx := context.Background()
+	members, err := (&model.DaoMember{DaoID: daoID}).List(ctx, conf.MustMongoDB())
+	if err != nil {
+		logrus.Errorf("daoMember.List dao_id:%s err:%s", daoID.Hex(), err)
+		return nil, errcode.ServerError
+	}
+	role, _ := dao.RoleOf(ctx, conf.MustMongoDB(), address)
+	return &DaoRolesResp{
+		Owner:   dao.Address,
+		Members: members,
+		Grants:  dao.DaoGrants(),
+		Role:    role,
+	}, nil
+}
+
+// SetDaoRole the owner assigns any role, an admin only moves addresses below admin
+// between moderator and member.
+func SetDaoRole(operator *model.User, daoID primitive.ObjectID, param DaoRoleReq) *errcode.Error {
+	if !param.Role.Assignable() {
+		return errcode.InvalidDaoRole
+	}
+	dao, err := ds.GetDao(&model.Dao{ID: daoID})
+	if err != nil {
+		return errcode.NoExistDao
+	}
+	if _, err = ds.GetUserByAddress(param.Address); err != nil {
+		return errcode.NoExistUserAddress
+	}
+	ctx := context.Background()
+	db := conf.MustMongoDB()
+	opRole, err := dao.RoleOf(ctx, db, operator.Address)
+	if err != nil {
+		return errcode.ServerError
+	}
+	current, err := dao.RoleOf(ctx, db, param.Address)
+	if err != nil {
+		return errcode.ServerError
+	}
+	if current == model.DaoRoleOwner {
+		return errcode.InvalidDaoRole
+	}
+	switch opRole {
+	case model.DaoRoleOwner:
+	case model.DaoRoleAdmin:
+		if current.Rank() >= opRole.Rank() || param.Role.Rank() >= opRole.Rank() {
+			return errcode.NoPermission
+		}
+	default:
+		return errcode.NoPermission
+	}
+	member := &model.DaoMember{DaoID: daoID, Address: param.Address, Role: param.Role, GrantedBy: operator.Address}
+	if err = member.SetRole(ctx, db); err != nil {
+		logrus.Errorf("daoMember.SetRole dao_id:%s address:%s err:%s", daoID.Hex(), param.Address, err)
+		return errcode.SetDaoRoleFailed
+	}
+	return nil
+}
+
+// SetDaoGrants only the owner decides what each role may do
+func SetDaoGrants(operator *model.User, daoID primitive.ObjectID, param DaoGrantsReq) *errcode.Error {
+	dao, err := ds.GetDao(&model.Dao{ID: daoID})
+	if err != nil {
+		return errcode.NoExistDao
+	}
+	if dao.Address != operator.Address {
+		return errcode.NoPermission
+	}
+	for role, perms := range param.Grants {
+		if !role.Assignable() {
+			return errcode.InvalidDaoGrants.WithDetails(string(role))
+		}
+		for _, perm := range perms {
+			if !perm.Valid() {
+				return errcode.InvalidDaoGrants.WithDetails(string(perm))
+			}
+		}
+	}
+	dao.Grants = param.Grants
+	err = ds.UpdateDao(dao, func(context.Context, *model.Dao) error { return nil })
+	if err != nil {
+		logrus.Errorf("dao grants dao_id:%s err:%s", daoID.Hex(), err)
+		return errcode.SetDaoRoleFailed
+	}
+	return nil
+}
+
+// KickChatMember remove the address from the chat group of the DAO
+func KickChatMember(ctx context.Context, operator *model.User, param ChatKickReq) *errcode.Error {
+	e := CheckDaoAllow(operator, &core.Action{Act: core.ActKickChatMember, DaoID: param.DaoID})
+	if e != nil {
+		return e
+	}
+	// nobody kicks a role at or above its own
+	if GetDaoRole(param.Address, param.DaoID).Rank() >= GetDaoRole(operator.Address, param.DaoID).Rank() {
+		return errcode.NoPermission
+	}
+	if _, err := KickGroupMembers(ctx, param.DaoID.Hex(), param.Address); err != nil {
+		logrus.Errorf("chat.KickGroupMember dao_id:%s address:%s err:%s", param.DaoID.Hex(), param.Address, err)
+		return errcode.ServerError
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/core"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/errcode"
+	"github.com/hibiken/asynq"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	daoStatsDateLayout = "2006-01-02"
+	daoStatsMaxDays    = 366
+	daoStatsDefaultDay = 30
+)
+
+type DaoStatsReq struct {
+	// From and To the UTC dates as 2006-01-02, both included
+	From string `form:"from"`
+	To   string `form:"to"`
+}
+
+type DaoStatsPostFormatted struct {
+	*model.DaoStatsPost `json:",inline"`
+	Post                *model.PostFormatted `json:"post"`
+}
+
+type DaoStatsResp struct {
+	From     int64                    `json:"from"`
+	To       int64                    `json:"to"`
+	Days     []*model.DaoStats        `json:"days"`
+	TopPosts []*DaoStatsPostFormatted `json:"top_posts"`
+}
+
+const TypeDaoStats = "dao:stats"
+
+func NewDaoStatsTask() *asynq.Task {
+	return asynq.NewTask(TypeDaoStats, nil)
+}
+
+// HandleDaoStatsTask roll up today and yesterday, yesterday is rolled up again to take
+// the activity between its last run and the midnight.
+func HandleDaoStatsTask(ctx context.Context, t *asynq.Task) error {
+	today := daoStatsDay(time.Now())
+	if err := rollupDaoStats(ctx, today.AddDate(0, 0, -1), false); err != nil {
+		return err
+	}
+	return rollupDaoStats(ctx, today, true)
+}
+
+func daoStatsDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// rollupDaoStats aggregate the activity of the day into the rollups of the DAOs. The views are only
+// counted as a total on the posts, so they are rolled up for today only.
+func rollupDaoStats(ctx context.Context, day time.Time, withViews bool) error {
+	db := conf.MustMongoDB()
+	start, end := day.Unix(), day.AddDate(0, 0, 1).Unix()
+
+	follows, unfollows, followers, err := (&model.DaoBookmark{}).DailyFollows(ctx, db, start, end)
+	if err != nil {
+		return err
+	}
+	subscriptions, revenue, err := (&model.DaoSubscribe{}).DailyRevenue(ctx, db, start, end)
+	if err != nil {
+		return err
+	}
+	engagement, err := model.DailyPostEngagement(ctx, db, start, end)
+	if err != nil {
+		return err
+	}
+	var viewTotals, lastViewTotals map[primitive.ObjectID]int64
+	if withViews {
+		viewTotals, err = (&model.Post{}).ViewTotals(ctx, db)
+		if err != nil {
+			return err
+		}
+		lastViewTotals, err = (&model.DaoStats{}).ViewTotals(ctx, db, day.AddDate(0, 0, -1).Unix())
+		if err != nil {
+			return err
+		}
+	}
+
+	ids := map[primitive.ObjectID]struct{}{}
+	for _, m := range []map[primitive.ObjectID]int64{follows, unfollows, followers, subscriptions, viewTotals} {
+		for id := range m {
+			ids[id] = struct{}{}
+		}
+	}
+	for id := range engagement {
+		ids[id] = struct{}{}
+	}
+	for id := range ids {
+		if id.IsZero() {
+			continue
+		}
+		stats := &model.DaoStats{
+			DaoID:         id,
+			Day:           start,
+			Followers:     followers[id],
+			Follows:       follows[id],
+			Unfollows:     unfollows[id],
+			Subscriptions: subscriptions[id],
+			Revenue:       revenue[id],
+			TopPosts:      []*model.DaoStatsPost{},
+		}
+		if stats.Revenue == "" {
+			stats.Revenue = "0"
+		}
+		for _, p := range engagement[id] {
+			stats.Upvotes += p.Upvotes
+			stats.Comments += p.Comments
+			stats.Collections += p.Collections
+		}
+		stats.TopPosts = append(stats.TopPosts, model.TopDaoStatsPosts(engagement[id], model.DaoStatsTopPosts)...)
+		if withViews {
+			stats.ViewTotal = viewTotals[id]
+			// the first rollup of the DAO has no baseline
+			if last, ok := lastViewTotals[id]; ok && stats.ViewTotal > last {
+				stats.Views = stats.ViewTotal - last
+			}
+		}
+		if err = stats.Save(ctx, db, withViews); err != nil {
+			logrus.Errorf("daoStats.Save dao_id:%s day:%d err:%s", id.Hex(), start, err)
+		}
+	}
+	return nil
+}
+
+// GetDaoStats the daily rollups of the DAO in the range and its top posts over the range,
+// seen by the roles managing the DAO.
+func GetDaoStats(ctx context.Context, user *model.User, daoID primitive.ObjectID, param DaoStatsReq) (*DaoStatsResp, *errcode.Error) {
+	if e := CheckDaoAllow(user, &core.Action{Act: core.ActEditDao, DaoID: daoID}); e != nil {
+		return nil, e
+	}
+	to := daoStatsDay(time.Now())
+	if param.To != "" {
+		t, err := time.Parse(daoStatsDateLayout, param.To)
+		if err != nil {
+			return nil, errcode.InvalidParams.WithDetails("to")
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, 1-daoStatsDefaultDay)
+	if param.From != "" {
+		t, err := time.Parse(daoStatsDateLayout, param.From)
+		if err != nil {
+			return nil, errcode.InvalidParams.WithDetails("from")
+		}
+		from = t
+	}
+	if from.After(to) || to.Sub(from) >= daoStatsMaxDays*24*time.Hour {
+		return nil, errcode.InvalidParams.WithDetails("the range is at most 366 days")
+	}
+
+	days, err := (&model.DaoStats{DaoID: daoID}).List(ctx, conf.MustMongoDB(), from.Unix(), to.Unix())
+	if err != nil {
+		logrus.Errorf("daoStats.List dao_id:%s err:%s", daoID.Hex(), err)
+		return nil, errcode.ServerError
+	}
+	// the days yet to come are left out
+	if today := daoStatsDay(time.Now()); to.After(today) {
+		days = model.FillDaoStatsDays(daoID, from, today, days)
+	} else {
+		days = model.FillDaoStatsDays(daoID, from, to, days)
+	}
+	merged := map[primitive.ObjectID]*model.DaoStatsPost{}
+	for _, d := range days {
+		for _, p := range d.TopPosts {
+			m, ok := merged[p.PostID]
+			if !ok {
+				m = &model.DaoStatsPost{PostID: p.PostID}
+				merged[p.PostID] = m
+			}
+			m.Upvotes += p.Upvotes
+			m.Comments += p.Comments
+			m.Collections += p.Collections
+		}
+		d.TopPosts = nil
+	}
+
+	resp := &DaoStatsResp{
+		From:     from.Unix(),
+		To:       to.Unix(),
+		Days:     days,
+		TopPosts: []*DaoStatsPostFormatted{},
+	}
+	top := model.TopDaoStatsPosts(merged, model.DaoStatsTopPosts)
+	var posts []*model.Post
+	for _, p := range top {
+		post, err := ds.GetPostByID(p.PostID)
+		if err != nil {
+			// deleted since
+			continue
+		}
+		posts = append(posts, post)
+	}
+	formatted, err := ds.MergePosts(user.Address, posts)
+	if err != nil {
+		logrus.Errorf("ds.MergePosts dao_id:%s err:%s", daoID.Hex(), err)
+		return nil, errcode.ServerError
+	}
+	byID := make(map[primitive.ObjectID]*model.PostFormatted, len(formatted))
+	for _, f := range formatted {
+		byID[f.ID] = f
+	}
+	for _, p := range top {
+		if f, ok := byID[p.PostID]; ok {
+			resp.TopPosts = append(resp.TopPosts, &DaoStatsPostFormatted{DaoStatsPost: p, Post: f})
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"testing"
+
+	"favor-dao-backend/internal/model"
+)
+
+func TestDaoPricingChanged(t *testing.T) {
+	gate := &model.TokenGate{Contract: "0x00000000000000000000000000000000000000AA", Standard: model.TokenERC20, Threshold: "5"}
+	tiers := []model.DaoTier{{Level: model.PostMember1, Price: "100", Days: 30}}
+	dao := &model.Dao{Price: "100", Tiers: tiers, Gate: gate}
+	plain := &model.Dao{Price: "100"}
+
+	tests := []struct {
+		name  string
+		dao   *model.Dao
+		param DaoUpdateReq
+		want  bool
+	}{
+		{"settings only", dao, DaoUpdateReq{Name: "new name"}, false},
+		{"same price", dao, DaoUpdateReq{Price: "100"}, false},
+		{"price", dao, DaoUpdateReq{Price: "200"}, true},
+		{"same tiers", dao, DaoUpdateReq{Tiers: []model.DaoTier{{Level: model.PostMember1, Price: "100", Days: 30}}}, false},
+		{"tiers", dao, DaoUpdateReq{Tiers: []model.DaoTier{{Level: model.PostMember1, Price: "50", Days: 30}}}, true},
+		{"tiers reset", dao, DaoUpdateReq{Tiers: []model.DaoTier{}}, true},
+		{"no tiers to reset", plain, DaoUpdateReq{Tiers: []model.DaoTier{}}, false},
+		{"same gate", dao, DaoUpdateReq{Gate: &model.TokenGate{Contract: "0x00000000000000000000000000000000000000aa", Standard: model.TokenERC20, Threshold: "5"}}, false},
+		{"gate", dao, DaoUpdateReq{Gate: &model.TokenGate{Contract: gate.Contract, Standard: model.TokenERC20, Threshold: "6"}}, true},
+		{"gate dropped", dao, DaoUpdateReq{Gate: &model.TokenGate{}}, true},
+		{"no gate to drop", plain, DaoUpdateReq{Gate: &model.TokenGate{}}, false},
+	}
+	for _, tt := range tests {
+		if got := daoPricingChanged(tt.dao, &tt.param); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
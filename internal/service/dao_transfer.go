@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/core"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/errcode"
+	notify1 "favor-dao-backend/pkg/notify"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// daoTransferTTL the recipient accepts the transfer in time, or the owner starts a new one
+const daoTransferTTL = 7 * 24 * time.Hour
+
+type DaoTransferReq struct {
+	AuthByWalletRequest `json:",inline"`
+	To                  string `json:"to" binding:"required"`
+}
+
+type DaoTransferAcceptReq struct {
+	AuthByWalletRequest `json:",inline"`
+}
+
+// DaoTransferMessage the message signed by the owner to start the transfer
+func DaoTransferMessage(wallet string, daoID primitive.ObjectID, to string, timestamp int64) string {
+	return fmt.Sprintf("%s transfer DAO %s to %s at %d", wallet, daoID.Hex(), to, timestamp)
+}
+
+// DaoTransferAcceptMessage the message signed by the recipient to accept the transfer
+func DaoTransferAcceptMessage(wallet string, daoID primitive.ObjectID, timestamp int64) string {
+	return fmt.Sprintf("%s accept DAO %s at %d", wallet, daoID.Hex(), timestamp)
+}
+
+// pendingDaoTransfer the transfer waiting for the recipient, the expired one is closed here.
+func pendingDaoTransfer(ctx context.Context, daoID primitive.ObjectID) (*model.DaoTransfer, error) {
+	db := conf.MustMongoDB()
+	if _, err := (&model.DaoTransfer{}).Expire(ctx, db, time.Now().Unix()); err != nil {
+		return nil, err
+	}
+	transfer := &model.DaoTransfer{DaoID: daoID}
+	if err := transfer.Pending(ctx, db); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// InitDaoTransfer the owner offers the DAO to a follower, the signature is verified by the caller.
+func InitDaoTransfer(ctx context.Context, user *model.User, daoID primitive.ObjectID, param DaoTransferReq) (*model.DaoTransfer, *errcode.Error) {
+	dao, err := ds.GetDao(&model.Dao{ID: daoID})
+	if err != nil {
+		return nil, errcode.NoExistDao
+	}
+	if dao.Address != user.Address {
+		return nil, errcode.NoPermission
+	}
+	if param.To == user.Address {
+		return nil, errcode.InvalidDaoTransfer
+	}
+	to, err := ds.GetUserByAddress(param.To)
+	if err != nil {
+		return nil, errcode.NoExistUserAddress
+	}
+	if !ds.IsJoinedDAO(param.To, daoID) {
+		return nil, errcode.InvalidDaoTransfer.WithDetails("the recipient does not follow the DAO")
+	}
+	_, err = pendingDaoTransfer(ctx, daoID)
+	if err == nil {
+		return nil, errcode.DaoTransferPending
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		logrus.Errorf("pendingDaoTransfer dao_id:%s err:%s", daoID.Hex(), err)
+		return nil, errcode.ServerError
+	}
+
+	transfer := &model.DaoTransfer{
+		DaoID:     daoID,
+		From:      user.Address,
+		To:        param.To,
+		Status:    model.DaoTransferPending,
+		ExpiredOn: time.Now().Add(daoTransferTTL).Unix(),
+		FromSign: model.TransferSign{
+			Type:      string(param.Type),
+			Timestamp: param.Timestamp,
+			Signature: param.Signature,
+		},
+	}
+	if err = transfer.Create(ctx, conf.MustMongoDB()); err != nil {
+		logrus.Errorf("daoTransfer.Create dao_id:%s err:%s", daoID.Hex(), err)
+		return nil, errcode.DaoTransferFailed
+	}
+	notifyDaoTransfer(ctx, dao, to, fmt.Sprintf("%s offered you the ownership of the dao %s", user.Nickname, dao.Name))
+	return transfer, nil
+}
+
+// AcceptDaoTransfer the recipient takes the DAO, the signature is verified by the caller.
+func AcceptDaoTransfer(ctx context.Context, user *model.User, daoID primitive.ObjectID, param DaoTransferAcceptReq) (*model.DaoTransfer, *errcode.Error) {
+	transfer, err := pendingDaoTransfer(ctx, daoID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errcode.NoDaoTransfer
+		}
+		logrus.Errorf("pendingDaoTransfer dao_id:%s err:%s", daoID.Hex(), err)
+		return nil, errcode.ServerError
+	}
+	if transfer.To != user.Address {
+		return nil, errcode.NoPermission
+	}
+	if !ds.IsJoinedDAO(user.Address, daoID) {
+		return nil, errcode.InvalidDaoTransfer.WithDetails("the recipient does not follow the DAO")
+	}
+	transfer.ToSign = &model.TransferSign{
+		Type:      string(param.Type),
+		Timestamp: param.Timestamp,
+		Signature: param.Signature,
+	}
+	postIDs, err := ds.TransferDao(transfer, func(ctx context.Context, dao *model.Dao) error {
+		return TransferChatGroup(ctx, dao.ID.Hex(), dao.Address)
+	})
+	if err != nil {
+		logrus.Errorf("ds.TransferDao dao_id:%s from:%s to:%s err:%s", daoID.Hex(), transfer.From, transfer.To, err)
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return nil, errcode.NoDaoTransfer
+		case errors.Is(err, model.ErrDaoOwnerChanged):
+			return nil, errcode.DaoTransferFailed.WithDetails(err.Error())
+		}
+		return nil, errcode.DaoTransferFailed
+	}
+
+	dao, err := ds.GetDao(&model.Dao{ID: daoID})
+	if err == nil {
+		if _, err = PushDaoToSearch(dao); err != nil {
+			logrus.Warnf("dao transfer, push dao %s to search err: %v", daoID.Hex(), err)
+		}
+	}
+	for _, id := range postIDs {
+		post, err := ds.GetPostByID(id)
+		if err != nil {
+			continue
+		}
+		PushPostToSearch(post)
+	}
+	if from, err := ds.GetUserByAddress(transfer.From); err == nil && dao != nil {
+		notifyDaoTransfer(ctx, dao, from, fmt.Sprintf("%s accepted the ownership of the dao %s", user.Nickname, dao.Name))
+	}
+	return transfer, nil
+}
+
+// CancelDaoTransfer the owner withdraws the transfer or the recipient declines it
+func CancelDaoTransfer(ctx context.Context, user *model.User, daoID primitive.ObjectID) *errcode.Error {
+	transfer, err := pendingDaoTransfer(ctx, daoID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errcode.NoDaoTransfer
+		}
+		logrus.Errorf("pendingDaoTransfer dao_id:%s err:%s", daoID.Hex(), err)
+		return errcode.ServerError
+	}
+	if transfer.From != user.Address && transfer.To != user.Address {
+		return errcode.NoPermission
+	}
+	err = transfer.Finish(ctx, conf.MustMongoDB(), model.DaoTransferCancelled, user.Address)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errcode.NoDaoTransfer
+		}
+		logrus.Errorf("daoTransfer.Finish _id:%s err:%s", transfer.ID.Hex(), err)
+		return errcode.DaoTransferFailed
+	}
+	return nil
+}
+
+// GetDaoTransfer the pending transfer, only seen by the owner and the recipient
+func GetDaoTransfer(ctx context.Context, user *model.User, daoID primitive.ObjectID) (*model.DaoTransfer, *errcode.Error) {
+	transfer, err := pendingDaoTransfer(ctx, daoID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errcode.NoDaoTransfer
+		}
+		logrus.Errorf("pendingDaoTransfer dao_id:%s err:%s", daoID.Hex(), err)
+		return nil, errcode.ServerError
+	}
+	if transfer.From != user.Address && transfer.To != user.Address {
+		return nil, errcode.NoPermission
+	}
+	return transfer, nil
+}
+
+// GetDaoTransfers the audit records of the ownership, seen by the roles managing the DAO
+func GetDaoTransfers(ctx context.Context, user *model.User, daoID primitive.ObjectID) ([]*model.DaoTransfer, *errcode.Error) {
+	if e := CheckDaoAllow(user, &core.Action{Act: core.ActEditDao, DaoID: daoID}); e != nil {
+		return nil, e
+	}
+	list, err := (&model.DaoTransfer{DaoID: daoID}).List(ctx, conf.MustMongoDB())
+	if err != nil {
+		logrus.Errorf("daoTransfer.List dao_id:%s err:%s", daoID.Hex(), err)
+		return nil, errcode.ServerError
+	}
+	return list, nil
+}
+
+func notifyDaoTransfer(ctx context.Context, dao *model.Dao, to *model.User, content string) {
+	err := notifyGateway.Notify(ctx, notify1.PushNotifyRequest{
+		IsSave:    true,
+		NetWorkId: conf.ExternalAppSetting.NetworkID,
+		Region:    conf.ExternalAppSetting.Region,
+		Title:     "DAO transfer",
+		Content:   content,
+		From:      dao.ID.Hex(),
+		FromType:  model.DAO_TYPE,
+		To:        to.ID.Hex(),
+	})
+	if err != nil {
+		logrus.Errorf("dao transfer notify to:%s err:%s", to.Address, err)
+	}
+}
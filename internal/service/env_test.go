@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/core"
+	"favor-dao-backend/internal/dao"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/hub"
+	"favor-dao-backend/pkg/pointSystem"
+	"favor-dao-backend/pkg/psub"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis_rate/v10"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// testMongoEnv the host of the MongoDB the service tests run against, e.g. localhost:27017.
+// The transactions need a replica set. The tests using the database are skipped without it.
+const testMongoEnv = "FAVOR_TEST_MONGO"
+
+const testConfig = `
+Logger:
+  Level: error
+Features:
+  Test: [ "PointFake", "LocalChat", "LocalNotify" ]
+External:
+  RedPacketTimeout: 300
+  RedPacketMaxCount: 200
+MongoDB:
+  Host: %s
+  DBName: %s
+Redis:
+  Host: %s
+Eth:
+  Endpoint: "http://localhost:8545"
+Point:
+  Callback: "http://localhost/v1"
+  Secret: "%s"
+  NotifyTimeout: 10
+`
+
+var (
+	testEnvOnce sync.Once
+	testEnvErr  error
+	// testEnvDB the throwaway database, dropped when the tests end
+	testEnvDB string
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if testEnvDB != "" {
+		if err := conf.MustMongoDB().Drop(context.Background()); err != nil {
+			fmt.Fprintf(os.Stderr, "drop test database %s: %s\n", testEnvDB, err)
+		}
+	}
+	os.Exit(code)
+}
+
+// setupTestEnv wire the services to a throwaway database on the MongoDB of FAVOR_TEST_MONGO and
+// an in-memory redis, with the fake point system. Its pay notify goes through PayNotify.
+func setupTestEnv(t *testing.T, opts pointSystem.FakeOptions) (*pointSystem.Fake, *fakeNotifier) {
+	host := os.Getenv(testMongoEnv)
+	if host == "" {
+		t.Skipf("%s is not set", testMongoEnv)
+	}
+	testEnvOnce.Do(func() {
+		testEnvErr = initTestEnv(host)
+	})
+	if testEnvErr != nil {
+		t.Fatal(testEnvErr)
+	}
+	// the callbacks must not land before the transaction of the pay commits
+	if opts.Delay == 0 {
+		opts.Delay = 200 * time.Millisecond
+	}
+	fake, n := setupFakePoint(t, opts)
+	n.deliver = PayNotify
+	ts = &fakeSearch{}
+	// callbacks in flight must not reach the fake of the next test
+	t.Cleanup(fake.Wait)
+	return fake, n
+}
+
+func initTestEnv(host string) error {
+	mr, err := miniredis.Run()
+	if err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp("", "favor-test")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	dbName := "favor_test_" + primitive.NewObjectID().Hex()
+	config := fmt.Sprintf(testConfig, host, dbName, mr.Addr(), testPaySecret)
+	if err = os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(config), 0o644); err != nil {
+		return err
+	}
+	conf.Initialize([]string{"Test"}, true, dir)
+	testEnvDB = dbName
+
+	limiter = redis_rate.NewLimiter(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	queue = asynq.NewClient(asynq.RedisClientOpt{Addr: mr.Addr()})
+	ds = dao.DataService()
+	ams = dao.AuthorizationManageService()
+	pubsub = psub.New(conf.Redis, "pay_notify")
+	chatHub = hub.New(conf.Redis, "chat")
+	notifyHub = hub.New(conf.Redis, "notify")
+	chat = dao.ChatService()
+	notifyGateway = newNotifyService()
+	return nil
+}
+
+// fakeSearch the search engine of the tests, AddDocuments fails with err when set
+type fakeSearch struct {
+	err error
+}
+
+var _ core.TweetSearchService = (*fakeSearch)(nil)
+
+func (s *fakeSearch) IndexName() string {
+	return "test"
+}
+
+func (s *fakeSearch) AddDocuments(_ core.DocItems, _ ...string) (bool, error) {
+	return s.err == nil, s.err
+}
+
+func (s *fakeSearch) DeleteDocuments(_ []string) error {
+	return nil
+}
+
+func (s *fakeSearch) Search(_ *core.QueryReq, _, _ int) (*core.QueryResp, error) {
+	return &core.QueryResp{}, nil
+}
+
+// createTestUser a user with a unique address
+func createTestUser(t *testing.T, name string) *model.User {
+	user := &model.User{
+		Nickname: name + primitive.NewObjectID().Hex()[16:],
+		Address:  name + "-" + primitive.NewObjectID().Hex(),
+	}
+	if _, err := user.Create(context.Background(), conf.MustMongoDB()); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// createTestDao a DAO of the owner, the tiers of the price when tiers are not given
+func createTestDao(t *testing.T, owner *model.User, visibility model.DaoVisibleT, price string, tiers ...model.DaoTier) *model.Dao {
+	d := &model.Dao{
+		Address:    owner.Address,
+		Name:       "dao-" + primitive.NewObjectID().Hex(),
+		Visibility: visibility,
+		Price:      price,
+		Tiers:      tiers,
+	}
+	if _, err := d.Create(context.Background(), conf.MustMongoDB()); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/errcode"
+	notify1 "favor-dao-backend/pkg/notify"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// moderationOrgan the organ key the moderation notifications are sent from
+const moderationOrgan = "moderation"
+
+type ComplaintResolveReq struct {
+	Action model.ComplaintAction `json:"action"   binding:"required"`
+	// Note to the author and the reporters
+	Note string `json:"note"     binding:"max=500"`
+	// Duration of the suspension in seconds, 0 forever
+	Duration int64 `json:"duration" binding:"min=0"`
+}
+
+type ComplaintQueueItem struct {
+	*model.ComplaintGroup `json:",inline"`
+	Post                  *model.PostFormatted `json:"post"`
+}
+
+// GetModerationQueue the reported posts with their pending complaints, the most reported first
+func GetModerationQueue(ctx context.Context, admin *model.User, offset, limit int) ([]*ComplaintQueueItem, int64, *errcode.Error) {
+	groups, total, err := (&model.PostComplaint{}).Queue(ctx, conf.MustMongoDB(), offset, limit)
+	if err != nil {
+		logrus.Errorf("postComplaint.Queue err:%s", err)
+		return nil, 0, errcode.ServerError
+	}
+	posts := make([]*model.Post, 0, len(groups))
+	for _, g := range groups {
+		post, err := ds.GetPostByID(g.PostID)
+		if err != nil {
+			continue
+		}
+		posts = append(posts, post)
+	}
+	formatted, err := ds.MergePosts(admin.Address, posts)
+	if err != nil {
+		logrus.Errorf("ds.MergePosts err:%s", err)
+		return nil, 0, errcode.ServerError
+	}
+	byID := make(map[primitive.ObjectID]*model.PostFormatted, len(formatted))
+	for _, p := range formatted {
+		byID[p.ID] = p
+	}
+	list := make([]*ComplaintQueueItem, 0, len(groups))
+	for _, g := range groups {
+		// the post is nil when it was deleted since
+		list = append(list, &ComplaintQueueItem{ComplaintGroup: g, Post: byID[g.PostID]})
+	}
+	return list, total, nil
+}
+
+// GetPostComplaints the pending complaints of the post
+func GetPostComplaints(ctx context.Context, postID primitive.ObjectID) ([]*model.PostComplaint, *errcode.Error) {
+	list, err := (&model.PostComplaint{PostID: postID}).Pending(ctx, conf.MustMongoDB())
+	if err != nil {
+		logrus.Errorf("postComplaint.Pending post_id:%s err:%s", postID.Hex(), err)
+		return nil, errcode.ServerError
+	}
+	return list, nil
+}
+
+// ResolveComplaints close the pending complaints of the post with the action, the author
+// and the reporters are notified of the outcome.
+func ResolveComplaints(ctx context.Context, admin *model.User, postID primitive.ObjectID, param ComplaintResolveReq) *errcode.Error {
+	if !param.Action.Valid() {
+		return errcode.InvalidComplaintAction
+	}
+	db := conf.MustMongoDB()
+	complaints, err := (&model.PostComplaint{PostID: postID}).Pending(ctx, db)
+	if err != nil {
+		logrus.Errorf("postComplaint.Pending post_id:%s err:%s", postID.Hex(), err)
+		return errcode.ServerError
+	}
+	if len(complaints) == 0 {
+		return errcode.NoPendingComplaint
+	}
+	post, err := ds.GetPostByID(postID)
+	if err != nil && param.Action != model.ComplaintDismiss {
+		// only dismissed once the post is gone
+		return errcode.GetPostFailed
+	}
+
+	var outcome string
+	switch param.Action {
+	case model.ComplaintDismiss:
+		outcome = "no violation was found"
+	case model.ComplaintHide:
+		if err = ds.VisiblePost(post, model.PostVisitPrivate); err != nil {
+			logrus.Errorf("ds.VisiblePost post_id:%s err:%s", postID.Hex(), err)
+			return errcode.ResolveComplaintFailed
+		}
+		post.Visibility = model.PostVisitPrivate
+		PushPostToSearch(post)
+		outcome = "the post was hidden"
+	case model.ComplaintBlacklist:
+		if e := blacklistPost(ctx, admin, postID); e != nil {
+			return e
+		}
+		outcome = "the post was removed from the platform"
+	case model.ComplaintWarn:
+		outcome = "the author was warned"
+	case model.ComplaintSuspend:
+		// the author is notified of the suspension itself
+		_, e := ImposeSanction(ctx, admin, post.Address, SanctionReq{
+			Type:     model.SanctionSuspend,
+			Reason:   param.Note,
+			Duration: param.Duration,
+		})
+		if e != nil {
+			return e
+		}
+		outcome = "the author was suspended"
+	}
+
+	if _, err = (&model.PostComplaint{PostID: postID}).Resolve(ctx, db, param.Action, admin.Address); err != nil {
+		logrus.Errorf("postComplaint.Resolve post_id:%s err:%s", postID.Hex(), err)
+		return errcode.ResolveComplaintFailed
+	}
+
+	reasons := map[string]struct{}{}
+	reporters := map[string]struct{}{}
+	for _, c := range complaints {
+		reasons[c.Reason] = struct{}{}
+		reporters[c.Address] = struct{}{}
+	}
+	if post != nil && param.Action != model.ComplaintDismiss && param.Action != model.ComplaintSuspend {
+		content := fmt.Sprintf("Your post was reported for %s, %s", joinKeys(reasons), outcome)
+		if param.Note != "" {
+			content += ": " + param.Note
+		}
+		notifyModeration(ctx, post.Address, content)
+	}
+	for address := range reporters {
+		notifyModeration(ctx, address, fmt.Sprintf("Thanks for your report, %s", outcome))
+	}
+	return nil
+}
+
+// blacklistPost hide the post from everyone by the global blacklist
+func blacklistPost(ctx context.Context, admin *model.User, postID primitive.ObjectID) *errcode.Error {
+	db := conf.MustMongoDB()
+	blacklist := &model.Blacklist{}
+	err := blacklist.FindOne(ctx, db, bson.M{"block_id": postID, "model": model.BlockModelPost})
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		logrus.Errorf("blacklist.FindOne post_id:%s err:%s", postID.Hex(), err)
+		return errcode.ServerError
+	}
+	blacklist = &model.Blacklist{Address: admin.Address, BlockId: postID, Model: model.BlockModelPost}
+	if err = blacklist.Create(ctx, db); err != nil {
+		logrus.Errorf("blacklist.Create post_id:%s err:%s", postID.Hex(), err)
+		return errcode.ResolveComplaintFailed
+	}
+	return nil
+}
+
+func joinKeys(m map[string]struct{}) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		if k != "" {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return "a violation"
+	}
+	return strings.Join(keys, ", ")
+}
+
+func notifyModeration(ctx context.Context, address, content string) {
+	user, err := ds.GetUserByAddress(address)
+	if err != nil {
+		return
+	}
+	err = notifyGateway.Notify(ctx, notify1.PushNotifyRequest{
+		IsSave:    true,
+		NetWorkId: conf.ExternalAppSetting.NetworkID,
+		Region:    conf.ExternalAppSetting.Region,
+		Title:     "Moderation",
+		Content:   content,
+		From:      moderationOrgan,
+		FromType:  model.ORANGE,
+		To:        user.ID.Hex(),
+	})
+	if err != nil {
+		logrus.Errorf("moderation notify to:%s err:%s", address, err)
+	}
+}
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"context"
+
+	"favor-dao-backend/internal/conf"
 	"favor-dao-backend/internal/model"
 	"favor-dao-backend/pkg/errcode"
 	"github.com/sirupsen/logrus"
@@ -177,12 +180,14 @@ func PutNotifyRead(from, to primitive.ObjectID) (bool, *errcode.Error) {
 		if err != nil {
 			return false, errcode.CreateMsgReadFailed
 		}
+		publishNotifyUnread(context.Background(), from, to)
 		return true, nil
 	}
 	result, err := ds.UpdateReadAt(mr)
 	if err != nil {
 		return false, errcode.UpdateMsgReadFailed
 	}
+	publishNotifyUnread(context.Background(), from, to)
 	return result, nil
 }
 
@@ -230,6 +235,7 @@ func DeleteNotifyByFrom(from, to primitive.ObjectID) (bool, *errcode.Error) {
 			return false, errcode.DeleteMsgFailed
 		}
 	}
+	publishNotifyUnread(context.Background(), from, to)
 	return true, nil
 }
 
@@ -244,3 +250,29 @@ func NotifySys(from primitive.ObjectID, pageSize, pageNum int) (*[]model.MsgSys,
 	}
 	return list, count, nil
 }
+
+func MuteNotify(userID, from primitive.ObjectID) *errcode.Error {
+	err := (&model.NotifyMute{UserID: userID, From: from}).Mute(context.Background(), conf.MustMongoDB())
+	if err != nil {
+		logrus.Errorf("notifyMute.Mute user_id:%s from:%s err:%s", userID.Hex(), from.Hex(), err)
+		return errcode.MuteNotifyFailed
+	}
+	return nil
+}
+
+func UnmuteNotify(userID, from primitive.ObjectID) *errcode.Error {
+	err := (&model.NotifyMute{UserID: userID, From: from}).Unmute(context.Background(), conf.MustMongoDB())
+	if err != nil {
+		logrus.Errorf("notifyMute.Unmute user_id:%s from:%s err:%s", userID.Hex(), from.Hex(), err)
+		return errcode.UnmuteNotifyFailed
+	}
+	return nil
+}
+
+func ListNotifyMutes(userID primitive.ObjectID) ([]*model.NotifyMute, *errcode.Error) {
+	list, err := (&model.NotifyMute{UserID: userID}).List(context.Background(), conf.MustMongoDB())
+	if err != nil {
+		return nil, errcode.GetNotifyMutesFailed
+	}
+	return list, nil
+}
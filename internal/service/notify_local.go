@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/json"
+	"favor-dao-backend/pkg/notify"
+	"github.com/hibiken/asynq"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	NotifyQueue   = "notify"
+	TypeNotifyDao = "notify:dao"
+)
+
+// localNotify writes the notifications to msg/msg_send/msg_sys instead of posting
+// them to the gateway, the followers of a dao are reached in batches by the job server.
+type localNotify struct{}
+
+var _ notify.Service = localNotify{}
+
+func newNotifyService() notify.Service {
+	if conf.CfgIf("LocalNotify") {
+		logrus.Infof("use local notify with fanout batch %d", conf.NotifySetting.FanoutBatch)
+		return localNotify{}
+	}
+	return notify.New(conf.NotifySetting.Gateway)
+}
+
+// notifyFrom the organ is given by its key, others by the object id
+func notifyFrom(from string, fromType model.FromTypeEnum) (primitive.ObjectID, error) {
+	if fromType == model.ORANGE {
+		organ, err := ds.GetOrganByKey(from)
+		if err != nil {
+			return primitive.NilObjectID, fmt.Errorf("organ %s: %w", from, err)
+		}
+		return organ.ID, nil
+	}
+	return primitive.ObjectIDFromHex(from)
+}
+
+func (localNotify) Notify(ctx context.Context, req notify.PushNotifyRequest) error {
+	from, err := notifyFrom(req.From, req.FromType)
+	if err != nil {
+		return err
+	}
+	to, err := primitive.ObjectIDFromHex(req.To)
+	if err != nil {
+		return err
+	}
+	db := conf.MustMongoDB()
+	muted, err := (&model.NotifyMute{UserID: to, From: from}).IsMuted(ctx, db)
+	if err != nil || muted {
+		return err
+	}
+	msg := &model.Msg{Title: req.Title, Content: req.Content, Links: req.Links}
+	if !req.IsSave {
+		msg.CreatedAt = time.Now().Unix()
+		publishNotify(ctx, to, &NotifyEvent{Type: NotifyEventMsg, From: from.Hex(), FromType: req.FromType, Msg: msg})
+		return nil
+	}
+	if _, err = msg.Create(ctx, db); err != nil {
+		return err
+	}
+	send := &model.MsgSend{MsgID: msg.ID, From: from, To: to, FromType: req.FromType}
+	_, err = send.Create(ctx, db)
+	return err
+}
+
+func (localNotify) NotifyDao(ctx context.Context, req notify.PushNotifyRequest) error {
+	daoID, err := primitive.ObjectIDFromHex(req.From)
+	if err != nil {
+		return err
+	}
+	payload := NotifyDaoPayload{
+		DaoID:    daoID.Hex(),
+		FromType: req.FromType,
+		Save:     req.IsSave,
+		Msg:      model.Msg{Title: req.Title, Content: req.Content, Links: req.Links},
+	}
+	if req.IsSave {
+		if _, err = payload.Msg.Create(ctx, conf.MustMongoDB()); err != nil {
+			return err
+		}
+	} else {
+		payload.Msg.CreatedAt = time.Now().Unix()
+	}
+	_, err = queue.Enqueue(NewNotifyDaoTask(payload), asynq.Queue(NotifyQueue))
+	return err
+}
+
+// NotifySys the system messages are read by everyone, there is nothing to fan out.
+func (localNotify) NotifySys(ctx context.Context, req notify.PushNotifySysRequest) error {
+	if !req.IsSave {
+		return nil
+	}
+	from, err := notifyFrom(req.From, model.ORANGE)
+	if err != nil {
+		return err
+	}
+	_, err = (&model.MsgSys{From: from, Title: req.Title, Content: req.Content, Links: req.Links}).Create(ctx, conf.MustMongoDB())
+	return err
+}
+
+type NotifyDaoPayload struct {
+	DaoID    string             `json:"dao_id"`
+	FromType model.FromTypeEnum `json:"from_type"`
+	Save     bool               `json:"save"`
+	Msg      model.Msg          `json:"msg"`
+	// After the last bookmark notified by the previous batch
+	After string `json:"after,omitempty"`
+}
+
+func NewNotifyDaoTask(p NotifyDaoPayload) *asynq.Task {
+	payload, _ := json.Marshal(p)
+	return asynq.NewTask(TypeNotifyDao, payload)
+}
+
+// HandleNotifyDaoTask notify one batch of the followers, the next batch is enqueued as a new task
+// so a retry never repeats the batches already done.
+func HandleNotifyDaoTask(ctx context.Context, t *asynq.Task) (err error) {
+	var p NotifyDaoPayload
+	if err = json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v: %w", err, asynq.SkipRetry)
+	}
+	daoID, err := primitive.ObjectIDFromHex(p.DaoID)
+	if err != nil {
+		return fmt.Errorf("dao_id %s: %w", p.DaoID, asynq.SkipRetry)
+	}
+	var after primitive.ObjectID
+	if p.After != "" {
+		if after, err = primitive.ObjectIDFromHex(p.After); err != nil {
+			return fmt.Errorf("after %s: %w", p.After, asynq.SkipRetry)
+		}
+	}
+	db := conf.MustMongoDB()
+	limit := conf.NotifySetting.FanoutBatch
+	bookmarks, err := (&model.DaoBookmark{DaoID: daoID}).ListByDao(ctx, db, after, limit)
+	if err != nil {
+		return err
+	}
+	if len(bookmarks) == 0 {
+		return nil
+	}
+	addresses := make([]string, 0, len(bookmarks))
+	for _, v := range bookmarks {
+		addresses = append(addresses, v.Address)
+	}
+	users, err := ds.GetUsersByAddresses(addresses)
+	if err != nil {
+		return err
+	}
+	userIDs := make([]primitive.ObjectID, 0, len(users))
+	for _, u := range users {
+		userIDs = append(userIDs, u.ID)
+	}
+	muted, err := (&model.NotifyMute{From: daoID}).MutedUsers(ctx, db, userIDs)
+	if err != nil {
+		return err
+	}
+
+	sends := make([]*model.MsgSend, 0, len(userIDs))
+	for _, id := range userIDs {
+		if muted[id] {
+			continue
+		}
+		if !p.Save {
+			publishNotify(ctx, id, &NotifyEvent{Type: NotifyEventMsg, From: p.DaoID, FromType: p.FromType, Msg: &p.Msg})
+			continue
+		}
+		sends = append(sends, &model.MsgSend{MsgID: p.Msg.ID, From: daoID, To: id, FromType: p.FromType})
+	}
+	if len(sends) > 0 {
+		if err = (&model.MsgSend{}).CreateMany(ctx, db, sends); err != nil {
+			return err
+		}
+	}
+	logrus.Debugf("notify dao %s: %d followers after %s, %d muted", p.DaoID, len(bookmarks), p.After, len(muted))
+
+	if len(bookmarks) < limit {
+		return nil
+	}
+	p.After = bookmarks[len(bookmarks)-1].ID.Hex()
+	_, err = queue.Enqueue(NewNotifyDaoTask(p), asynq.Queue(NotifyQueue))
+	return err
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/hub"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	NotifyEventMsg       = "notify"
+	NotifyEventUnread    = "unread"
+	NotifyEventRedpacket = "redpacket_claim"
+
+	notifyStreamLeaderKey = "notify_stream:leader"
+	notifyStreamResumeKey = "notify_stream:resume"
+	notifyStreamLease     = 30 * time.Second
+)
+
+// NotifyEvent is pushed to the notification stream of the user
+type NotifyEvent struct {
+	Type        string                `json:"type"`
+	From        string                `json:"from,omitempty"`
+	FromType    model.FromTypeEnum    `json:"fromType"`
+	Msg         *model.Msg            `json:"msg,omitempty"`
+	UnreadCount int64                 `json:"unreadCount"`
+	Claim       *model.RedpacketClaim `json:"claim,omitempty"`
+}
+
+func notifyUserKey(userID primitive.ObjectID) string {
+	return "user:" + userID.Hex()
+}
+
+// SubscribeNotify the notification events of the user
+func SubscribeNotify(userID primitive.ObjectID) *hub.Subscription {
+	return notifyHub.Subscribe(32, notifyUserKey(userID))
+}
+
+func publishNotify(ctx context.Context, to primitive.ObjectID, event *NotifyEvent) {
+	if err := notifyHub.PublishJSON(ctx, notifyUserKey(to), event); err != nil {
+		logrus.Errorf("notifyHub.Publish to:%s type:%s err:%s", to.Hex(), event.Type, err)
+	}
+}
+
+func notifyUnread(from, to primitive.ObjectID) int64 {
+	var readAt int64
+	if mr, _ := ds.GetMsgRead(from, to); mr != nil {
+		readAt = mr.ReadAt
+	}
+	count, _ := ds.CountUnreadMsg(from, to, readAt)
+	return count
+}
+
+func publishNotifyUnread(ctx context.Context, from, to primitive.ObjectID) {
+	publishNotify(ctx, to, &NotifyEvent{
+		Type:        NotifyEventUnread,
+		From:        from.Hex(),
+		UnreadCount: notifyUnread(from, to),
+	})
+}
+
+func publishMsgSend(ctx context.Context, ms *model.MsgSend) {
+	msg, err := ds.GetMsgById(ms.MsgID)
+	if err != nil {
+		logrus.Errorf("notify stream: GetMsgById msg_id:%s err:%s", ms.MsgID.Hex(), err)
+		return
+	}
+	publishNotify(ctx, ms.To, &NotifyEvent{
+		Type:        NotifyEventMsg,
+		From:        ms.From.Hex(),
+		FromType:    ms.FromType,
+		Msg:         msg,
+		UnreadCount: notifyUnread(ms.From, ms.To),
+	})
+}
+
+// publishRedpacketClaim tell the claimer and the sender of the redpacket about a settled claim
+func publishRedpacketClaim(ctx context.Context, claim *model.RedpacketClaim) {
+	rp := &model.Redpacket{}
+	rp.ID = claim.RedpacketId
+	if err := rp.First(ctx, conf.MustMongoDB()); err != nil {
+		logrus.Errorf("notify stream: redpacket.First _id:%s err:%s", claim.RedpacketId.Hex(), err)
+		return
+	}
+	addresses := []string{claim.Address}
+	if rp.Address != claim.Address {
+		addresses = append(addresses, rp.Address)
+	}
+	for _, address := range addresses {
+		user, err := ds.GetUserByAddress(address)
+		if err != nil {
+			continue
+		}
+		publishNotify(ctx, user.ID, &NotifyEvent{Type: NotifyEventRedpacket, Claim: claim})
+	}
+}
+
+// runNotifyWatcher the messages are written by the notify gateway, one node holding the lease
+// watches the inserts of msg_send and publishes them to all nodes.
+func runNotifyWatcher(ctx context.Context) {
+	node := primitive.NewObjectID().Hex()
+	for {
+		ok, err := conf.Redis.SetNX(ctx, notifyStreamLeaderKey, node, notifyStreamLease).Result()
+		if err != nil {
+			logrus.Errorf("notify stream: acquire lease err:%s", err)
+		} else if ok {
+			watchMsgSend(ctx, node)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(notifyStreamLease / 3):
+		}
+	}
+}
+
+func watchMsgSend(ctx context.Context, node string) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer func() {
+		if conf.Redis.Get(context.Background(), notifyStreamLeaderKey).Val() == node {
+			conf.Redis.Del(context.Background(), notifyStreamLeaderKey)
+		}
+	}()
+	go func() {
+		ticker := time.NewTicker(notifyStreamLease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if conf.Redis.Get(ctx, notifyStreamLeaderKey).Val() != node {
+					cancel()
+					return
+				}
+				conf.Redis.Expire(ctx, notifyStreamLeaderKey, notifyStreamLease)
+			}
+		}
+	}()
+
+	opts := options.ChangeStream()
+	if token, err := conf.Redis.Get(ctx, notifyStreamResumeKey).Bytes(); err == nil {
+		opts.SetStartAfter(bson.Raw(token))
+	}
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{"operationType": "insert"}}}}
+	stream, err := conf.MustMongoDB().Collection((&model.MsgSend{}).Table()).Watch(ctx, pipeline, opts)
+	if err != nil {
+		logrus.Warnf("notify stream: watch msg_send err:%s", err)
+		return
+	}
+	defer stream.Close(context.Background())
+	for stream.Next(ctx) {
+		var change struct {
+			FullDocument model.MsgSend `bson:"fullDocument"`
+		}
+		if err = stream.Decode(&change); err != nil {
+			logrus.Errorf("notify stream: decode change err:%s", err)
+			continue
+		}
+		publishMsgSend(ctx, &change.FullDocument)
+		conf.Redis.Set(ctx, notifyStreamResumeKey, []byte(stream.ResumeToken()), 24*time.Hour)
+	}
+	if err = stream.Err(); err != nil && ctx.Err() == nil {
+		logrus.Warnf("notify stream: watch msg_send err:%s", err)
+	}
+}
@@ -3,11 +3,18 @@ package service
 import (
 	"context"
 	"errors"
+	"time"
 
+	"favor-dao-backend/internal/conf"
 	"favor-dao-backend/internal/core"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/errcode"
 	"favor-dao-backend/pkg/pointSystem"
+	"favor-dao-backend/pkg/psub"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type PayCallbackParam struct {
@@ -19,50 +26,189 @@ type PayCallbackParam struct {
 }
 
 const (
-	TxPending    = "pending"
-	TxInProgress = "in_progress"
-	TxCompleted  = "completed"
-	TxFailed     = "failed"
-	TxCancelled  = "cancelled"
-	TxRollback   = "rollback"
-	TxCrashed    = "crashed"
+	TxPending    = pointSystem.TxPending
+	TxInProgress = pointSystem.TxInProgress
+	TxCompleted  = pointSystem.TxCompleted
+	TxFailed     = pointSystem.TxFailed
+	TxCancelled  = pointSystem.TxCancelled
+	TxRollback   = pointSystem.TxRollback
+	TxCrashed    = pointSystem.TxCrashed
 )
 
+const (
+	PayMethodSubDao          = "sub_dao"
+	PayMethodSendRedpacket   = "send_redpacket"
+	PayMethodClaimRedpacket  = "claim_redpacket"
+	PayMethodRefundRedpacket = "refund_redpacket"
+	PayMethodRefundSubDao    = "refund_sub_dao"
+)
+
+func payReturnURI(method, orderID string) string {
+	return conf.PointSetting.Callback + "/pay/notify?method=" + method + "&order_id=" + orderID
+}
+
+// payStatusOf the outcome of the trade, false while it is not settled
+func payStatusOf(txStatus string) (model.PayStatus, bool) {
+	switch txStatus {
+	case TxCompleted:
+		return model.PaySuccess, true
+	case TxRollback, TxCancelled:
+		return model.PayFailed, true
+	}
+	return model.PaySubmit, false
+}
+
+// payNotifyKey the pubsub key of the order waiting for pay notify
+func payNotifyKey(method, orderID string) string {
+	return method + ":" + orderID
+}
+
+// createPayOrder track the order until it is settled by the pay notify or the reconciler
+func createPayOrder(ctx context.Context, method, orderID string) error {
+	order := &model.PayOrder{Method: method, OrderID: orderID}
+	return order.Create(ctx, conf.MustMongoDB())
+}
+
+func setPayOrderTxID(ctx context.Context, method, orderID, txID string) {
+	order := &model.PayOrder{Method: method, OrderID: orderID}
+	err := order.SetTxID(ctx, conf.MustMongoDB(), txID)
+	if err != nil {
+		logrus.Errorf("payOrder.SetTxID method:%s order_id:%s tx_id:%s err:%s", method, orderID, txID, err)
+		// the pay notify or the reconciler will fix the txID
+	}
+}
+
+// waitPayNotify wait for the pay notify of the order, which may land on any node
+func waitPayNotify(ctx context.Context, notify *psub.Notify) (txStatus string, err error) {
+	timer := time.NewTimer(conf.PointSetting.NotifyTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-timer.C:
+		// the order is left to the reconciler
+		return "", errcode.PayNotifyTimeout
+	case txStatus = <-notify.Ch:
+		return txStatus, nil
+	}
+}
+
 func PayNotify(notify PayCallbackParam) (err error) {
 	logrus.Infof("PayNotify method:%s order_id:%s tx_id:%s tx_status:%s", notify.Method, notify.OrderId, notify.TxID, notify.TxStatus)
+
+	var final model.PayOrderStatus
+	switch notify.TxStatus {
+	case TxCompleted:
+		final = model.PayOrderSuccess
+	case TxRollback, TxCancelled:
+		final = model.PayOrderFailed
+	default:
+		return payEvent(notify)
+	}
+
+	ctx := context.Background()
+	db := conf.MustMongoDB()
+	order := &model.PayOrder{Method: notify.Method, OrderID: notify.OrderId, TxID: notify.TxID, TxStatus: notify.TxStatus}
+	tracked := true
+	err = order.Transit(ctx, db, model.PayOrderSubmit, model.PayOrderSettling)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if order.First(ctx, db) == nil {
+			// settling or settled by another notify
+			logrus.Infof("PayNotify method:%s order_id:%s already %d", notify.Method, notify.OrderId, order.Status)
+			return nil
+		}
+		// order submitted before tracking
+		tracked = false
+	} else if err != nil {
+		return err
+	}
+
+	err = payEvent(notify)
+	if tracked {
+		if err != nil {
+			// give it back to the reconciler
+			if e := order.Transit(ctx, db, model.PayOrderSettling, model.PayOrderSubmit); e != nil {
+				logrus.Errorf("payOrder.Transit method:%s order_id:%s to submit err:%s", notify.Method, notify.OrderId, e)
+			}
+			return err
+		}
+		if e := order.Transit(ctx, db, model.PayOrderSettling, final); e != nil {
+			logrus.Errorf("payOrder.Transit method:%s order_id:%s to %d err:%s", notify.Method, notify.OrderId, final, e)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if e := pubsub.Notify(ctx, payNotifyKey(notify.Method, notify.OrderId), notify.TxStatus); e != nil {
+		logrus.Errorf("pubsub.Notify method:%s order_id:%s err:%s", notify.Method, notify.OrderId, e)
+	}
+	return nil
+}
+
+func payEvent(notify PayCallbackParam) error {
 	switch notify.Method {
-	case "sub_dao":
+	case PayMethodSubDao:
 		return eventSubDAO(notify)
-	case "send_redpacket":
+	case PayMethodSendRedpacket:
 		return eventSendRedpacket(notify)
-	case "claim_redpacket":
+	case PayMethodClaimRedpacket:
 		return eventClaimRedpacket(notify)
-	case "refund_redpacket":
+	case PayMethodRefundRedpacket:
 		return eventRefundRedpacket(notify)
+	case PayMethodRefundSubDao:
+		return eventRefundSubDAO(notify)
 	default:
 		return errors.New("unknown method")
 	}
-
 }
 
 func eventSubDAO(notify PayCallbackParam) error {
-	var subStatus core.DaoSubscribeT
-	switch notify.TxStatus {
-	case TxCompleted:
-		// success
-		subStatus = core.DaoSubscribeSuccess
-	case TxRollback, TxCancelled:
-		// failed
-		subStatus = core.DaoSubscribeFailed
-	default:
+	status, settled := payStatusOf(notify.TxStatus)
+	if !settled {
 		return nil
 	}
-	err := UpdateSubscribeDAO(notify.OrderId, notify.TxID, subStatus)
+	subStatus := core.DaoSubscribeFailed
+	if status == model.PaySuccess {
+		subStatus = core.DaoSubscribeSuccess
+	}
+	return UpdateSubscribeDAO(notify.OrderId, notify.TxID, subStatus)
+}
+
+type PayTradeReq struct {
+	TxID      string `form:"tx_id"`
+	BindOrder string `form:"bind_order"`
+	Channel   string `form:"channel"`
+}
+
+type PayTradeResponse struct {
+	Trade *pointSystem.Trade `json:"trade"`
+	Order *model.PayOrder    `json:"order,omitempty"`
+}
+
+// PayTrade look up the trade in the point system along with our side of the order
+func PayTrade(ctx context.Context, param PayTradeReq) (*PayTradeResponse, error) {
+	var (
+		trade *pointSystem.Trade
+		err   error
+	)
+	if param.TxID != "" {
+		trade, err = point.TradeInfo(ctx, param.TxID)
+	} else {
+		trade, err = point.FindTrade(ctx, param.Channel, param.BindOrder, "")
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
-	pubsub.Notify(notify.OrderId, subStatus)
-	return nil
+	out := &PayTradeResponse{Trade: trade}
+	order := &model.PayOrder{}
+	err = order.FindOne(ctx, conf.MustMongoDB(), bson.M{"method": trade.Channel, "order_id": trade.BindOrder})
+	if err == nil {
+		out.Order = order
+	} else if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+	return out, nil
 }
 
 func FindAccounts(ctx context.Context, address string) (accounts []pointSystem.Account, err error) {
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/pointSystem"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const testPaySecret = "secret"
+
+// fakeNotifier collects the pay notify callbacks of the fake point system as the
+// notify route receives them, the signature checked
+type fakeNotifier struct {
+	t  *testing.T
+	mu sync.Mutex
+	// callbacks by the bind order
+	callbacks map[string][]PayCallbackParam
+	// deliver the callbacks to the service as the notify route does, nil only records them
+	deliver func(PayCallbackParam) error
+}
+
+func (n *fakeNotifier) notify(_ context.Context, uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return err
+	}
+	query := u.Query()
+	if e := checkPayNotifySign(query, testPaySecret, time.Minute, time.Now()); e != nil {
+		n.t.Errorf("pay notify %s: %s", uri, e.Msg())
+		return e
+	}
+	param := PayCallbackParam{
+		OrderId:     query.Get("order_id"),
+		Method:      query.Get("method"),
+		TxID:        query.Get("tx_id"),
+		TxStatus:    query.Get("tx_status"),
+		TxTimestamp: query.Get("tx_timestamp"),
+	}
+	n.mu.Lock()
+	n.callbacks[param.OrderId] = append(n.callbacks[param.OrderId], param)
+	deliver := n.deliver
+	n.mu.Unlock()
+	if deliver != nil {
+		if err = deliver(param); err != nil {
+			n.t.Errorf("pay notify %s: %s", uri, err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (n *fakeNotifier) last(orderID string) (PayCallbackParam, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	list := n.callbacks[orderID]
+	if len(list) == 0 {
+		return PayCallbackParam{}, false
+	}
+	return list[len(list)-1], true
+}
+
+// setupFakePoint wire the fake point system in place of the gateway
+func setupFakePoint(t *testing.T, opts pointSystem.FakeOptions) (*pointSystem.Fake, *fakeNotifier) {
+	n := &fakeNotifier{t: t, callbacks: make(map[string][]PayCallbackParam)}
+	opts.Secret = testPaySecret
+	opts.Notify = n.notify
+	fake := pointSystem.NewFake(opts)
+
+	savedPoint, savedPointSetting, savedApp := point, conf.PointSetting, conf.ExternalAppSetting
+	point = fake
+	// the settings loaded by setupTestEnv are kept
+	pointSetting, app := conf.PointSettingS{}, conf.ExternalAppSettingS{}
+	if savedPointSetting != nil {
+		pointSetting = *savedPointSetting
+	}
+	if savedApp != nil {
+		app = *savedApp
+	}
+	pointSetting.Callback, pointSetting.Secret = "http://localhost/v1", testPaySecret
+	app.RedpacketAddress = "redpacket"
+	conf.PointSetting, conf.ExternalAppSetting = &pointSetting, &app
+	t.Cleanup(func() {
+		point, conf.PointSetting, conf.ExternalAppSetting = savedPoint, savedPointSetting, savedApp
+	})
+	return fake, n
+}
+
+func TestSubscribeDaoPayFailed(t *testing.T) {
+	fake, n := setupFakePoint(t, pointSystem.FakeOptions{
+		Channels: map[string]string{PayMethodSubDao: pointSystem.TxRollback},
+	})
+	if err := fake.Deposit("member", "100"); err != nil {
+		t.Fatal(err)
+	}
+
+	orderID := "64f0459841ef4c88ee2e87f5"
+	pay := pointSystem.PayRequest{
+		FromObject: "member",
+		ToSubject:  "owner",
+		Amount:     "60",
+		Channel:    PayMethodSubDao,
+		ReturnURI:  payReturnURI(PayMethodSubDao, orderID),
+		BindOrder:  orderID,
+	}
+	txID, err := point.Pay(context.Background(), pay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.Wait()
+
+	notify, ok := n.last(orderID)
+	if !ok {
+		t.Fatal("no pay notify")
+	}
+	if notify.Method != PayMethodSubDao || notify.TxID != txID {
+		t.Errorf("notify %+v of tx %s", notify, txID)
+	}
+	if status, settled := payStatusOf(notify.TxStatus); !settled || status != model.PayFailed {
+		t.Errorf("tx_status %s settled %v as %d, want failed", notify.TxStatus, settled, status)
+	}
+	if got := fake.Balance("member"); got != "100" {
+		t.Errorf("member balance %s, want the failed pay back 100", got)
+	}
+	if got := fake.Balance("owner"); got != "0" {
+		t.Errorf("owner balance %s, want 0", got)
+	}
+
+	// the subscription retried with the same order is not charged twice
+	fake.SetStatus(PayMethodSubDao, pointSystem.TxCompleted)
+	again, err := point.Pay(context.Background(), pay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != txID {
+		t.Errorf("retried pay tx %s, want %s", again, txID)
+	}
+}
+
+func TestPayStatusOf(t *testing.T) {
+	tests := []struct {
+		txStatus string
+		status   model.PayStatus
+		settled  bool
+	}{
+		{TxCompleted, model.PaySuccess, true},
+		{TxRollback, model.PayFailed, true},
+		{TxCancelled, model.PayFailed, true},
+		{TxInProgress, model.PaySubmit, false},
+		{TxPending, model.PaySubmit, false},
+	}
+	for _, tt := range tests {
+		status, settled := payStatusOf(tt.txStatus)
+		if status != tt.status || settled != tt.settled {
+			t.Errorf("%s: got %d %v, want %d %v", tt.txStatus, status, settled, tt.status, tt.settled)
+		}
+	}
+}
+
+func TestSubDao(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		status string
+		// the balances after the pay settles
+		member, owner string
+		want          model.DaoSubscribeT
+		order         model.PayOrderStatus
+		tier          model.PostMemberT
+	}{
+		{"completed", pointSystem.TxCompleted, "40", "60", model.DaoSubscribeSuccess, model.PayOrderSuccess, model.PostMember3},
+		{"rollback", pointSystem.TxRollback, "100", "0", model.DaoSubscribeFailed, model.PayOrderFailed, model.PostMemberNothing},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, _ := setupTestEnv(t, pointSystem.FakeOptions{
+				Channels: map[string]string{PayMethodSubDao: tt.status},
+			})
+			ctx := context.Background()
+			owner, member := createTestUser(t, "owner"), createTestUser(t, "member")
+			d := createTestDao(t, owner, model.DaoVisitPublic, "60")
+			if err := fake.Deposit(member.Address, "100"); err != nil {
+				t.Fatal(err)
+			}
+
+			txID, status, err := SubDao(ctx, d.ID, member.Address, model.PostMember3, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fake.Wait()
+			if status != tt.want {
+				t.Errorf("subscribe status %d, want %d", status, tt.want)
+			}
+
+			sub := &model.DaoSubscribe{}
+			if err = sub.FindOne(ctx, conf.MustMongoDB(), bson.M{"address": member.Address, "dao_id": d.ID}); err != nil {
+				t.Fatal(err)
+			}
+			if sub.Status != tt.want || sub.TxID != txID {
+				t.Errorf("subscription %d tx %s, want %d tx %s", sub.Status, sub.TxID, tt.want, txID)
+			}
+			order := &model.PayOrder{Method: PayMethodSubDao, OrderID: sub.ID.Hex()}
+			if err = order.First(ctx, conf.MustMongoDB()); err != nil {
+				t.Fatal(err)
+			}
+			if order.Status != tt.order || order.TxID != txID {
+				t.Errorf("pay order %d tx %s, want %d tx %s", order.Status, order.TxID, tt.order, txID)
+			}
+			// no subscription is recorded when the payment fails
+			if got := GetSubscribeTier(member.Address, d.ID); got != tt.tier {
+				t.Errorf("subscribed tier %d, want %d", got, tt.tier)
+			}
+			if got := fake.Balance(member.Address); got != tt.member {
+				t.Errorf("member balance %s, want %s", got, tt.member)
+			}
+			if got := fake.Balance(owner.Address); got != tt.owner {
+				t.Errorf("owner balance %s, want %s", got, tt.owner)
+			}
+		})
+	}
+}
@@ -48,6 +48,10 @@ type PostCreationReq struct {
 	RefId      primitive.ObjectID `json:"ref_id"`
 	Visibility model.PostVisibleT `json:"visibility"`
 	Member     model.PostMemberT  `json:"member"`
+	// ScheduledOn the unix time to publish the draft at, 0 keeps it until it is published by hand
+	ScheduledOn int64 `json:"scheduled_on"`
+	// Gate the post is only for the holders of the token, a repost is gated as its original
+	Gate *model.TokenGate `json:"gate"`
 }
 
 type PostDelReq struct {
@@ -60,6 +64,8 @@ type PostLockReq struct {
 
 type PostStickReq struct {
 	ID string `json:"id" binding:"required"`
+	// Duration of the pin in seconds, 0 until it is unpinned
+	Duration int64 `json:"duration" binding:"min=0"`
 }
 
 type PostVisibilityReq struct {
@@ -79,6 +85,8 @@ type PostContentItem struct {
 	Content string             `json:"content"  binding:"required"`
 	Type    model.PostContentT `json:"type"  binding:"required"`
 	Sort    int64              `json:"sort"  binding:"required"`
+	// Poll of the CONTENT_TYPE_POLL item, the content is its question
+	Poll *PollReq `json:"poll"`
 }
 
 func (p *PostContentItem) Check() error {
@@ -87,10 +95,21 @@ func (p *PostContentItem) Check() error {
 			return fmt.Errorf("链接不合法")
 		}
 	}
+	if p.Type == model.CONTENT_TYPE_POLL {
+		return p.Poll.Check()
+	}
 
 	return nil
 }
 
+// poll the poll of the item, nil for the other types
+func (p *PostContentItem) poll() *model.Poll {
+	if p.Type != model.CONTENT_TYPE_POLL || p.Poll == nil {
+		return nil
+	}
+	return p.Poll.Poll()
+}
+
 func tagsFrom(originTags []string) []string {
 	tags := make([]string, 0, len(originTags))
 	for _, tag := range originTags {
@@ -113,6 +132,19 @@ func CreatePost(user *model.User, param PostCreationReq) (_ *model.PostFormatted
 		}
 	}()
 
+	// only the original posts are drafted, a repost follows the visibility of the original
+	draft := param.RefId.IsZero() && (param.Visibility == model.PostVisitDraft || param.ScheduledOn > 0)
+	if draft && param.ScheduledOn > 0 && param.ScheduledOn <= time.Now().Unix() {
+		return nil, ErrScheduleTime
+	}
+	if param.Gate != nil && param.RefId.IsZero() {
+		if e := checkTokenGate(param.Gate); e != nil {
+			return nil, e
+		}
+	} else {
+		param.Gate = nil
+	}
+
 	if mediaContents, err = persistMediaContents(param.Contents); err != nil {
 		return
 	}
@@ -129,6 +161,7 @@ func CreatePost(user *model.User, param PostCreationReq) (_ *model.PostFormatted
 			Content: item.Content,
 			Type:    item.Type,
 			Sort:    item.Sort,
+			Poll:    item.poll(),
 		})
 	}
 
@@ -137,23 +170,44 @@ func CreatePost(user *model.User, param PostCreationReq) (_ *model.PostFormatted
 		param.Type = 0
 	}
 
-	// check address has alwaysTop feature
-	alwaysTop := 0
-	for _, addr := range conf.ExternalAppSetting.AlwaysTopAddresses {
-		if user.Address == addr {
-			alwaysTop = 1
+	if draft {
+		post, err = ds.CreatePost(&model.Post{
+			Address:     user.Address,
+			DaoId:       param.DaoId,
+			Tags:        strings.Join(tagsFrom(param.Tags), ","),
+			Visibility:  model.PostVisitDraft,
+			Type:        param.Type,
+			OrigType:    param.Type,
+			Member:      param.Member,
+			ScheduledOn: param.ScheduledOn,
+			Gate:        param.Gate,
+			// the visibility asked for, public for a plain draft
+			PublishVisibility: param.Visibility,
+		}, contents)
+		if err != nil {
+			return nil, err
+		}
+		// the polls start closing when the draft is published
+		if post.ScheduledOn > 0 {
+			if err = schedulePostPublish(post); err != nil {
+				return nil, err
+			}
+		}
+		formattedPosts, err := ds.RevampPosts(user.Address, []*model.PostFormatted{post.Format()})
+		if err != nil {
+			return nil, err
 		}
+		return formattedPosts[0], nil
 	}
 
+	// check address has alwaysTop feature
+	alwaysTop, topExpiredOn := alwaysTopOf(user.Address)
+
 	// put unpin job
 	if alwaysTop == 1 {
 		defer func() {
 			if err == nil {
-				task := NewPostUnpinTask(post.ID)
-				_, taskErr := queue.Enqueue(task, asynq.ProcessIn(time.Hour*24), asynq.Queue(PostQueue))
-				if taskErr != nil {
-					logrus.Errorf("unpin post %s enqueue failed: %v", post.ID, taskErr)
-				}
+				enqueuePostUnpin(post, model.PinPlatform, topExpiredOn)
 			}
 		}()
 	}
@@ -161,14 +215,15 @@ func CreatePost(user *model.User, param PostCreationReq) (_ *model.PostFormatted
 	if !param.RefId.IsZero() {
 		// create post ref
 		post, err = ds.CreatePost(&model.Post{
-			Address:    user.Address,
-			DaoId:      param.DaoId,
-			Visibility: param.Visibility,
-			Type:       param.Type,
-			RefId:      param.RefId,
-			RefType:    param.RefType,
-			Member:     param.Member,
-			IsTop:      alwaysTop,
+			Address:      user.Address,
+			DaoId:        param.DaoId,
+			Visibility:   param.Visibility,
+			Type:         param.Type,
+			RefId:        param.RefId,
+			RefType:      param.RefType,
+			Member:       param.Member,
+			IsTop:        alwaysTop,
+			TopExpiredOn: topExpiredOn,
 		}, contents)
 		if err != nil {
 			return nil, err
@@ -176,14 +231,16 @@ func CreatePost(user *model.User, param PostCreationReq) (_ *model.PostFormatted
 	} else {
 		tags := tagsFrom(param.Tags)
 		post, err = ds.CreatePost(&model.Post{
-			Address:    user.Address,
-			DaoId:      param.DaoId,
-			Tags:       strings.Join(tags, ","),
-			Visibility: param.Visibility,
-			Type:       param.Type,
-			OrigType:   param.Type,
-			Member:     param.Member,
-			IsTop:      alwaysTop,
+			Address:      user.Address,
+			DaoId:        param.DaoId,
+			Tags:         strings.Join(tags, ","),
+			Visibility:   param.Visibility,
+			Type:         param.Type,
+			OrigType:     param.Type,
+			Member:       param.Member,
+			IsTop:        alwaysTop,
+			TopExpiredOn: topExpiredOn,
+			Gate:         param.Gate,
 		}, contents)
 		if err != nil {
 			return nil, err
@@ -201,20 +258,53 @@ func CreatePost(user *model.User, param PostCreationReq) (_ *model.PostFormatted
 		}
 	}
 
+	schedulePollClose(contents)
 	PushPostToSearch(post)
 
 	formattedPosts, err := ds.RevampPosts(user.Address, []*model.PostFormatted{post.Format()})
 	if err != nil {
 		return nil, err
 	}
+	notifyPostCreated(post)
+	return formattedPosts[0], nil
+}
+
+// alwaysTopOf the new posts of the AlwaysTopAddresses are pinned on the platform for a while
+func alwaysTopOf(address string) (int, int64) {
+	for _, addr := range conf.ExternalAppSetting.AlwaysTopAddresses {
+		if address == addr {
+			return 1, time.Now().Add(conf.ExternalAppSetting.AlwaysTopDuration).Unix()
+		}
+	}
+	return 0, 0
+}
+
+func enqueuePostUnpin(post *model.Post, scope model.PinScope, expiredOn int64) {
+	task := NewPostUnpinTask(post.ID, scope, expiredOn)
+	_, taskErr := queue.Enqueue(task, asynq.ProcessAt(time.Unix(expiredOn, 0)), asynq.Queue(PostQueue))
+	if taskErr != nil {
+		logrus.Errorf("unpin post %s enqueue failed: %v", post.ID, taskErr)
+	}
+}
+
+// notifyPostCreated tell the subscribers of the DAO about the new post
+func notifyPostCreated(post *model.Post) {
+	if IsShadowBanned(post.Address) {
+		// nobody else sees the post, the followers are not told about it
+		return
+	}
 	linkMap := make(map[string]any)
 	if post.Type == model.SMS {
 		linkMap["route"] = "PostDetail"
 	} else {
 		linkMap["route"] = "VideoPlay"
 	}
-	dao := &model.Dao{ID: param.DaoId}
-	d, _ := ds.GetDao(dao)
+	dao := &model.Dao{ID: post.DaoId}
+	d, err := ds.GetDao(dao)
+	if err != nil {
+		logrus.Errorf("ds.GetDao dao_id:%s err:%s", post.DaoId.Hex(), err)
+		return
+	}
 
 	content := fmt.Sprintf("The %s Dao you subscribe to has posted new content", d.Name)
 	linkMap["id"] = post.ID
@@ -226,12 +316,11 @@ func CreatePost(user *model.User, param PostCreationReq) (_ *model.PostFormatted
 		Title:     "",
 		Content:   content,
 		Links:     string(links),
-		From:      param.DaoId.Hex(),
+		From:      post.DaoId.Hex(),
 		FromType:  model.DAO_TYPE,
-		To:        param.DaoId.Hex(),
+		To:        post.DaoId.Hex(),
 	}
-	err = notifyGateway.NotifyDao(context.TODO(), nr)
-	return formattedPosts[0], nil
+	notifyGateway.NotifyDao(context.TODO(), nr)
 }
 
 func DeletePost(user *model.User, id primitive.ObjectID) *errcode.Error {
@@ -269,16 +358,21 @@ func deleteOssObjects(mediaContents []string) {
 	}
 }
 
-func StickPost(id primitive.ObjectID) error {
-	post, _ := ds.GetPostByID(id)
-
-	err := ds.StickPost(post)
-
+// StickPost pin the post on the page of its DAO, or unpin it when it is pinned already
+func StickPost(user *model.User, id primitive.ObjectID, duration int64) (int, *errcode.Error) {
+	post, err := ds.GetPostByID(id)
 	if err != nil {
-		return err
+		return 0, errcode.GetPostFailed
 	}
-
-	return nil
+	e := CheckDaoAllow(user, &core.Action{Act: core.ActStickTweet, DaoID: post.DaoId})
+	if e != nil {
+		return 0, e
+	}
+	pinned, _ := post.Pinned(model.PinDao)
+	if e = PinPost(post, model.PinDao, !pinned, duration); e != nil {
+		return 0, e
+	}
+	return post.DaoTop, nil
 }
 
 func VisiblePost(user *model.User, postId primitive.ObjectID, visibility model.PostVisibleT) *errcode.Error {
@@ -286,10 +380,22 @@ func VisiblePost(user *model.User, postId primitive.ObjectID, visibility model.P
 	if err != nil {
 		return errcode.GetPostFailed
 	}
-	e := CheckIsMyDAO(user.Address, post.DaoId)
+	e := CheckDaoAllow(user, &core.Action{Act: core.ActVisibleTweet, DaoID: post.DaoId})
 	if e != nil {
 		return e
 	}
+	if visibility == model.PostVisitDraft {
+		return errcode.InvalidParams.WithDetails("the draft is published by /post/publish")
+	}
+	if post.Visibility == model.PostVisitDraft {
+		// the draft stays one, it goes out with the visibility when it is published
+		post.PublishVisibility = visibility
+		if err = ds.UpdatePost(post); err != nil {
+			logrus.Warnf("update post failure: %v", err)
+			return errcode.VisiblePostFailed
+		}
+		return nil
+	}
 	if err = ds.VisiblePost(post, visibility); err != nil {
 		logrus.Warnf("update post failure: %v", err)
 		return errcode.VisiblePostFailed
@@ -461,6 +567,10 @@ func GetPost(user string, id primitive.ObjectID) (*model.PostFormatted, error) {
 			postFormatted.OrigContents = append(postFormatted.OrigContents, refReplies.PostFormat())
 		}
 	}
+	polls := append(append([]*model.PostContentFormatted{}, postFormatted.Contents...), postFormatted.OrigContents...)
+	if err = model.FillPollVoted(context.TODO(), conf.MustMongoDB(), user, polls); err != nil {
+		return nil, err
+	}
 
 	users, err := ds.GetUsersByAddresses([]string{post.Address, post.AuthorId})
 	if err != nil {
@@ -488,9 +598,11 @@ func GetPost(user string, id primitive.ObjectID) (*model.PostFormatted, error) {
 	if postFormatted.Dao.Address == user {
 		postFormatted.Dao.IsJoined = true
 		postFormatted.Dao.IsSubscribed = true
+		postFormatted.Dao.SubscribedTier = model.PostMember3
 	} else {
 		postFormatted.Dao.IsJoined = CheckJoinedDAO(user, post.DaoId)
-		postFormatted.Dao.IsSubscribed = CheckSubscribeDAO(user, post.DaoId)
+		postFormatted.Dao.SubscribedTier = GetSubscribeTier(user, post.DaoId)
+		postFormatted.Dao.IsSubscribed = postFormatted.Dao.SubscribedTier != model.PostMemberNothing
 	}
 
 	if postFormatted.AuthorId != "" {
@@ -502,9 +614,11 @@ func GetPost(user string, id primitive.ObjectID) (*model.PostFormatted, error) {
 		if postFormatted.AuthorDao.Address == user {
 			postFormatted.AuthorDao.IsJoined = true
 			postFormatted.AuthorDao.IsSubscribed = true
+			postFormatted.AuthorDao.SubscribedTier = model.PostMember3
 		} else {
 			postFormatted.AuthorDao.IsJoined = CheckJoinedDAO(user, post.AuthorDaoId)
-			postFormatted.AuthorDao.IsSubscribed = CheckSubscribeDAO(user, post.AuthorDaoId)
+			postFormatted.AuthorDao.SubscribedTier = GetSubscribeTier(user, post.AuthorDaoId)
+			postFormatted.AuthorDao.IsSubscribed = postFormatted.AuthorDao.SubscribedTier != model.PostMemberNothing
 		}
 	}
 
@@ -516,17 +630,48 @@ func GetPostContentByID(id primitive.ObjectID) ([]*model.PostContent, error) {
 }
 
 func GetIndexPosts(user *model.User, offset int, limit int) (*rest.IndexTweetsResp, error) {
-	return ds.IndexPosts(user, offset, limit)
+	resp, err := ds.IndexPosts(user, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	var viewer string
+	if user != nil {
+		viewer = user.Address
+	}
+	filterTokenGated(viewer, resp.Tweets...)
+	return resp, nil
 }
 
+// GetPostList the posts of the conditions as the user sees them, the drafts and the shadow-banned
+// authors cut
 func GetPostList(user string, req *PostListReq) ([]*model.PostFormatted, error) {
+	andPostQuery(req.Conditions, bson.M{"visibility": bson.M{"$ne": model.PostVisitDraft}})
+	if shadowed := GetShadowBannedAddresses(user); len(shadowed) > 0 {
+		andPostQuery(req.Conditions, bson.M{"address": bson.M{"$nin": shadowed}})
+	}
 	posts, err := ds.GetPosts(req.Conditions, req.Offset, req.Limit)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return ds.MergePosts(user, posts)
+	formatted, err := ds.MergePosts(user, posts)
+	if err != nil {
+		return nil, err
+	}
+	filterTokenGated(user, formatted...)
+	return formatted, nil
+}
+
+// andPostQuery add the filter to the query of the conditions
+func andPostQuery(conditions *model.ConditionsT, filter bson.M) {
+	query := (*conditions)["query"]
+	if query == nil {
+		query = bson.M{}
+		(*conditions)["query"] = query
+	}
+	and, _ := query["$and"].(bson.A)
+	query["$and"] = append(and, filter)
 }
 
 func GetPostCount(conditions *model.ConditionsT) (int64, error) {
@@ -542,17 +687,20 @@ func GetPostListFromSearch(user *model.User, q *core.QueryReq, offset, limit int
 			q.Visibility = []core.PostVisibleT{core.PostVisitPublic}
 		}
 	}
+	if user == nil {
+		user = &model.User{}
+	}
+	q.Viewer = user.Address
+	q.ShadowAddresses = GetShadowBannedAddresses(user.Address)
 	resp, err := ts.Search(q, offset, limit)
 	if err != nil {
 		return nil, 0, err
 	}
-	if user == nil {
-		user = &model.User{}
-	}
 	posts, err := ds.RevampPosts(user.Address, resp.Items)
 	if err != nil {
 		return nil, 0, err
 	}
+	filterTokenGated(user.Address, posts...)
 	return posts, resp.Total, nil
 }
 
@@ -592,6 +740,7 @@ func PushPostToSearch(post *model.Post) {
 		"member":            post.Member,
 		"visibility":        post.Visibility,
 		"is_top":            post.IsTop,
+		"dao_top":           post.DaoTop,
 		"is_essence":        post.IsEssence,
 		"content":           contentFormatted,
 		"tags":              tagMaps,
@@ -605,6 +754,8 @@ func PushPostToSearch(post *model.Post) {
 		"ref_type":          post.RefType,
 		"created_on":        post.CreatedOn,
 		"modified_on":       post.ModifiedOn,
+		"edited":            post.IsEdited(),
+		"gate":              post.Gate,
 		"latest_replied_on": post.LatestRepliedOn,
 	}}
 
@@ -631,13 +782,22 @@ func PushPostsToSearch() {
 	nums := int(pages)
 
 	for i := 0; i < nums; i++ {
-		posts, _ := GetPostList("", &PostListReq{
-			Conditions: &model.ConditionsT{},
-			Offset:     i * splitNum,
-			Limit:      splitNum,
-		})
+		// all the posts as they are, not as a guest sees them
+		list, err := ds.GetPosts(&model.ConditionsT{}, i*splitNum, splitNum)
+		if err != nil {
+			logrus.Errorf("ds.GetPosts offset:%d err:%s", i*splitNum, err)
+			continue
+		}
+		posts, err := ds.MergePosts("", list)
+		if err != nil {
+			logrus.Errorf("ds.MergePosts offset:%d err:%s", i*splitNum, err)
+			continue
+		}
 
 		for _, post := range posts {
+			if post.Visibility == model.PostVisitDraft {
+				continue
+			}
 			contentFormatted := ""
 
 			for _, content := range post.Contents {
@@ -659,6 +819,7 @@ func PushPostsToSearch() {
 				"member":            post.Member,
 				"visibility":        post.Visibility,
 				"is_top":            post.IsTop,
+				"dao_top":           post.DaoTop,
 				"is_essence":        post.IsEssence,
 				"content":           contentFormatted,
 				"tags":              post.Tags,
@@ -672,6 +833,8 @@ func PushPostsToSearch() {
 				"ref_type":          post.RefType,
 				"created_on":        post.CreatedOn,
 				"modified_on":       post.ModifiedOn,
+				"edited":            post.Edited,
+				"gate":              post.Gate,
 				"latest_replied_on": post.LatestRepliedOn,
 			}}
 			_, err := ts.AddDocuments(docs, post.ID.Hex())
@@ -730,11 +893,16 @@ func GetPostTags(param *PostTagsReq) ([]*model.TagFormatted, error) {
 
 func FilterMemberContent(user *model.User, post *model.PostFormatted) *model.PostFormatted {
 	// Warning, Other related places tweetHelpServant.filterMemberContent
+	var viewer string
+	if user != nil {
+		viewer = user.Address
+	}
+	filterTokenGated(viewer, post)
 	if post.Member == model.PostMemberNothing {
 		return post
 	}
 	if post.Type == model.VIDEO {
-		if user == nil || (user.Address != post.Address && !CheckSubscribeDAO(user.Address, post.DaoId)) {
+		if user == nil || (user.Address != post.Address && !post.Member.Allow(GetSubscribeTier(user.Address, post.DaoId))) {
 			for k, v := range post.Contents {
 				if v.Type == model.CONTENT_TYPE_VIDEO {
 					post.Contents[k].Content = ""
@@ -742,7 +910,11 @@ func FilterMemberContent(user *model.User, post *model.PostFormatted) *model.Pos
 			}
 		}
 	} else if post.OrigType == model.VIDEO {
-		if user == nil || (user.Address != post.AuthorId && !CheckSubscribeDAO(user.Address, post.AuthorDaoId)) {
+		member := post.OrigMember
+		if member == model.PostMemberNothing {
+			member = post.Member
+		}
+		if user == nil || (user.Address != post.AuthorId && !member.Allow(GetSubscribeTier(user.Address, post.AuthorDaoId))) {
 			for k, v := range post.OrigContents {
 				if v.Type == model.CONTENT_TYPE_VIDEO {
 					post.OrigContents[k].Content = ""
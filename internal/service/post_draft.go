@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"favor-dao-backend/internal/core"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/errcode"
+	"github.com/hibiken/asynq"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const TypePostPublish = "post:publish"
+
+var ErrScheduleTime = errors.New("the scheduled time is passed")
+
+type PostPublishReq struct {
+	ID string `json:"id" binding:"required"`
+	// ScheduledOn the unix time to publish at, 0 publishes right now
+	ScheduledOn int64 `json:"scheduled_on" binding:"min=0"`
+}
+
+type PostPublishPayload struct {
+	Id          primitive.ObjectID
+	ScheduledOn int64
+}
+
+func NewPostPublishTask(post *model.Post) *asynq.Task {
+	payload, _ := json.Marshal(PostPublishPayload{Id: post.ID, ScheduledOn: post.ScheduledOn})
+	return asynq.NewTask(TypePostPublish, payload)
+}
+
+// HandlePostPublishTask publish the draft unless it was published, rescheduled or deleted meanwhile
+func HandlePostPublishTask(ctx context.Context, t *asynq.Task) (err error) {
+	var p PostPublishPayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v", err)
+	}
+
+	defer func() {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			logrus.Warnf("post %s not found to publish", p.Id)
+			err = nil
+		}
+	}()
+
+	post, err := ds.GetPostByID(p.Id)
+	if err != nil {
+		return err
+	}
+	if post.Visibility != model.PostVisitDraft || post.ScheduledOn != p.ScheduledOn {
+		return nil
+	}
+	err = publishPost(post)
+	var e *errcode.Error
+	if errors.As(err, &e) {
+		// the DAO or the author lost the right to post since it was scheduled, it stays a draft
+		logrus.Warnf("post %s not published: %s", p.Id.Hex(), e.Msg())
+		return nil
+	}
+	return err
+}
+
+func schedulePostPublish(post *model.Post) error {
+	_, err := queue.Enqueue(NewPostPublishTask(post), asynq.ProcessAt(time.Unix(post.ScheduledOn, 0)), asynq.Queue(PostQueue))
+	if err != nil {
+		logrus.Errorf("publish post %s enqueue failed: %v", post.ID, err)
+	}
+	return err
+}
+
+// checkPublishable the author may still post in the DAO, is not banned from it nor suspended, checked
+// again when the draft goes out
+func checkPublishable(post *model.Post) *errcode.Error {
+	author, err := ds.GetUserByAddress(post.Address)
+	if err != nil {
+		return errcode.NoExistUserAddress
+	}
+	if e := CheckDaoAllow(author, &core.Action{Act: core.ActCreatePublicTweet, DaoID: post.DaoId}); e != nil {
+		return e
+	}
+	if e := CheckDaoBanned(post.DaoId, post.Address); e != nil {
+		return e
+	}
+	return GetUserSanctions(post.Address).CheckSuspended()
+}
+
+// publishPost the draft goes out the way a new post does: the tags, the search and the DAO notification
+func publishPost(post *model.Post) error {
+	if e := checkPublishable(post); e != nil {
+		return e
+	}
+	if top, expiredOn := alwaysTopOf(post.Address); top == 1 {
+		post.SetPin(model.PinPlatform, true, expiredOn)
+	}
+	if err := ds.PublishPost(post); err != nil {
+		return err
+	}
+	if post.IsTop == 1 && post.TopExpiredOn > 0 {
+		enqueuePostUnpin(post, model.PinPlatform, post.TopExpiredOn)
+	}
+
+	// the polls of a draft start closing once it is out
+	contents, err := ds.GetPostContentByID(post.ID)
+	if err != nil {
+		logrus.Errorf("ds.GetPostContentByID post_id:%s err:%s", post.ID.Hex(), err)
+	} else {
+		schedulePollClose(contents)
+	}
+	PushPostToSearch(post)
+	notifyPostCreated(post)
+	return nil
+}
+
+// ListDrafts the drafts of the user, the scheduled ones included, the latest first
+func ListDrafts(user *model.User, offset, limit int) ([]*model.PostFormatted, int64, error) {
+	conditions := &model.ConditionsT{
+		"query": bson.M{"address": user.Address, "visibility": model.PostVisitDraft},
+		"ORDER": bson.M{"created_on": -1},
+	}
+	posts, err := ds.GetPosts(conditions, offset, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := ds.GetPostCount(conditions)
+	if err != nil {
+		return nil, 0, err
+	}
+	list, err := ds.MergePosts(user.Address, posts)
+	if err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+// PublishDraft publish the draft now, or schedule it for the later time
+func PublishDraft(user *model.User, id primitive.ObjectID, scheduledOn int64) (*model.PostFormatted, *errcode.Error) {
+	post, err := ds.GetPostByID(id)
+	if err != nil {
+		return nil, errcode.GetPostFailed
+	}
+	if post.Address != user.Address {
+		return nil, errcode.NoPermission
+	}
+	if post.Visibility != model.PostVisitDraft {
+		return nil, errcode.PostNotDraft
+	}
+	if e := checkPublishable(post); e != nil {
+		return nil, e
+	}
+
+	if scheduledOn > 0 {
+		if scheduledOn <= time.Now().Unix() {
+			return nil, errcode.InvalidParams.WithDetails(ErrScheduleTime.Error())
+		}
+		// the task of the former time finds the time changed and does nothing
+		post.ScheduledOn = scheduledOn
+		if err = ds.UpdatePost(post); err != nil {
+			logrus.Errorf("ds.UpdatePost post_id:%s err:%s", id.Hex(), err)
+			return nil, errcode.PublishPostFailed
+		}
+		if err = schedulePostPublish(post); err != nil {
+			return nil, errcode.PublishPostFailed
+		}
+	} else if err = publishPost(post); err != nil {
+		if e, ok := err.(*errcode.Error); ok {
+			return nil, e
+		}
+		logrus.Errorf("service.publishPost post_id:%s err:%s", id.Hex(), err)
+		return nil, errcode.PublishPostFailed
+	}
+
+	formatted, err := ds.RevampPosts(user.Address, []*model.PostFormatted{post.Format()})
+	if err != nil || len(formatted) == 0 {
+		logrus.Errorf("ds.RevampPosts post_id:%s err:%v", id.Hex(), err)
+		return post.Format(), nil
+	}
+	return formatted[0], nil
+}
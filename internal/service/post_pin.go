@@ -0,0 +1,63 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/errcode"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PostPinReq struct {
+	// Duration of the pin in seconds, 0 until it is unpinned
+	Duration int64 `json:"duration" binding:"min=0"`
+}
+
+// PinPost pin the post in the scope for the duration in seconds, or unpin it. A DAO pins
+// up to DaoPinLimit posts on its page at once.
+func PinPost(post *model.Post, scope model.PinScope, pinned bool, duration int64) *errcode.Error {
+	if post.Visibility == model.PostVisitDraft {
+		return errcode.PostNotEditable.WithDetails("the draft can not be pinned")
+	}
+	var expiredOn int64
+	if pinned {
+		if already, _ := post.Pinned(scope); !already && scope == model.PinDao {
+			count, err := ds.CountDaoPins(post.DaoId)
+			if err != nil {
+				logrus.Errorf("ds.CountDaoPins dao_id:%s err:%s", post.DaoId.Hex(), err)
+				return errcode.ServerError
+			}
+			if count >= int64(conf.ExternalAppSetting.DaoPinLimit) {
+				return errcode.DaoPinLimit.WithDetails(fmt.Sprintf("at most %d", conf.ExternalAppSetting.DaoPinLimit))
+			}
+		}
+		if duration > 0 {
+			expiredOn = time.Now().Unix() + duration
+		}
+	}
+	if err := ds.PinPost(post, scope, pinned, expiredOn); err != nil {
+		logrus.Errorf("ds.PinPost post_id:%s err:%s", post.ID.Hex(), err)
+		return errcode.LockPostFailed
+	}
+	if expiredOn > 0 {
+		// a pin replaced meanwhile keeps its own expiry, the task finds it changed
+		enqueuePostUnpin(post, scope, expiredOn)
+	}
+	PushPostToSearch(post)
+	return nil
+}
+
+// PinPlatformPost pin the post on the home page of the platform, for the admins
+func PinPlatformPost(id primitive.ObjectID, pinned bool, duration int64) (*model.Post, *errcode.Error) {
+	post, err := ds.GetPostByID(id)
+	if err != nil {
+		return nil, errcode.GetPostFailed
+	}
+	if e := PinPost(post, model.PinPlatform, pinned, duration); e != nil {
+		return nil, e
+	}
+	return post, nil
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/errcode"
+	"github.com/hibiken/asynq"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	TypePollClose = "poll:close"
+
+	pollMaxOptions    = 10
+	pollMaxOptionSize = 100
+)
+
+type PollReq struct {
+	Options  []string `json:"options"`
+	Multiple bool     `json:"multiple"`
+	// MembersOnly only the subscribers of the DAO vote
+	MembersOnly bool `json:"members_only"`
+	// CloseOn the unix time the poll closes at
+	CloseOn int64 `json:"close_on"`
+}
+
+func (p *PollReq) Check() error {
+	if p == nil {
+		return errors.New("the poll is missing")
+	}
+	if len(p.Options) < 2 || len(p.Options) > pollMaxOptions {
+		return fmt.Errorf("a poll has 2 to %d options", pollMaxOptions)
+	}
+	for _, option := range p.Options {
+		if option = strings.TrimSpace(option); option == "" || len([]rune(option)) > pollMaxOptionSize {
+			return fmt.Errorf("a poll option has 1 to %d characters", pollMaxOptionSize)
+		}
+	}
+	if p.CloseOn <= time.Now().Unix() {
+		return errors.New("the poll closes in the future")
+	}
+	return nil
+}
+
+func (p *PollReq) Poll() *model.Poll {
+	options := make([]*model.PollOption, len(p.Options))
+	for i, option := range p.Options {
+		options[i] = &model.PollOption{Text: strings.TrimSpace(option)}
+	}
+	return &model.Poll{
+		Options:     options,
+		Multiple:    p.Multiple,
+		MembersOnly: p.MembersOnly,
+		CloseOn:     p.CloseOn,
+	}
+}
+
+type PollVoteReq struct {
+	ContentID string `json:"content_id" binding:"required"`
+	Options   []int  `json:"options"    binding:"required"`
+}
+
+type PollClosePayload struct {
+	ContentID primitive.ObjectID
+	CloseOn   int64
+}
+
+func NewPollCloseTask(contentID primitive.ObjectID, closeOn int64) *asynq.Task {
+	payload, _ := json.Marshal(PollClosePayload{ContentID: contentID, CloseOn: closeOn})
+	return asynq.NewTask(TypePollClose, payload)
+}
+
+// HandlePollCloseTask close the poll unless it is gone or its close time changed
+func HandlePollCloseTask(ctx context.Context, t *asynq.Task) error {
+	var p PollClosePayload
+	if err := json.Unmarshal(t.Payload(), &p); err != nil {
+		return fmt.Errorf("json.Unmarshal failed: %v", err)
+	}
+	db := conf.MustMongoDB()
+	content, err := (&model.PostContent{ID: p.ContentID}).Get(db)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			logrus.Warnf("poll %s not found to close", p.ContentID)
+			return nil
+		}
+		return err
+	}
+	if content.Poll == nil || content.Poll.Closed || content.Poll.CloseOn != p.CloseOn {
+		return nil
+	}
+	err = content.ClosePoll(ctx, db)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	return err
+}
+
+func schedulePollClose(contents []*model.PostContent) {
+	for _, content := range contents {
+		if content.Poll == nil || content.Poll.CloseOn <= 0 {
+			continue
+		}
+		task := NewPollCloseTask(content.ID, content.Poll.CloseOn)
+		_, err := queue.Enqueue(task, asynq.ProcessAt(time.Unix(content.Poll.CloseOn, 0)), asynq.Queue(PostQueue))
+		if err != nil {
+			logrus.Errorf("close poll %s enqueue failed: %v", content.ID, err)
+		}
+	}
+}
+
+// VotePoll the vote of the user on the poll, one vote per wallet
+func VotePoll(user *model.User, contentID primitive.ObjectID, choices []int) (*model.Poll, *errcode.Error) {
+	db := conf.MustMongoDB()
+	content, err := (&model.PostContent{ID: contentID}).Get(db)
+	if err != nil || content.Poll == nil {
+		return nil, errcode.NoExistPoll
+	}
+	poll := content.Poll
+	if poll.IsClosed() {
+		return nil, errcode.PollClosed
+	}
+	if e := checkPollChoices(poll, choices); e != nil {
+		return nil, e
+	}
+
+	post, err := ds.GetPostByID(content.PostID)
+	if err != nil {
+		return nil, errcode.NoExistPoll
+	}
+	if post.Address != user.Address {
+		if post.Visibility != model.PostVisitPublic {
+			return nil, errcode.NoExistPoll
+		}
+		if poll.MembersOnly && !CheckSubscribeDAO(user.Address, post.DaoId) {
+			if dao, err := ds.GetDao(&model.Dao{ID: post.DaoId}); err != nil || dao.Address != user.Address {
+				return nil, errcode.PollMembersOnly
+			}
+		}
+		if !postUnlocked(user.Address, post) {
+			return nil, errcode.NoPermission
+		}
+	}
+
+	vote := &model.PollVote{
+		ContentID: content.ID,
+		PostID:    content.PostID,
+		Address:   user.Address,
+		Options:   choices,
+	}
+	if err = vote.Cast(context.TODO(), db); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errcode.PollVoted
+		}
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errcode.PollClosed
+		}
+		logrus.Errorf("pollVote.Cast content_id:%s err:%s", contentID.Hex(), err)
+		return nil, errcode.VotePollFailed
+	}
+
+	content, err = (&model.PostContent{ID: contentID}).Get(db)
+	if err != nil {
+		logrus.Errorf("postContent.Get content_id:%s err:%s", contentID.Hex(), err)
+		return nil, errcode.VotePollFailed
+	}
+	result := content.Poll.Format()
+	result.Voted = choices
+	return result, nil
+}
+
+func checkPollChoices(poll *model.Poll, choices []int) *errcode.Error {
+	if len(choices) == 0 || (!poll.Multiple && len(choices) > 1) {
+		return errcode.InvalidParams.WithDetails("choose one option, or more on a multiple choice poll")
+	}
+	seen := make(map[int]struct{}, len(choices))
+	for _, i := range choices {
+		if i < 0 || i >= len(poll.Options) {
+			return errcode.InvalidParams.WithDetails("unknown poll option")
+		}
+		if _, ok := seen[i]; ok {
+			return errcode.InvalidParams.WithDetails("duplicated poll option")
+		}
+		seen[i] = struct{}{}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"favor-dao-backend/internal/model"
+)
+
+func TestPollReq_Check(t *testing.T) {
+	future := time.Now().Add(time.Hour).Unix()
+	tests := []struct {
+		name string
+		poll *PollReq
+		ok   bool
+	}{
+		{"missing", nil, false},
+		{"one option", &PollReq{Options: []string{"yes"}, CloseOn: future}, false},
+		{"blank option", &PollReq{Options: []string{"yes", " "}, CloseOn: future}, false},
+		{"closed", &PollReq{Options: []string{"yes", "no"}, CloseOn: time.Now().Unix() - 1}, false},
+		{"valid", &PollReq{Options: []string{"yes", "no"}, CloseOn: future}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.poll.Check(); (err == nil) != tt.ok {
+			t.Errorf("%s: Check() = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestCheckPollChoices(t *testing.T) {
+	single := &model.Poll{Options: make([]*model.PollOption, 3)}
+	multiple := &model.Poll{Options: make([]*model.PollOption, 3), Multiple: true}
+	tests := []struct {
+		poll    *model.Poll
+		choices []int
+		ok      bool
+	}{
+		{single, []int{0}, true},
+		{single, []int{0, 1}, false},
+		{single, nil, false},
+		{single, []int{3}, false},
+		{multiple, []int{0, 2}, true},
+		{multiple, []int{1, 1}, false},
+		{multiple, []int{-1}, false},
+	}
+	for _, tt := range tests {
+		if e := checkPollChoices(tt.poll, tt.choices); (e == nil) != tt.ok {
+			t.Errorf("multiple %v choices %v: got %v, want ok %v", tt.poll.Multiple, tt.choices, e, tt.ok)
+		}
+	}
+}
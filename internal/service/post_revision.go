@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/errcode"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PostEditReq struct {
+	Contents []*PostContentItem `json:"contents" binding:"required"`
+	// Tags of the post, the reposts keep the tags of the original
+	Tags []string `json:"tags"`
+}
+
+// EditPost the author replaces the contents and the tags of the post, the replaced version
+// is kept in the revisions.
+func EditPost(user *model.User, id primitive.ObjectID, param PostEditReq) (_ *model.PostFormatted, e *errcode.Error) {
+	post, err := ds.GetPostByID(id)
+	if err != nil {
+		return nil, errcode.GetPostFailed
+	}
+	if post.Address != user.Address {
+		return nil, errcode.NoPermission
+	}
+	if e = CheckDaoWritable(post.DaoId); e != nil {
+		return nil, e
+	}
+	oldContents, err := ds.GetPostContentsByIDs([]primitive.ObjectID{post.ID})
+	if err != nil {
+		logrus.Errorf("ds.GetPostContentsByIDs post_id:%s err:%s", id.Hex(), err)
+		return nil, errcode.EditPostFailed
+	}
+	if !post.RefId.IsZero() && len(oldContents) == 0 {
+		// a plain retweet has nothing of its own
+		return nil, errcode.PostNotEditable
+	}
+	for _, content := range oldContents {
+		if content.Poll != nil && content.Poll.Voters > 0 {
+			return nil, errcode.PostNotEditable.WithDetails("the poll has votes")
+		}
+	}
+
+	contents := make([]*model.PostContent, 0, len(param.Contents))
+	for _, item := range param.Contents {
+		if err := item.Check(); err != nil {
+			logrus.Infof("contents check err: %v", err)
+			continue
+		}
+		contents = append(contents, &model.PostContent{
+			Address: user.Address,
+			Content: item.Content,
+			Type:    item.Type,
+			Sort:    item.Sort,
+			Poll:    item.poll(),
+		})
+	}
+	if len(contents) == 0 {
+		return nil, errcode.InvalidParams.WithDetails("no valid content")
+	}
+
+	mediaContents, err := persistMediaContents(param.Contents)
+	defer func() {
+		if e != nil {
+			// the media of the old version stay with its revision
+			deleteOssObjects(newMedia(mediaContents, oldContents))
+		}
+	}()
+	if err != nil {
+		logrus.Errorf("persistMediaContents post_id:%s err:%s", id.Hex(), err)
+		return nil, errcode.EditPostFailed
+	}
+
+	revision := &model.PostRevision{
+		PostID:   post.ID,
+		Address:  post.Address,
+		Tags:     post.Tags,
+		Contents: oldContents,
+		EditedOn: post.ModifiedOn,
+	}
+	count, err := revision.Count(context.TODO(), conf.MustMongoDB())
+	if err != nil {
+		logrus.Errorf("postRevision.Count post_id:%s err:%s", id.Hex(), err)
+		return nil, errcode.EditPostFailed
+	}
+	revision.Version = int(count) + 1
+
+	if post.RefId.IsZero() {
+		post.Tags = strings.Join(tagsFrom(param.Tags), ",")
+	}
+	if err = ds.EditPost(post, contents, revision); err != nil {
+		logrus.Errorf("ds.EditPost post_id:%s err:%s", id.Hex(), err)
+		return nil, errcode.EditPostFailed
+	}
+	if post.Visibility != model.PostVisitDraft {
+		schedulePollClose(contents)
+		PushPostToSearch(post)
+	}
+
+	formatted, err := ds.RevampPosts(user.Address, []*model.PostFormatted{post.Format()})
+	if err != nil || len(formatted) == 0 {
+		// the edit is done, only the response is short
+		logrus.Errorf("ds.RevampPosts post_id:%s err:%v", id.Hex(), err)
+		return post.Format(), nil
+	}
+	return formatted[0], nil
+}
+
+func newMedia(media []string, old []*model.PostContent) []string {
+	kept := make(map[string]struct{}, len(old))
+	for _, c := range old {
+		kept[c.Content] = struct{}{}
+	}
+	out := make([]string, 0, len(media))
+	for _, m := range media {
+		if _, ok := kept[m]; !ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// ListPostRevisions the replaced versions of the post, the latest first, filtered as GetPost does.
+// The revisions of a private post are only for its author, those of a gated post for the token
+// holders, and the videos of a member post for its subscribers.
+func ListPostRevisions(viewer string, id primitive.ObjectID, offset, limit int) ([]*model.PostRevision, int64, *errcode.Error) {
+	post, err := ds.GetPostByID(id)
+	if err != nil {
+		return nil, 0, errcode.GetPostFailed
+	}
+	if post.Address != viewer && (post.Visibility == model.PostVisitDraft || IsShadowBanned(post.Address)) {
+		return nil, 0, errcode.NotFound
+	}
+	if post.Visibility == model.PostVisitPrivate && post.Address != viewer {
+		return nil, 0, errcode.NoPermission
+	}
+	if !postUnlocked(viewer, post) {
+		return nil, 0, errcode.NoPermission
+	}
+	revision := &model.PostRevision{PostID: post.ID}
+	db := conf.MustMongoDB()
+	total, err := revision.Count(context.TODO(), db)
+	if err != nil {
+		logrus.Errorf("postRevision.Count post_id:%s err:%s", id.Hex(), err)
+		return nil, 0, errcode.ServerError
+	}
+	list, err := revision.List(context.TODO(), db, offset, limit)
+	if err != nil {
+		logrus.Errorf("postRevision.List post_id:%s err:%s", id.Hex(), err)
+		return nil, 0, errcode.ServerError
+	}
+	if !memberVideoAllowed(viewer, post) {
+		for _, r := range list {
+			for _, content := range r.Contents {
+				if content.Type == model.CONTENT_TYPE_VIDEO {
+					content.Content = ""
+				}
+			}
+		}
+	}
+	return list, total, nil
+}
+
+// memberVideoAllowed the viewer may watch the videos of the post, as FilterMemberContent allows
+func memberVideoAllowed(viewer string, post *model.Post) bool {
+	if post.Type != model.VIDEO || post.Member == model.PostMemberNothing {
+		return true
+	}
+	if viewer == "" {
+		return false
+	}
+	return viewer == post.Address || post.Member.Allow(GetSubscribeTier(viewer, post.DaoId))
+}
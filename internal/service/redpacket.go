@@ -40,6 +40,8 @@ type RedpacketRequest struct {
 	Title  string              `json:"title"    binding:"required"`
 	Amount string              `json:"amount"   binding:"required"`
 	Total  int64               `json:"total"    binding:"required"`
+	// DaoID the DAO the redpacket is sent in if any, its banned members can not claim it
+	DaoID primitive.ObjectID `json:"dao_id"`
 }
 
 type ClaimChRequest struct {
@@ -53,6 +55,27 @@ type ClaimChResponse struct {
 	err  *errcode.Error
 }
 
+// checkRedpacketDao a redpacket sent in a DAO is sent by a member of it, the redpackets sent
+// outside of a DAO have none to check
+func checkRedpacketDao(address string, daoID primitive.ObjectID) *errcode.Error {
+	if daoID.IsZero() {
+		return nil
+	}
+	if _, err := ds.GetDao(&model.Dao{ID: daoID}); err != nil {
+		return errcode.NoExistDao
+	}
+	if e := CheckDaoWritable(daoID); e != nil {
+		return e
+	}
+	if e := CheckDaoBanned(daoID, address); e != nil {
+		return e
+	}
+	if !ds.IsJoinedDAO(address, daoID) {
+		return errcode.NoPermission.WithDetails("only the members send redpackets in the DAO")
+	}
+	return nil
+}
+
 func CreateRedpacket(address string, parm RedpacketRequest) (id string, err error) {
 	if parm.Total > conf.ExternalAppSetting.RedPacketMaxCount || parm.Total < 1 {
 		err = errcode.RedpacketNumberErr
@@ -66,6 +89,10 @@ func CreateRedpacket(address string, parm RedpacketRequest) (id string, err erro
 		err = errcode.RedpacketAmountErr
 		return
 	}
+	if e := checkRedpacketDao(address, parm.DaoID); e != nil {
+		err = e
+		return
+	}
 	var (
 		notify *psub.Notify
 	)
@@ -84,6 +111,7 @@ func CreateRedpacket(address string, parm RedpacketRequest) (id string, err erro
 		Type:    parm.Type,
 		Total:   parm.Total,
 		Balance: parm.Amount,
+		DaoID:   parm.DaoID,
 	}
 	if parm.Type == model.RedpacketTypeAverage {
 		redpacket.AvgAmount = parm.Amount
@@ -96,9 +124,13 @@ func CreateRedpacket(address string, parm RedpacketRequest) (id string, err erro
 			return nil, err
 		}
 		id = redpacket.ID.Hex()
+		err = createPayOrder(sessCtx, PayMethodSendRedpacket, id)
+		if err != nil {
+			return nil, err
+		}
 
 		// sub order
-		notify, err = pubsub.NewSubscribe(id)
+		notify, err = pubsub.NewSubscribe(payNotifyKey(PayMethodSendRedpacket, id))
 		if err != nil {
 			return nil, err
 		}
@@ -108,8 +140,8 @@ func CreateRedpacket(address string, parm RedpacketRequest) (id string, err erro
 			ToSubject:  conf.ExternalAppSetting.RedpacketAddress,
 			Amount:     redpacket.Amount,
 			Comment:    "",
-			Channel:    "send_redpacket",
-			ReturnURI:  conf.PointSetting.Callback + "/pay/notify?method=send_redpacket&order_id=" + id,
+			Channel:    PayMethodSendRedpacket,
+			ReturnURI:  payReturnURI(PayMethodSendRedpacket, id),
 			BindOrder:  id,
 		})
 		if err != nil {
@@ -125,14 +157,14 @@ func CreateRedpacket(address string, parm RedpacketRequest) (id string, err erro
 		logrus.Errorf("redpacket.Update order_id:%s tx_id:%s err:%s", id, redpacket.TxID, e)
 		// When an error occurs, wait for the callback to fix the txID again
 	}
+	setPayOrderTxID(ctx, PayMethodSendRedpacket, id, redpacket.TxID)
 	// wait pay notify
-	select {
-	case <-ctx.Done():
-		err = ctx.Err()
-	case val := <-notify.Ch:
-		if !val.(bool) {
-			err = errors.New("create redpacket failed")
-		}
+	txStatus, err := waitPayNotify(ctx, notify)
+	if err != nil {
+		return
+	}
+	if txStatus != TxCompleted {
+		err = errors.New("create redpacket failed")
 	}
 	return
 }
@@ -167,7 +199,6 @@ func eventSendRedpacket(notify PayCallbackParam) error {
 		if er != nil {
 			logrus.Errorf("enqueue RedpacketDoneTask %s", er)
 		}
-		pubsub.Notify(notify.OrderId, true)
 	case TxRollback, TxCancelled:
 		// failed
 		m.PayStatus = model.PayFailed
@@ -176,7 +207,6 @@ func eventSendRedpacket(notify PayCallbackParam) error {
 			logrus.Errorf("send_redpacket on notify: redpacket.Update tx_status:%s tx_id:%s _id:%s err:%s", notify.TxStatus, notify.TxID, notify.OrderId, err)
 			return err
 		}
-		pubsub.Notify(notify.OrderId, false)
 	}
 	return nil
 }
@@ -292,6 +322,12 @@ func ClaimRedpacket(ctx context.Context, address string, redpacketID primitive.O
 		if redpacket.IsTimeout {
 			return nil, errcode.RedpacketTimeout
 		}
+		// the redpackets sent outside of a DAO have none
+		if !redpacket.DaoID.IsZero() {
+			if e := CheckDaoBanned(redpacket.DaoID, address); e != nil {
+				return nil, e
+			}
+		}
 		if redpacket.ClaimCount == redpacket.Total {
 			return nil, errcode.RedpacketHasBeenCollectedCompletely
 		}
@@ -320,6 +356,10 @@ func ClaimRedpacket(ctx context.Context, address string, redpacketID primitive.O
 		if rr.ID.IsZero() {
 			return nil, errcode.RedpacketAlreadyClaim
 		}
+		err = createPayOrder(sessCtx, PayMethodClaimRedpacket, rr.ID.Hex())
+		if err != nil {
+			return nil, err
+		}
 		err = redpacket.FindAndUpdate(sessCtx, conf.MustMongoDB(), bson.M{model.ID: redpacket.ID, "balance": redpacket.Balance, "claim_count": redpacket.ClaimCount}, bson.M{
 			"$set": bson.M{"balance": balance, "claim_count": redpacket.ClaimCount + 1},
 		})
@@ -327,13 +367,13 @@ func ClaimRedpacket(ctx context.Context, address string, redpacketID primitive.O
 			return nil, err
 		}
 		// pay
-		redpacket.TxID, err = point.Pay(sessCtx, pointSystem.PayRequest{
+		rr.TxID, err = point.Pay(sessCtx, pointSystem.PayRequest{
 			FromObject: conf.ExternalAppSetting.RedpacketAddress,
 			ToSubject:  address,
 			Amount:     rr.Amount,
 			Comment:    "",
-			Channel:    "claim_redpacket",
-			ReturnURI:  conf.PointSetting.Callback + "/pay/notify?method=claim_redpacket&order_id=" + rr.ID.Hex(),
+			Channel:    PayMethodClaimRedpacket,
+			ReturnURI:  payReturnURI(PayMethodClaimRedpacket, rr.ID.Hex()),
 			BindOrder:  rr.ID.Hex(),
 		})
 		if err != nil {
@@ -352,6 +392,7 @@ func ClaimRedpacket(ctx context.Context, address string, redpacketID primitive.O
 	}
 
 	conf.Redis.Decr(ctx, key)
+	setPayOrderTxID(ctx, PayMethodClaimRedpacket, rr.ID.Hex(), rr.TxID)
 
 	return rr, nil
 }
@@ -381,6 +422,9 @@ func eventClaimRedpacket(notify PayCallbackParam) error {
 		logrus.Errorf("claim_redpacket on notify: RedpacketRecords.Update tx_status:%s tx_id:%s _id:%s err:%s", notify.TxStatus, notify.TxID, notify.OrderId, err)
 		return err
 	}
+	if m.PayStatus == model.PaySuccess {
+		publishRedpacketClaim(ctx, m)
+	}
 	return nil
 }
 
@@ -1,22 +1,19 @@
 package service
 
 import (
-	"bytes"
 	"context"
 	"crypto/rand"
 	"fmt"
-	"io"
 	"math/big"
-	mathrand "math/rand"
-	"net/http"
 	"strconv"
-	"sync"
 	"testing"
 
+	"favor-dao-backend/internal/conf"
 	"favor-dao-backend/internal/model"
 	"favor-dao-backend/pkg/convert"
-	"favor-dao-backend/pkg/json"
+	"favor-dao-backend/pkg/pointSystem"
 	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func sum(numbers []string) (total string) {
@@ -107,86 +104,177 @@ func TestRedpacketRand(t *testing.T) {
 	t.Logf("got %d redpackets: %v", count, values)
 }
 
-func TestClaimRedpacket(t *testing.T) {
-	// t.Skip("redpacket feat, need run server")
+func TestRedpacketRefund(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		status string
+		// sender the balance of the sender after the refund settles
+		sender string
+	}{
+		{"completed", pointSystem.TxCompleted, "40"},
+		{"rollback", pointSystem.TxRollback, "0"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, n := setupFakePoint(t, pointSystem.FakeOptions{
+				Channels: map[string]string{PayMethodRefundRedpacket: tt.status},
+			})
+			ctx := context.Background()
+			if err := fake.Deposit("sender", "100"); err != nil {
+				t.Fatal(err)
+			}
 
-	redpacketID := "64f0459841ef4c88ee2e87f5"
-	totalUser := 1000
-	isRand := true
-	testUser := func() int {
-		return mathrand.Intn(10)
-	}
-	ctx := context.TODO()
-	var claimTotal int64 = 0
-	wg := sync.WaitGroup{}
-	for i := 0; i < totalUser; i++ {
-		var v = i
-		if isRand {
-			v = testUser()
-		}
-		wg.Add(1)
-		go func(k int) {
-			defer wg.Done()
-			url := fmt.Sprintf("http://127.0.0.1:8010/v1/test/redpacket/%s?user=test%d", redpacketID, k)
-			var resp struct {
-				Code    int                  `json:"code"`
-				Msg     string               `json:"msg"`
-				Data    model.RedpacketClaim `json:"data,omitempty"`
-				Details []string             `json:"details"`
-			}
-			err := request(ctx, "POST", url, nil, &resp)
-			if err != nil {
-				t.Logf("test%d err:%s msg:%s details:%s", k, err, resp.Msg, resp.Details)
-				for _, v := range resp.Details {
-					t.Logf("test%d, details:%s", k, v)
+			m := &model.Redpacket{Address: "sender", Amount: "100", Total: 5}
+			m.ID = primitive.NewObjectID()
+			if _, err := point.Pay(ctx, pointSystem.PayRequest{
+				FromObject: m.Address,
+				ToSubject:  conf.ExternalAppSetting.RedpacketAddress,
+				Amount:     m.Amount,
+				Channel:    PayMethodSendRedpacket,
+				ReturnURI:  payReturnURI(PayMethodSendRedpacket, m.ID.Hex()),
+				BindOrder:  m.ID.Hex(),
+			}); err != nil {
+				t.Fatal(err)
+			}
+			fake.Wait()
+
+			// 3 of the 5 packets are claimed before it times out
+			balance := convert.StrTo(m.Amount).MustBigInt()
+			for i, amount := range []string{"10", "20", "30"} {
+				if _, err := point.Pay(ctx, pointSystem.PayRequest{
+					FromObject: conf.ExternalAppSetting.RedpacketAddress,
+					ToSubject:  fmt.Sprintf("claimer%d", i),
+					Amount:     amount,
+					Channel:    PayMethodClaimRedpacket,
+					BindOrder:  fmt.Sprintf("claim%d", i),
+				}); err != nil {
+					t.Fatal(err)
 				}
-				return
+				balance.Sub(balance, convert.StrTo(amount).MustBigInt())
+				m.ClaimCount++
 			}
-			if resp.Code != 0 {
-				t.Logf("test%d errcode:%d, msg:%s", k, resp.Code, resp.Msg)
-				return
+			fake.Wait()
+			m.Balance = balance.String()
+
+			refund, ok := redpacketRefund(m)
+			if !ok {
+				t.Fatal("the unclaimed packets must be refunded")
 			}
-			t.Logf("test%d claim: %s", k, resp.Data.Amount)
-			a, _ := new(big.Int).SetString(resp.Data.Amount, 10)
-			claimTotal += a.Int64()
-			// }(i)
-		}(v)
-	}
-	wg.Wait()
-	t.Logf("total claim: %d", claimTotal)
-}
+			if refund.ToSubject != "sender" || refund.Amount != "40" {
+				t.Errorf("refund %s to %s, want 40 to the sender", refund.Amount, refund.ToSubject)
+			}
+			if m.RefundTxID, _ = point.Pay(ctx, refund); m.RefundTxID == "" {
+				t.Fatal("no refund tx")
+			}
+			// the done task retried pays the same refund
+			if again, _ := point.Pay(ctx, refund); again != m.RefundTxID {
+				t.Errorf("retried refund tx %s, want %s", again, m.RefundTxID)
+			}
+			if _, ok = redpacketRefund(m); ok {
+				t.Error("the refunded redpacket must not be refunded again")
+			}
+			fake.Wait()
 
-func request(ctx context.Context, method, url string, body, respData interface{}) error {
-	client := http.DefaultClient
-	var reqBody io.Reader
-	if body != nil {
-		rawBody, err := json.Marshal(body)
-		if err != nil {
-			return err
-		}
-		reqBody = bytes.NewReader(rawBody)
-	} else {
-		reqBody = nil
+			notify, ok := n.last(m.ID.Hex())
+			if !ok || notify.Method != PayMethodRefundRedpacket || notify.TxID != m.RefundTxID {
+				t.Fatalf("refund notify %+v, want tx %s", notify, m.RefundTxID)
+			}
+			if status, settled := payStatusOf(notify.TxStatus); !settled || (status == model.PaySuccess) != (tt.status == pointSystem.TxCompleted) {
+				t.Errorf("refund tx_status %s settled %v as %d", notify.TxStatus, settled, status)
+			}
+			if got := fake.Balance("sender"); got != tt.sender {
+				t.Errorf("sender balance %s, want %s", got, tt.sender)
+			}
+			if got := fake.Balance("claimer2"); got != "30" {
+				t.Errorf("claimer balance %s, want 30", got)
+			}
+		})
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
-	if err != nil {
-		return err
-	}
-	req.Header.Set("accept", "application/json")
-	req.Header.Set("content-type", "application/json")
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
+	m := &model.Redpacket{Address: "sender", Total: 2, ClaimCount: 2, Balance: "0"}
+	if _, ok := redpacketRefund(m); ok {
+		t.Error("a fully claimed redpacket has nothing to refund")
 	}
-	// if resp.StatusCode != http.StatusOK {
-	// 	return errors.New(resp.Status)
-	// }
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
+}
+
+func TestRedpacketDone(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		status string
+		// the balances of the sender and of the redpacket account after the refund settles
+		sender, pool string
+		refund       model.PayStatus
+	}{
+		{"completed", pointSystem.TxCompleted, "40", "0", model.PaySuccess},
+		{"rollback", pointSystem.TxRollback, "0", "40", model.PayFailed},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, _ := setupTestEnv(t, pointSystem.FakeOptions{
+				Channels: map[string]string{PayMethodRefundRedpacket: tt.status},
+			})
+			ctx := context.Background()
+			sender := createTestUser(t, "sender")
+			if err := fake.Deposit(sender.Address, "100"); err != nil {
+				t.Fatal(err)
+			}
+
+			id, err := CreateRedpacket(sender.Address, RedpacketRequest{
+				Type:   model.RedpacketTypeAverage,
+				Title:  "test",
+				Amount: "20",
+				Total:  5,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			redpacketID, _ := primitive.ObjectIDFromHex(id)
+			// 3 of the 5 packets are claimed before it times out
+			for i := 0; i < 3; i++ {
+				claimer := createTestUser(t, "claimer")
+				if _, e := ClaimRedpacket(ctx, claimer.Address, redpacketID); e != nil {
+					t.Fatal(e)
+				}
+			}
+			fake.Wait()
+
+			task := NewRedpacketDoneTask(id)
+			if err = HandleRedpacketDoneTask(ctx, task); err != nil {
+				t.Fatal(err)
+			}
+			// the done task retried does not refund twice
+			if err = HandleRedpacketDoneTask(ctx, task); err != nil {
+				t.Fatal(err)
+			}
+			fake.Wait()
+
+			m := &model.Redpacket{}
+			m.ID = redpacketID
+			if err = m.First(ctx, conf.MustMongoDB()); err != nil {
+				t.Fatal(err)
+			}
+			if !m.IsTimeout || m.ClaimCount != 3 || m.Balance != "40" {
+				t.Errorf("redpacket timeout %v claimed %d balance %s, want timeout 3 claimed 40 left", m.IsTimeout, m.ClaimCount, m.Balance)
+			}
+			if m.RefundTxID == "" || m.RefundStatus != tt.refund {
+				t.Errorf("refund tx %q status %d, want %d", m.RefundTxID, m.RefundStatus, tt.refund)
+			}
+			if m.PayStatus != model.PaySuccess {
+				t.Errorf("redpacket pay status %d, want paid", m.PayStatus)
+			}
+			order := &model.PayOrder{Method: PayMethodSendRedpacket, OrderID: id}
+			if err = order.First(ctx, conf.MustMongoDB()); err != nil {
+				t.Fatal(err)
+			}
+			if order.Status != model.PayOrderSuccess || order.TxID != m.TxID {
+				t.Errorf("pay order %d tx %s, want paid tx %s", order.Status, order.TxID, m.TxID)
+			}
+			if got := fake.Balance(sender.Address); got != tt.sender {
+				t.Errorf("sender balance %s, want %s", got, tt.sender)
+			}
+			if got := fake.Balance(conf.ExternalAppSetting.RedpacketAddress); got != tt.pool {
+				t.Errorf("redpacket account balance %s, want %s", got, tt.pool)
+			}
+		})
 	}
-	return json.Unmarshal(respBody, respData)
 }
 
 func TestRedis(t *testing.T) {
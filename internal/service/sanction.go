@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/errcode"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type SanctionReq struct {
+	Type   model.SanctionT `json:"type"     binding:"required"`
+	Reason string          `json:"reason"   binding:"max=500"`
+	// Duration of the sanction in seconds, 0 forever
+	Duration int64 `json:"duration" binding:"min=0"`
+}
+
+// UserSanctions the sanctions in force of a user, middleware.Login loads them for the request
+type UserSanctions struct {
+	Suspend   *model.UserSanction
+	ShadowBan *model.UserSanction
+}
+
+func GetUserSanctions(address string) *UserSanctions {
+	list, err := (&model.UserSanction{Address: address}).ActiveList(context.TODO(), conf.MustMongoDB())
+	if err != nil {
+		logrus.Errorf("userSanction.ActiveList address:%s err:%s", address, err)
+	}
+	s := &UserSanctions{}
+	for _, v := range list {
+		switch v.Type {
+		case model.SanctionSuspend:
+			s.Suspend = v
+		case model.SanctionShadowBan:
+			s.ShadowBan = v
+		}
+	}
+	return s
+}
+
+// CheckSuspended the suspended user can not post, comment, claim redpackets or create DAOs,
+// the error tells the reason and the expiry.
+func (s *UserSanctions) CheckSuspended() *errcode.Error {
+	if s == nil || s.Suspend == nil {
+		return nil
+	}
+	if s.Suspend.ExpiredOn > 0 {
+		return errcode.UserSuspended.WithDetails(s.Suspend.Reason, time.Unix(s.Suspend.ExpiredOn, 0).UTC().Format(time.RFC3339))
+	}
+	return errcode.UserSuspended.WithDetails(s.Suspend.Reason)
+}
+
+func IsShadowBanned(address string) bool {
+	err := (&model.UserSanction{Address: address, Type: model.SanctionShadowBan}).Active(context.TODO(), conf.MustMongoDB())
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			logrus.Errorf("userSanction.Active address:%s err:%s", address, err)
+		}
+		return false
+	}
+	return true
+}
+
+// GetShadowBannedAddresses the authors whose content is hidden from the viewer, the viewer
+// always sees its own.
+func GetShadowBannedAddresses(viewer string) []string {
+	list, err := (&model.UserSanction{Type: model.SanctionShadowBan}).ActiveAddresses(context.TODO(), conf.MustMongoDB())
+	if err != nil {
+		logrus.Errorf("userSanction.ActiveAddresses err:%s", err)
+		return nil
+	}
+	out := list[:0]
+	for _, address := range list {
+		if address != viewer {
+			out = append(out, address)
+		}
+	}
+	return out
+}
+
+func ListSanctions(ctx context.Context, typ model.SanctionT, offset, limit int) ([]*model.UserSanction, int64, *errcode.Error) {
+	list, total, err := (&model.UserSanction{Type: typ}).FindPage(ctx, conf.MustMongoDB(), offset, limit)
+	if err != nil {
+		logrus.Errorf("userSanction.FindPage type:%d err:%s", typ, err)
+		return nil, 0, errcode.ServerError
+	}
+	return list, total, nil
+}
+
+// ImposeSanction replace the sanction of the same type of the user, the suspended user is notified
+// while the shadow-banned one is not.
+func ImposeSanction(ctx context.Context, admin *model.User, address string, param SanctionReq) (*model.UserSanction, *errcode.Error) {
+	if !param.Type.Valid() {
+		return nil, errcode.InvalidParams.WithDetails("unknown sanction type")
+	}
+	if address == admin.Address {
+		return nil, errcode.NoPermission
+	}
+	user, err := ds.GetUserByAddress(address)
+	if err != nil {
+		return nil, errcode.NoExistUserAddress
+	}
+	if user.HasRole(model.UserRoleModerator) {
+		// the staff are demoted first
+		return nil, errcode.NoPermission
+	}
+	sanction := &model.UserSanction{
+		Address: address,
+		Type:    param.Type,
+		Reason:  param.Reason,
+		By:      admin.Address,
+	}
+	if param.Duration > 0 {
+		sanction.ExpiredOn = time.Now().Unix() + param.Duration
+	}
+	if err = sanction.Impose(ctx, conf.MustMongoDB()); err != nil {
+		logrus.Errorf("userSanction.Impose address:%s err:%s", address, err)
+		return nil, errcode.ServerError
+	}
+	if param.Type == model.SanctionSuspend {
+		content := "Your account is suspended"
+		if sanction.ExpiredOn > 0 {
+			content += " until " + time.Unix(sanction.ExpiredOn, 0).UTC().Format(time.RFC3339)
+		}
+		if param.Reason != "" {
+			content += ": " + param.Reason
+		}
+		notifyModeration(ctx, address, content)
+	}
+	return sanction, nil
+}
+
+func LiftSanction(ctx context.Context, address string, typ model.SanctionT) *errcode.Error {
+	err := (&model.UserSanction{Address: address, Type: typ}).Lift(ctx, conf.MustMongoDB())
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errcode.NoExistSanction
+		}
+		logrus.Errorf("userSanction.Lift address:%s err:%s", address, err)
+		return errcode.ServerError
+	}
+	if typ == model.SanctionSuspend {
+		notifyModeration(ctx, address, "The suspension of your account is lifted")
+	}
+	return nil
+}
@@ -9,28 +9,34 @@ import (
 	"favor-dao-backend/internal/core"
 	"favor-dao-backend/internal/dao"
 	"favor-dao-backend/internal/model"
-	"favor-dao-backend/pkg/comet"
+	"favor-dao-backend/pkg/hub"
 	"favor-dao-backend/pkg/notify"
 	"favor-dao-backend/pkg/pointSystem"
 	"favor-dao-backend/pkg/psub"
+	"favor-dao-backend/pkg/tokengate"
 
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/go-redis/redis_rate/v10"
 	"github.com/hibiken/asynq"
 	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 var (
 	ds            core.DataService
 	ts            core.TweetSearchService
+	ams           core.AuthorizationManageService
 	eth           *ethclient.Client
-	chat          *comet.ChatGateway
-	point         *pointSystem.Gateway
+	gate          *tokengate.Checker
+	chat          core.ChatService
+	point         pointSystem.Service
 	pubsub        *psub.Service
+	chatHub       *hub.Hub
+	notifyHub     *hub.Hub
 	queue         *asynq.Client
 	limiter       *redis_rate.Limiter
-	notifyGateway *notify.Gateway
+	notifyGateway notify.Service
 )
 
 func Initialize() {
@@ -44,21 +50,40 @@ func Initialize() {
 	setupJobServer()
 	ds = dao.DataService()
 	ts = dao.TweetSearchService()
+	ams = dao.AuthorizationManageService()
 
-	pubsub = psub.New()
+	pubsub = psub.New(conf.Redis, "pay_notify")
+	chatHub = hub.New(conf.Redis, "chat")
+	notifyHub = hub.New(conf.Redis, "notify")
+	go runNotifyWatcher(context.Background())
 	// MUST connect!
 	client, err := ethclient.Dial(conf.EthSetting.Endpoint)
 	if err != nil {
 		panic(fmt.Sprintf("dial eth: %s", err))
 	}
 	eth = client
-	notifyGateway = notify.New(conf.NotifySetting.Gateway)
+	gate = tokengate.New(eth, conf.Redis, conf.EthSetting.GateCacheTTL)
+	notifyGateway = newNotifyService()
 	if err != nil {
 		panic(err)
 	}
-	chat = comet.New(conf.ChatSetting.AppId, conf.ChatSetting.Region, conf.ChatSetting.ApiKey)
-	point = pointSystem.New(conf.PointSetting.Gateway)
+	chat = dao.ChatService()
 	conf.PointSetting.Callback = strings.TrimRight(conf.PointSetting.Callback, "/")
+	point = newPointSystem()
+}
+
+func newPointSystem() pointSystem.Service {
+	if conf.CfgIf("PointFake") {
+		logrus.Infof("use fake point system with status %s", conf.PointFakeSetting.Status)
+		return pointSystem.NewFake(pointSystem.FakeOptions{
+			Status:      conf.PointFakeSetting.Status,
+			Channels:    conf.PointFakeSetting.Channels,
+			Delay:       conf.PointFakeSetting.Delay,
+			InitBalance: conf.PointFakeSetting.InitBalance,
+			Secret:      conf.PointSetting.Secret,
+		})
+	}
+	return pointSystem.New(conf.PointSetting.Gateway)
 }
 
 func persistMediaContents(contents []*PostContentItem) (items []string, err error) {
@@ -77,7 +102,10 @@ func persistMediaContents(contents []*PostContentItem) (items []string, err erro
 	return
 }
 
-const PostQueue = "post"
+const (
+	PostQueue = "post"
+	PayQueue  = "pay"
+)
 
 func setupJobServer() {
 	resiConfig := asynq.RedisClientOpt{
@@ -100,12 +128,23 @@ func setupJobServer() {
 			Queues: map[string]int{
 				PostQueue:      10,
 				QueueRedpacket: 10,
+				PayQueue:       10,
+				NotifyQueue:    5,
 			},
 		},
 	)
 	mux := asynq.NewServeMux()
 	mux.HandleFunc(PostUnpin, HandlePostUnpinTask)
+	mux.HandleFunc(TypePostPublish, HandlePostPublishTask)
+	mux.HandleFunc(TypePollClose, HandlePollCloseTask)
 	mux.HandleFunc(TypeRedpacketDone, HandleRedpacketDoneTask)
+	mux.HandleFunc(TypePayReconcile, HandlePayReconcileTask)
+	mux.HandleFunc(TypeDaoSubscribe, HandleDaoSubscribeTask)
+	mux.HandleFunc(TypeNotifyDao, HandleNotifyDaoTask)
+	mux.HandleFunc(TypeDaoStats, HandleDaoStatsTask)
+	mux.HandleFunc(TypeDaoPreview, HandleDaoPreviewTask)
+	mux.HandleFunc(TypeUserCancellation, HandleUserCancellationTask)
+	mux.HandleFunc(TypeSearchReindex, HandleSearchReindexTask)
 
 	go func() {
 		if err := server.Run(mux); err != nil {
@@ -113,5 +152,36 @@ func setupJobServer() {
 		}
 	}()
 
+	scheduler := asynq.NewScheduler(resiConfig, &asynq.SchedulerOpts{
+		Logger: logrus.StandardLogger(),
+	})
+	// every node registers the same entry, Unique keeps one task per interval
+	interval := conf.PointSetting.ReconcileInterval
+	_, err := scheduler.Register(fmt.Sprintf("@every %s", interval), NewPayReconcileTask(), asynq.Queue(PayQueue), asynq.Unique(interval))
+	if err != nil {
+		panic(err)
+	}
+	interval = conf.ExternalAppSetting.SubscribeCheckInterval
+	_, err = scheduler.Register(fmt.Sprintf("@every %s", interval), NewDaoSubscribeTask(), asynq.Queue(PayQueue), asynq.Unique(interval))
+	if err != nil {
+		panic(err)
+	}
+	interval = conf.ExternalAppSetting.DaoStatsInterval
+	_, err = scheduler.Register(fmt.Sprintf("@every %s", interval), NewDaoStatsTask(), asynq.Queue(PostQueue), asynq.Unique(interval))
+	if err != nil {
+		panic(err)
+	}
+	// the zero DAO refreshes the stale previews
+	interval = conf.ExternalAppSetting.DaoPreviewTTL
+	_, err = scheduler.Register(fmt.Sprintf("@every %s", interval), NewDaoPreviewTask(primitive.NilObjectID), asynq.Queue(PostQueue), asynq.Unique(interval))
+	if err != nil {
+		panic(err)
+	}
+	go func() {
+		if err := scheduler.Run(); err != nil {
+			panic(err)
+		}
+	}()
+
 	queue = asynq.NewClient(resiConfig)
 }
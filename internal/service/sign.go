@@ -5,13 +5,16 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
+	"crypto/hmac"
 	"fmt"
-	"sort"
+	"net/url"
+	"strconv"
 	"time"
 
 	"favor-dao-backend/internal/conf"
+	"favor-dao-backend/internal/model"
 	"favor-dao-backend/pkg/errcode"
-	"favor-dao-backend/pkg/util"
+	"favor-dao-backend/pkg/pointSystem"
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -82,27 +85,55 @@ func VerifySignMessage(ctx context.Context, auth *AuthByWalletRequest, guessMess
 	return ok, nil
 }
 
-func GetParamSign(param map[string]interface{}, secretKey string) string {
-	signRaw := ""
+const PrefixRedisKeyPayNonce = "pay_notify_nonce:"
 
-	rawStrs := []string{}
-	for k, v := range param {
-		if k != "sign" {
-			rawStrs = append(rawStrs, k+"="+fmt.Sprintf("%v", v))
+// VerifyPayNotify check the signature, timestamp and nonce of the pay notify callback.
+// Rejected callbacks are written to the audit collection.
+func VerifyPayNotify(ctx context.Context, query url.Values, remoteIP string) *errcode.Error {
+	e := checkPayNotifySign(query, conf.PointSetting.Secret, conf.PointSetting.SignTolerance, time.Now())
+	if e == nil {
+		// replay protection, the nonce is kept until the timestamp is expired anyway
+		key := PrefixRedisKeyPayNonce + query.Get("nonce")
+		ok, err := conf.Redis.SetNX(ctx, key, query.Get("timestamp"), 2*conf.PointSetting.SignTolerance).Result()
+		if err != nil {
+			return errcode.ServerError.WithDetails(err.Error())
 		}
+		if ok {
+			return nil
+		}
+		e = errcode.PayNotifyReplay
 	}
 
-	sort.Strings(rawStrs)
-	for _, v := range rawStrs {
-		signRaw += v
+	logrus.Warnf("reject pay notify from %s: %s query:%s", remoteIP, e.Msg(), query.Encode())
+	audit := &model.PayNotifyAudit{
+		Method:   query.Get("method"),
+		OrderID:  query.Get("order_id"),
+		TxID:     query.Get("tx_id"),
+		TxStatus: query.Get("tx_status"),
+		Query:    query.Encode(),
+		RemoteIP: remoteIP,
+		Reason:   e.Msg(),
 	}
-
-	if conf.ServerSetting.RunMode == "debug" {
-		logrus.Info(map[string]string{
-			"signRaw": signRaw,
-			"sysSign": util.EncodeMD5(signRaw + secretKey),
-		})
+	if err := audit.Create(ctx, conf.MustMongoDB()); err != nil {
+		logrus.Errorf("payNotifyAudit.Create order_id:%s err:%s", audit.OrderID, err)
 	}
+	return e
+}
 
-	return util.EncodeMD5(signRaw + secretKey)
+func checkPayNotifySign(query url.Values, secret string, tolerance time.Duration, now time.Time) *errcode.Error {
+	sign := query.Get("sign")
+	if sign == "" || !hmac.Equal([]byte(sign), []byte(pointSystem.Sign(query, secret))) {
+		return errcode.PayNotifySign
+	}
+	if query.Get("nonce") == "" {
+		return errcode.PayNotifySign
+	}
+	timestamp, err := strconv.ParseInt(query.Get("timestamp"), 10, 64)
+	if err != nil {
+		return errcode.PayNotifySign
+	}
+	if d := now.Sub(time.Unix(timestamp, 0)); d > tolerance || d < -tolerance {
+		return errcode.PayNotifyExpired
+	}
+	return nil
 }
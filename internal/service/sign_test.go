@@ -0,0 +1,62 @@
+package service
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"favor-dao-backend/pkg/errcode"
+	"favor-dao-backend/pkg/pointSystem"
+)
+
+func TestCheckPayNotifySign(t *testing.T) {
+	secret := "secret"
+	now := time.Now()
+	signed := func(at time.Time) url.Values {
+		query := url.Values{}
+		query.Set("method", PayMethodSubDao)
+		query.Set("order_id", "64f0459841ef4c88ee2e87f5")
+		query.Set("tx_id", "tx")
+		query.Set("tx_status", TxCompleted)
+		pointSystem.SignQuery(query, secret, at)
+		return query
+	}
+
+	forged := signed(now)
+	forged.Set("tx_status", TxRollback)
+
+	noSign := signed(now)
+	noSign.Del("sign")
+
+	otherSecret := url.Values{}
+	otherSecret.Set("order_id", "64f0459841ef4c88ee2e87f5")
+	pointSystem.SignQuery(otherSecret, "other", now)
+
+	tests := []struct {
+		name  string
+		query url.Values
+		want  *errcode.Error
+	}{
+		{"valid", signed(now), nil},
+		{"clock skew", signed(now.Add(time.Minute)), nil},
+		{"forged status", forged, errcode.PayNotifySign},
+		{"no sign", noSign, errcode.PayNotifySign},
+		{"other secret", otherSecret, errcode.PayNotifySign},
+		{"expired", signed(now.Add(-10 * time.Minute)), errcode.PayNotifyExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkPayNotifySign(tt.query, secret, 5*time.Minute, now); got != tt.want {
+				t.Errorf("checkPayNotifySign() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// timestamp is part of the signature
+	moved := signed(now.Add(-10 * time.Minute))
+	moved.Set("timestamp", strconv.FormatInt(now.Unix(), 10))
+	if got := checkPayNotifySign(moved, secret, 5*time.Minute, now); got != errcode.PayNotifySign {
+		t.Errorf("checkPayNotifySign() = %v, want %v", got, errcode.PayNotifySign)
+	}
+}
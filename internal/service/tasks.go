@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"favor-dao-backend/internal/conf"
 	"favor-dao-backend/internal/model"
@@ -51,18 +52,8 @@ func HandleRedpacketDoneTask(ctx context.Context, t *asynq.Task) (err error) {
 		if err != nil {
 			return nil, err
 		}
-		// check need refund
-		if m.Total-m.ClaimCount > 0 && m.RefundTxID == "" {
-			// pay
-			m.RefundTxID, err = point.Pay(sessCtx, pointSystem.PayRequest{
-				FromObject: conf.ExternalAppSetting.RedpacketAddress,
-				ToSubject:  m.Address,
-				Amount:     m.Balance,
-				Comment:    "",
-				Channel:    "refund_redpacket",
-				ReturnURI:  conf.PointSetting.Callback + "/pay/notify?method=refund_redpacket&order_id=" + p.Id,
-				BindOrder:  p.Id,
-			})
+		if refund, ok := redpacketRefund(&m); ok {
+			m.RefundTxID, err = point.Pay(sessCtx, refund)
 		}
 		if err != nil {
 			return nil, err
@@ -72,6 +63,23 @@ func HandleRedpacketDoneTask(ctx context.Context, t *asynq.Task) (err error) {
 	return err
 }
 
+// redpacketRefund the pay giving the unclaimed balance back to the sender, false when nothing is left
+// or the refund is paid already
+func redpacketRefund(m *model.Redpacket) (pointSystem.PayRequest, bool) {
+	if m.Total-m.ClaimCount <= 0 || m.RefundTxID != "" {
+		return pointSystem.PayRequest{}, false
+	}
+	return pointSystem.PayRequest{
+		FromObject: conf.ExternalAppSetting.RedpacketAddress,
+		ToSubject:  m.Address,
+		Amount:     m.Balance,
+		Comment:    "",
+		Channel:    PayMethodRefundRedpacket,
+		ReturnURI:  payReturnURI(PayMethodRefundRedpacket, m.ID.Hex()),
+		BindOrder:  m.ID.Hex(),
+	}, true
+}
+
 func eventRefundRedpacket(notify PayCallbackParam) error {
 	ctx := context.Background()
 	m := &model.Redpacket{}
@@ -90,27 +98,179 @@ func eventRefundRedpacket(notify PayCallbackParam) error {
 		}
 		return nil
 	}
-	switch notify.TxStatus {
-	case TxCompleted:
-		// success
-		m.RefundStatus = model.PaySuccess
-	case TxRollback, TxCancelled:
-		// failed
-		m.RefundStatus = model.PayFailed
-	default:
+	status, settled := payStatusOf(notify.TxStatus)
+	if !settled {
 		return nil
 	}
+	m.RefundStatus = status
 	return upFun()
 }
 
+const TypePayReconcile = "pay:reconcile"
+
+func NewPayReconcileTask() *asynq.Task {
+	return asynq.NewTask(TypePayReconcile, nil)
+}
+
+// HandlePayReconcileTask settle the orders whose pay notify never arrived by querying the point system
+func HandlePayReconcileTask(ctx context.Context, t *asynq.Task) (err error) {
+	db := conf.MustMongoDB()
+	before := time.Now().Add(-conf.PointSetting.NotifyTimeout).Unix()
+
+	m := &model.PayOrder{}
+	n, err := m.ResetStale(ctx, db, before)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		logrus.Warnf("Pay reconcile: reset %d orders stuck in settling", n)
+	}
+
+	orders, err := m.FindPending(ctx, db, before, 100)
+	if err != nil {
+		return err
+	}
+	logrus.Debugf("Pay reconcile: %d pending orders\n", len(orders))
+
+	for _, order := range orders {
+		if e := reconcilePayOrder(ctx, order); e != nil {
+			logrus.Errorf("Pay reconcile method:%s order_id:%s err:%s", order.Method, order.OrderID, e)
+		}
+	}
+	return nil
+}
+
+func reconcilePayOrder(ctx context.Context, order *model.PayOrder) error {
+	notify := PayCallbackParam{
+		OrderId: order.OrderID,
+		Method:  order.Method,
+		TxID:    order.TxID,
+	}
+	exhausted := order.Attempts+1 >= conf.PointSetting.ReconcileMaxAttempts
+
+	trade, err := point.FindTrade(ctx, order.Method, order.OrderID, order.TxID)
+	if errors.Is(err, pointSystem.ErrTradeNotFound) {
+		// the pay request never reached the point system
+		if !exhausted {
+			return order.Checked(ctx, conf.MustMongoDB())
+		}
+		notify.TxStatus = TxCancelled
+		return PayNotify(notify)
+	}
+	if err != nil {
+		if e := order.Checked(ctx, conf.MustMongoDB()); e != nil {
+			logrus.Errorf("payOrder.Checked method:%s order_id:%s err:%s", order.Method, order.OrderID, e)
+		}
+		return err
+	}
+	notify.TxID = trade.Id
+	switch trade.Status {
+	case TxCompleted, TxRollback, TxCancelled:
+		notify.TxStatus = trade.Status
+	case TxFailed, TxCrashed:
+		if !exhausted {
+			return order.Checked(ctx, conf.MustMongoDB())
+		}
+		notify.TxStatus = TxCancelled
+	default:
+		// still pending in the point system
+		return order.Checked(ctx, conf.MustMongoDB())
+	}
+	return PayNotify(notify)
+}
+
+const TypeDaoSubscribe = "dao:subscribe"
+
+func NewDaoSubscribeTask() *asynq.Task {
+	return asynq.NewTask(TypeDaoSubscribe, nil)
+}
+
+// HandleDaoSubscribeTask expire the subscriptions out of date and renew the auto renew ones before they expire
+func HandleDaoSubscribeTask(ctx context.Context, t *asynq.Task) (err error) {
+	db := conf.MustMongoDB()
+	now := time.Now()
+
+	m := &model.DaoSubscribe{}
+	n, err := m.Expire(ctx, db, now.Unix())
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		logrus.Debugf("DAO subscribe: %d subscriptions expired\n", n)
+	}
+
+	list, err := m.FindToRenew(ctx, db, now.Add(conf.ExternalAppSetting.SubscribeRenewAhead).Unix(), 100)
+	if err != nil {
+		return err
+	}
+	for _, sub := range list {
+		if e := renewDaoSubscribe(ctx, sub); e != nil {
+			logrus.Errorf("DAO subscribe renew order_id:%s err:%s", sub.ID.Hex(), e)
+		}
+	}
+	return nil
+}
+
+// renewDaoSubscribe create the next order of the subscription at the current price of its tier,
+// the new order starts when the old one expires.
+func renewDaoSubscribe(ctx context.Context, old *model.DaoSubscribe) error {
+	if e := CheckDAOUser(old.DaoID); e != nil {
+		return e
+	}
+	if e := CheckDaoWritable(old.DaoID); e != nil {
+		return e
+	}
+	sub := &model.DaoSubscribe{
+		Address:   old.Address,
+		DaoID:     old.DaoID,
+		Tier:      old.Tier,
+		AutoRenew: true,
+		StartOn:   old.ExpiredOn,
+	}
+	var txID string
+	err := ds.SubscribeDAO(sub, func(ctx context.Context, orderID string, dao *model.Dao) (err error) {
+		err = old.MarkRenewed(ctx, conf.MustMongoDB(), orderID)
+		if err != nil {
+			return err
+		}
+		err = createPayOrder(ctx, PayMethodSubDao, orderID)
+		if err != nil {
+			return err
+		}
+		txID, err = point.Pay(ctx, pointSystem.PayRequest{
+			FromObject: sub.Address,
+			ToSubject:  dao.Address,
+			Amount:     sub.PayAmount,
+			Comment:    "",
+			Channel:    PayMethodSubDao,
+			ReturnURI:  payReturnURI(PayMethodSubDao, orderID),
+			BindOrder:  orderID,
+		})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	oid := sub.ID.Hex()
+	e := ds.UpdateSubscribeDAOTxID(oid, txID)
+	if e != nil {
+		logrus.Errorf("ds.UpdateSubscribeDAOTxID order_id:%s tx_id:%s err:%s", oid, txID, e)
+	}
+	setPayOrderTxID(ctx, PayMethodSubDao, oid, txID)
+	return nil
+}
+
 type PostUnpinPayload struct {
-	Id primitive.ObjectID
+	Id    primitive.ObjectID
+	Scope model.PinScope
+	// ExpiredOn the expiry of the pin, the task of a pin since replaced does nothing
+	ExpiredOn int64
 }
 
 const PostUnpin = "post:unpin"
 
-func NewPostUnpinTask(postId primitive.ObjectID) *asynq.Task {
-	payload, _ := json.Marshal(PostUnpinPayload{Id: postId})
+func NewPostUnpinTask(postId primitive.ObjectID, scope model.PinScope, expiredOn int64) *asynq.Task {
+	payload, _ := json.Marshal(PostUnpinPayload{Id: postId, Scope: scope, ExpiredOn: expiredOn})
 
 	return asynq.NewTask(PostUnpin, payload)
 }
@@ -134,11 +294,13 @@ func HandlePostUnpinTask(ctx context.Context, t *asynq.Task) (err error) {
 		return err
 	}
 
-	if post.IsTop == 0 {
+	// the tasks queued before the expiry was kept carry none
+	pinned, expiredOn := post.Pinned(p.Scope)
+	if !pinned || (p.ExpiredOn > 0 && expiredOn != p.ExpiredOn) {
 		return nil
 	}
 
-	err = ds.StickPost(post)
+	err = ds.PinPost(post, p.Scope, false, 0)
 	if err != nil {
 		return err
 	}
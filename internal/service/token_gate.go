@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/errcode"
+	"favor-dao-backend/pkg/tokengate"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+)
+
+// tokenGateTimeout bound each read of the node, a gated page must not hang on it
+var tokenGateTimeout = 5 * time.Second
+
+// checkTokenGate the gate is well-formed and a token answers at the contract, the gate is
+// normalized in place
+func checkTokenGate(g *model.TokenGate) *errcode.Error {
+	if !g.Valid() {
+		return errcode.InvalidTokenGate
+	}
+	contract := common.HexToAddress(g.Contract)
+	threshold, _ := g.MinBalance()
+	g.Contract = contract.Hex()
+	g.Threshold = threshold.String()
+
+	ctx, cancel := context.WithTimeout(context.Background(), tokenGateTimeout)
+	defer cancel()
+	_, err := gate.BalanceOf(ctx, contract, common.Address{})
+	if errors.Is(err, tokengate.ErrNoContract) {
+		return errcode.InvalidTokenGate.WithDetails(err.Error())
+	}
+	if err != nil && ctx.Err() != nil {
+		logrus.Warnf("tokengate.BalanceOf contract:%s timed out after %s", g.Contract, tokenGateTimeout)
+		return errcode.TokenGateTimeout.WithDetails(g.Contract)
+	}
+	if err != nil {
+		// the node is out of reach, the gate stays closed until it is back
+		logrus.Warnf("tokengate.BalanceOf contract:%s err:%s", g.Contract, err)
+	}
+	return nil
+}
+
+// CheckTokenGate the address holds the token of the gate, a failed read counts as not holding
+func CheckTokenGate(address string, g *model.TokenGate) bool {
+	if g == nil {
+		return true
+	}
+	if address == "" {
+		return false
+	}
+	threshold, ok := g.MinBalance()
+	if !ok {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tokenGateTimeout)
+	defer cancel()
+	holds, err := gate.Holds(ctx, g.Contract, address, threshold)
+	if err != nil {
+		if ctx.Err() != nil {
+			err = fmt.Errorf("timed out after %s", tokenGateTimeout)
+		}
+		logrus.Errorf("tokengate.Holds contract:%s address:%s err:%s", g.Contract, address, err)
+		return false
+	}
+	return holds
+}
+
+// postGates the gates of the post itself, the DAO it is in and the DAO of the original of a repost
+func postGates(post *model.PostFormatted) []*model.TokenGate {
+	gates := make([]*model.TokenGate, 0, 3)
+	if post.Gate != nil {
+		gates = append(gates, post.Gate)
+	}
+	if post.Dao != nil && post.Dao.Gate != nil {
+		gates = append(gates, post.Dao.Gate)
+	}
+	if post.AuthorDao != nil && post.AuthorDao.Gate != nil {
+		gates = append(gates, post.AuthorDao.Gate)
+	}
+	return gates
+}
+
+func tokenGateOwner(viewer string, post *model.PostFormatted) bool {
+	if viewer == "" {
+		return false
+	}
+	if viewer == post.Address || viewer == post.AuthorId {
+		return true
+	}
+	return post.Dao != nil && post.Dao.Address == viewer
+}
+
+// filterTokenGated blank the contents of the gated posts the viewer does not hold the tokens
+// for, the titles stay as the teaser
+func filterTokenGated(viewer string, posts ...*model.PostFormatted) {
+	held := make(map[model.TokenGate]bool)
+	for _, post := range posts {
+		if post == nil || tokenGateOwner(viewer, post) {
+			continue
+		}
+		for _, g := range postGates(post) {
+			ok, checked := held[*g]
+			if !checked {
+				ok = CheckTokenGate(viewer, g)
+				held[*g] = ok
+			}
+			if !ok {
+				lockPostContents(post)
+				break
+			}
+		}
+	}
+}
+
+// postUnlocked the viewer passes the gates of the post
+func postUnlocked(viewer string, post *model.Post) bool {
+	formatted := post.Format()
+	if dao, err := ds.GetDao(&model.Dao{ID: post.DaoId}); err == nil {
+		formatted.Dao = dao.Format()
+	}
+	if !post.AuthorDaoId.IsZero() {
+		if dao, err := ds.GetDao(&model.Dao{ID: post.AuthorDaoId}); err == nil {
+			formatted.AuthorDao = dao.Format()
+		}
+	}
+	filterTokenGated(viewer, formatted)
+	return !formatted.Locked
+}
+
+func lockPostContents(post *model.PostFormatted) {
+	post.Locked = true
+	for _, contents := range [][]*model.PostContentFormatted{post.Contents, post.OrigContents} {
+		for _, content := range contents {
+			if content.Type == model.CONTENT_TYPE_TITLE {
+				continue
+			}
+			content.Content = ""
+			content.Poll = nil
+		}
+	}
+}
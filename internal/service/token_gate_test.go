@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"favor-dao-backend/internal/model"
+	"favor-dao-backend/pkg/errcode"
+	"favor-dao-backend/pkg/tokengate"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+func TestFilterTokenGated(t *testing.T) {
+	token := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	holder := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	author := "0x0000000000000000000000000000000000000009"
+
+	// answers balanceOf(holder) with the storage slot of the holder
+	code := common.FromHex("0x6004355460005260206000f3")
+	b := backends.NewSimulatedBackend(core.GenesisAlloc{
+		token: {
+			Balance: big.NewInt(0),
+			Code:    code,
+			Storage: map[common.Hash]common.Hash{common.BytesToHash(holder.Bytes()): common.BigToHash(big.NewInt(10))},
+		},
+	}, 8000000)
+	defer b.Close()
+	saved := gate
+	gate = tokengate.New(b, nil, 0)
+	defer func() { gate = saved }()
+
+	newPost := func(g *model.TokenGate) *model.PostFormatted {
+		return &model.PostFormatted{
+			Address: author,
+			Gate:    g,
+			Contents: []*model.PostContentFormatted{
+				{Type: model.CONTENT_TYPE_TITLE, Content: "title"},
+				{Type: model.CONTENT_TYPE_TEXT, Content: "text"},
+			},
+		}
+	}
+	g := &model.TokenGate{Contract: token.Hex(), Standard: model.TokenERC20, Threshold: "5"}
+
+	tests := []struct {
+		name   string
+		viewer string
+		gate   *model.TokenGate
+		locked bool
+	}{
+		{"no gate", "", nil, false},
+		{"anonymous", "", g, true},
+		{"author", author, g, false},
+		{"holder", holder.Hex(), g, false},
+		{"poor", "0x0000000000000000000000000000000000000002", g, true},
+		{"above the balance", holder.Hex(), &model.TokenGate{Contract: token.Hex(), Standard: model.TokenERC20, Threshold: "11"}, true},
+	}
+	for _, tt := range tests {
+		post := newPost(tt.gate)
+		filterTokenGated(tt.viewer, post)
+		if post.Locked != tt.locked {
+			t.Errorf("%s: locked %v, want %v", tt.name, post.Locked, tt.locked)
+		}
+		if post.Contents[0].Content != "title" {
+			t.Errorf("%s: the title must stay", tt.name)
+		}
+		if (post.Contents[1].Content == "") != tt.locked {
+			t.Errorf("%s: text %q", tt.name, post.Contents[1].Content)
+		}
+	}
+}
+
+// stuckNode a node which never answers
+type stuckNode struct{}
+
+func (stuckNode) CodeAt(ctx context.Context, _ common.Address, _ *big.Int) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (stuckNode) CallContract(ctx context.Context, _ ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestTokenGateTimeout(t *testing.T) {
+	saved, savedTimeout := gate, tokenGateTimeout
+	gate, tokenGateTimeout = tokengate.New(stuckNode{}, nil, 0), 50*time.Millisecond
+	defer func() { gate, tokenGateTimeout = saved, savedTimeout }()
+
+	g := &model.TokenGate{Contract: "0x00000000000000000000000000000000000000aa", Standard: model.TokenERC20, Threshold: "5"}
+	if e := checkTokenGate(g); e == nil || e.Code() != errcode.TokenGateTimeout.Code() {
+		t.Errorf("checkTokenGate on a stuck node: %v, want %v", e, errcode.TokenGateTimeout)
+	}
+
+	post := &model.PostFormatted{
+		Address:  "0x0000000000000000000000000000000000000009",
+		Gate:     g,
+		Contents: []*model.PostContentFormatted{{Type: model.CONTENT_TYPE_TEXT, Content: "text"}},
+	}
+	done := make(chan struct{})
+	go func() {
+		filterTokenGated("0x0000000000000000000000000000000000000001", post)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("filterTokenGated hangs on a stuck node")
+	}
+	if !post.Locked {
+		t.Error("the gate must stay closed when the node does not answer")
+	}
+}
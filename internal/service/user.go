@@ -149,8 +149,6 @@ func DeleteUser(ctx context.Context, param *AuthByWalletRequest) error {
 		return err
 	}
 
-	uid := userId(user.Address)
-
 	guessMessage := fmt.Sprintf("delete %s account at %d", param.WalletAddr, param.Timestamp)
 	ok, err := VerifySignMessage(ctx, param, guessMessage)
 	if err != nil {
@@ -162,22 +160,15 @@ func DeleteUser(ctx context.Context, param *AuthByWalletRequest) error {
 	}
 
 	// delete auth token
-	tokens, err := chat.Scoped().Context(ctx).Users().AuthToken(uid).List()
+	tokens, err := chat.RevokeAuthTokens(ctx, user.Address)
 	if err != nil {
 		return err
 	}
 
-	if len(tokens) > 0 {
-		for _, token := range tokens {
-			_, err = chat.Scoped().Context(ctx).Users().AuthToken(uid).Delete(token.AuthToken)
-			if err != nil {
-				return err
-			}
-
-			err = conf.Redis.Del(ctx, fmt.Sprintf("token_%s", token.AuthToken)).Err()
-			if err != nil {
-				return err
-			}
+	for _, token := range tokens {
+		err = conf.Redis.Del(ctx, fmt.Sprintf("token_%s", token)).Err()
+		if err != nil {
+			return err
 		}
 	}
 
@@ -257,6 +248,7 @@ func GetUserCollections(userAddress string, offset, limit int) ([]*model.PostFor
 	if err != nil {
 		return nil, 0, err
 	}
+	filterTokenGated(userAddress, postsFormatted...)
 
 	return postsFormatted, totalRows, nil
 }
@@ -279,6 +271,7 @@ func GetUserStars(userAddress string, offset, limit int) ([]*model.PostFormatted
 	if err != nil {
 		return nil, 0, err
 	}
+	filterTokenGated(userAddress, postsFormatted...)
 
 	return postsFormatted, totalRows, nil
 }
@@ -53,7 +53,7 @@ func (e *Error) StatusCode() int {
 	switch e.Code() {
 	case Success.Code():
 		return http.StatusOK
-	case NotFound.code, NoExistDao.code:
+	case NotFound.code, NoExistDao.code, NoExistConversation.code, NoDaoTransfer.code, NoExistDaoJoinRequest.code, NoPendingComplaint.code, NoExistOrgan.code, NoExistBlacklist.code, NoExistSanction.code, NoExistPoll.code:
 		return http.StatusNotFound
 	case ServerError.Code():
 		return http.StatusInternalServerError
@@ -73,6 +73,10 @@ func (e *Error) StatusCode() int {
 		return http.StatusTooManyRequests
 	case WaitForDelete.Code():
 		return http.StatusUnauthorized
+	case PayNotifyTimeout.Code():
+		return http.StatusAccepted
+	case PayNotifySign.Code(), PayNotifyExpired.Code(), PayNotifyReplay.Code(), NotConversationMember.Code(), DaoMemberBanned.Code(), DaoArchived.Code(), UserSuspended.Code():
+		return http.StatusForbidden
 	}
 
 	return http.StatusInternalServerError
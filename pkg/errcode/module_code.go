@@ -15,6 +15,17 @@ var (
 	GetPostTagsFailed = NewError(30006, "Get Post Tags Failed")
 	VisiblePostFailed = NewError(30012, "Visible Post Failed")
 	UserHasRetweeted  = NewError(30015, "User has retweeted")
+	EditPostFailed    = NewError(30016, "Edit Post Failed")
+	PostNotEditable   = NewError(30017, "The post can not be edited")
+	PublishPostFailed = NewError(30018, "Publish Post Failed")
+	PostNotDraft      = NewError(30019, "The post is not a draft")
+	NoExistPoll       = NewError(30020, "Poll not found")
+	PollClosed        = NewError(30021, "The poll is closed")
+	PollVoted         = NewError(30022, "Already voted on the poll")
+	PollMembersOnly   = NewError(30023, "Only the DAO subscribers vote on the poll")
+	VotePollFailed    = NewError(30024, "Vote Poll Failed")
+	InvalidTokenGate  = NewError(30025, "Invalid token gate")
+	TokenGateTimeout  = NewError(30026, "The token contract did not answer in time")
 
 	GetCommentsFailed   = NewError(40001, "Get Comments Failed")
 	CreateCommentFailed = NewError(40002, "Create Comment Failed")
@@ -42,8 +53,37 @@ var (
 	AlreadySubscribedDAO  = NewError(80008, "Already Subscribed DAO")
 	CreateChatGroupFailed = NewError(80009, "Create Chat Group Failed")
 	UpdateChatGroupFailed = NewError(80010, "Update Chat Group Failed")
+	NoExistDaoTier        = NewError(80011, "DAO subscription tier not found")
+	InvalidDaoTiers       = NewError(80012, "Invalid DAO subscription tiers")
+	SubscribeRefundFailed = NewError(80013, "Refund subscription Failed")
+	SubscribeNoRefund     = NewError(80014, "Subscription can not be refunded")
+	SubscribeRefundExpire = NewError(80015, "Subscription refund window passed")
+	InvalidDaoRole        = NewError(80016, "Invalid DAO role")
+	SetDaoRoleFailed      = NewError(80017, "Failed to set the DAO role")
+	InvalidDaoGrants      = NewError(80018, "Invalid DAO role grants")
+	InvalidDaoTransfer    = NewError(80019, "Invalid DAO transfer")
+	DaoTransferPending    = NewError(80020, "A DAO transfer is pending already")
+	NoDaoTransfer         = NewError(80021, "No pending DAO transfer")
+	DaoTransferFailed     = NewError(80022, "DAO transfer Failed")
+	DaoJoinNeedApproval   = NewError(80023, "The private DAO is joined by a request or an invite code")
+	DaoJoinFailed         = NewError(80024, "Join DAO Failed")
+	InvalidDaoInvite      = NewError(80025, "Invalid or expired invite code")
+	NoExistDaoJoinRequest = NewError(80026, "DAO join request not found")
+	DaoMemberBanned       = NewError(80027, "Banned from the DAO")
+	KickDaoMemberFailed   = NewError(80028, "Failed to kick the DAO member")
+	DaoArchived           = NewError(80029, "The DAO is archived")
+	ArchiveDaoFailed      = NewError(80030, "Archive DAO Failed")
+	DeleteDaoFailed       = NewError(80031, "Delete DAO Failed")
+	DaoRestoreExpired     = NewError(80032, "The DAO can no longer be restored")
+	InvalidDaoHomePage    = NewError(80033, "Invalid DAO home page")
+	DaoPinLimit           = NewError(80034, "The DAO pinned too many posts")
+	NoExistDaoMember      = NewError(80035, "Not a member of the DAO")
 
-	PayNotifyError = NewError(90001, "Pay notify Failed")
+	PayNotifyError   = NewError(90001, "Pay notify Failed")
+	PayNotifyTimeout = NewError(90002, "Payment is being confirmed, please check later")
+	PayNotifySign    = NewError(90003, "Invalid pay notify signature")
+	PayNotifyExpired = NewError(90004, "Pay notify expired")
+	PayNotifyReplay  = NewError(90005, "Pay notify replayed")
 
 	MsgListFailed           = NewError(100001, "Failed to get the message list")
 	MsgCountFailed          = NewError(100002, "Failed to get the messages count")
@@ -61,6 +101,24 @@ var (
 	DeleteMsgReadFailed     = NewError(100014, "Delete message read failure")
 	MsgSysListFailed        = NewError(100015, "System message fetch failure")
 	MsgSysCountFailed       = NewError(100016, "Failed to get system message count")
+	MuteNotifyFailed        = NewError(100017, "Failed to mute the notifications")
+	UnmuteNotifyFailed      = NewError(100018, "Failed to unmute the notifications")
+	GetNotifyMutesFailed    = NewError(100019, "Failed to get the muted notifications")
 
-	GetOrganFailed = NewError(110001, "Get organizational failure")
+	GetOrganFailed      = NewError(110001, "Get organizational failure")
+	NoExistOrgan        = NewError(110002, "Organization not found")
+	OrganKeyDuplication = NewError(110003, "Organization key duplication")
+
+	NoExistConversation   = NewError(120001, "Conversation not found")
+	NotConversationMember = NewError(120002, "Not a member of the conversation")
+	SendChatMessageFailed = NewError(120003, "Send chat message failed")
+	GetChatMessagesFailed = NewError(120004, "Get chat messages failed")
+
+	NoPendingComplaint     = NewError(130001, "No pending complaint of the post")
+	InvalidComplaintAction = NewError(130002, "Invalid complaint action")
+	ResolveComplaintFailed = NewError(130003, "Failed to resolve the complaints")
+	UserSuspended          = NewError(130004, "The account is suspended")
+	NoExistBlacklist       = NewError(130005, "Blacklist entry not found")
+	SearchReindexRunning   = NewError(130006, "The search reindex is already running")
+	NoExistSanction        = NewError(130007, "Sanction not found")
 )
@@ -0,0 +1,151 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// Hub fan out events to every node through a redis channel, each node delivers
+// them to all of its local subscribers of the key. Unlike psub a key may have
+// any number of subscribers, e.g. one per connected device.
+type Hub struct {
+	rdb     *redis.Client
+	channel string
+
+	mu   sync.RWMutex
+	subs map[string]map[*Subscription]struct{}
+}
+
+type Subscription struct {
+	hub  *Hub
+	keys map[string]struct{}
+	mu   sync.Mutex
+	// C receives the payloads, events are dropped when the subscriber falls behind
+	C chan []byte
+}
+
+type event struct {
+	Key     string          `json:"key"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func New(rdb *redis.Client, channel string) *Hub {
+	h := newHub(rdb, channel)
+	go h.run(context.Background())
+	return h
+}
+
+func newHub(rdb *redis.Client, channel string) *Hub {
+	return &Hub{
+		rdb:     rdb,
+		channel: channel,
+		subs:    make(map[string]map[*Subscription]struct{}),
+	}
+}
+
+func (h *Hub) run(ctx context.Context) {
+	sub := h.rdb.Subscribe(ctx, h.channel)
+	defer sub.Close()
+
+	// the channel reconnects by itself when the connection is lost
+	for msg := range sub.Channel() {
+		var e event
+		if err := json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+			logrus.Warnf("hub: invalid event on %s: %s", h.channel, err)
+			continue
+		}
+		h.deliver(e.Key, e.Payload)
+	}
+}
+
+func (h *Hub) deliver(key string, payload []byte) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for s := range h.subs[key] {
+		select {
+		case s.C <- payload:
+		default:
+			logrus.Warnf("hub: drop event of %s on %s, subscriber is full", key, h.channel)
+		}
+	}
+}
+
+// Publish the payload, which must be valid JSON, to the subscribers of key on all nodes.
+func (h *Hub) Publish(ctx context.Context, key string, payload []byte) error {
+	data, err := json.Marshal(event{Key: key, Payload: payload})
+	if err != nil {
+		return err
+	}
+	return h.rdb.Publish(ctx, h.channel, data).Err()
+}
+
+// PublishJSON marshal v and publish it.
+func (h *Hub) PublishJSON(ctx context.Context, key string, v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return h.Publish(ctx, key, payload)
+}
+
+// Subscribe create a subscription of the keys, more keys can be added later.
+func (h *Hub) Subscribe(size int, keys ...string) *Subscription {
+	s := &Subscription{
+		hub:  h,
+		keys: make(map[string]struct{}),
+		C:    make(chan []byte, size),
+	}
+	s.Add(keys...)
+	return s
+}
+
+func (s *Subscription) Add(keys ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	for _, key := range keys {
+		s.keys[key] = struct{}{}
+		m, ok := s.hub.subs[key]
+		if !ok {
+			m = make(map[*Subscription]struct{})
+			s.hub.subs[key] = m
+		}
+		m[s] = struct{}{}
+	}
+}
+
+func (s *Subscription) Remove(keys ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	for _, key := range keys {
+		s.remove(key)
+	}
+}
+
+// remove must be called with both locks held
+func (s *Subscription) remove(key string) {
+	delete(s.keys, key)
+	m := s.hub.subs[key]
+	delete(m, s)
+	if len(m) == 0 {
+		delete(s.hub.subs, key)
+	}
+}
+
+// Close remove all keys, C is not closed so a late delivery never panics.
+func (s *Subscription) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	for key := range s.keys {
+		s.remove(key)
+	}
+}
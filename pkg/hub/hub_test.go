@@ -0,0 +1,47 @@
+package hub
+
+import (
+	"testing"
+)
+
+func TestHubDeliver(t *testing.T) {
+	h := newHub(nil, "test")
+	phone := h.Subscribe(1, "user:a")
+	desktop := h.Subscribe(1, "user:a", "room:1")
+	other := h.Subscribe(1, "user:b")
+
+	h.deliver("user:a", []byte(`1`))
+	for _, s := range []*Subscription{phone, desktop} {
+		select {
+		case got := <-s.C:
+			if string(got) != "1" {
+				t.Errorf("want 1 got %s", got)
+			}
+		default:
+			t.Fatal("every subscriber of the key must receive the event")
+		}
+	}
+	if len(other.C) != 0 {
+		t.Error("subscriber of another key must not receive the event")
+	}
+
+	// full subscriber drops instead of blocking
+	h.deliver("room:1", []byte(`2`))
+	h.deliver("room:1", []byte(`3`))
+	if got := <-desktop.C; string(got) != "2" {
+		t.Errorf("want 2 got %s", got)
+	}
+
+	desktop.Remove("room:1")
+	h.deliver("room:1", []byte(`4`))
+	if len(desktop.C) != 0 {
+		t.Error("removed key must not be delivered")
+	}
+
+	phone.Close()
+	desktop.Close()
+	other.Close()
+	if len(h.subs) != 0 {
+		t.Errorf("want no keys left got %d", len(h.subs))
+	}
+}
@@ -12,6 +12,15 @@ import (
 	"favor-dao-backend/pkg/json"
 )
 
+// Service delivers the notifications, they are persisted when IsSave is set.
+type Service interface {
+	Notify(ctx context.Context, notify PushNotifyRequest) error
+	NotifyDao(ctx context.Context, notify PushNotifyRequest) error
+	NotifySys(ctx context.Context, notify PushNotifySysRequest) error
+}
+
+var _ Service = (*Gateway)(nil)
+
 type Gateway struct {
 	baseUrl string
 	client  *http.Client
@@ -0,0 +1,182 @@
+package opengraph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// maxBodySize the metadata is in the head, the rest of the page is not read
+const maxBodySize = 1 << 20
+
+var (
+	ErrNotHTML       = errors.New("opengraph: not an html page")
+	ErrPrivateTarget = errors.New("opengraph: the address is not public")
+)
+
+// NewClient the client which only connects to public addresses, the pages are given by the users
+// and must not reach the internal network.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublic(ip) {
+				return ErrPrivateTarget
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+		},
+	}
+}
+
+func isPublic(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// Meta the OpenGraph metadata of a page, the title and the description fall back
+// to the <title> and <meta name="description"> of the page.
+type Meta struct {
+	Title       string
+	Description string
+	Image       string
+	SiteName    string
+}
+
+// Fetch get the page and parse its metadata
+func Fetch(ctx context.Context, client *http.Client, pageURL string) (*Meta, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("opengraph: %s responds %d", pageURL, resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" && !strings.Contains(ct, "html") {
+		return nil, ErrNotHTML
+	}
+	meta, err := Parse(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, err
+	}
+	meta.Image = resolve(resp.Request.URL, meta.Image)
+	return meta, nil
+}
+
+// Parse read the metadata from the html until the end of its head
+func Parse(r io.Reader) (*Meta, error) {
+	var (
+		meta        Meta
+		title       string
+		description string
+		inTitle     bool
+	)
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if errors.Is(z.Err(), io.EOF) {
+				return meta.fallback(title, description), nil
+			}
+			return nil, z.Err()
+		case html.StartTagToken, html.SelfClosingTagToken:
+			t := z.Token()
+			switch t.Data {
+			case "title":
+				inTitle = true
+			case "meta":
+				key, content := metaAttrs(t)
+				switch key {
+				case "og:title":
+					meta.Title = content
+				case "og:description":
+					meta.Description = content
+				case "og:image", "og:image:url":
+					if meta.Image == "" {
+						meta.Image = content
+					}
+				case "og:site_name":
+					meta.SiteName = content
+				case "description":
+					description = content
+				}
+			case "body":
+				return meta.fallback(title, description), nil
+			}
+		case html.TextToken:
+			if inTitle {
+				title += string(z.Text())
+			}
+		case html.EndTagToken:
+			t := z.Token()
+			switch t.Data {
+			case "title":
+				inTitle = false
+			case "head":
+				return meta.fallback(title, description), nil
+			}
+		}
+	}
+}
+
+func (m *Meta) fallback(title, description string) *Meta {
+	if m.Title == "" {
+		m.Title = strings.TrimSpace(title)
+	}
+	if m.Description == "" {
+		m.Description = description
+	}
+	return m
+}
+
+func metaAttrs(t html.Token) (key, content string) {
+	for _, a := range t.Attr {
+		switch strings.ToLower(a.Key) {
+		case "property", "name":
+			if key == "" {
+				key = strings.ToLower(a.Val)
+			}
+		case "content":
+			content = strings.TrimSpace(a.Val)
+		}
+	}
+	return
+}
+
+// resolve the relative image against the page
+func resolve(base *url.URL, ref string) string {
+	if ref == "" || base == nil {
+		return ref
+	}
+	u, err := base.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return u.String()
+}
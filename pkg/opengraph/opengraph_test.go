@@ -0,0 +1,95 @@
+package opengraph
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want Meta
+	}{
+		{
+			name: "opengraph",
+			page: `<html><head>
+<title>Page title</title>
+<meta property="og:title" content="OG title">
+<meta property="og:description" content=" OG description ">
+<meta property="og:image" content="https://example.com/a.png">
+<meta property="og:image" content="https://example.com/b.png">
+<meta property="og:site_name" content="Example">
+</head><body><meta property="og:title" content="ignored"></body></html>`,
+			want: Meta{Title: "OG title", Description: "OG description", Image: "https://example.com/a.png", SiteName: "Example"},
+		},
+		{
+			name: "fallback",
+			page: `<html><head><title> Page title </title><meta name="description" content="Page description"/></head></html>`,
+			want: Meta{Title: "Page title", Description: "Page description"},
+		},
+		{
+			name: "empty",
+			page: ``,
+			want: Meta{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.page))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(`<head><meta property="og:image" content="/img/logo.png"></head>`))
+		case "/file":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Write([]byte(`%PDF`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	meta, err := Fetch(context.Background(), srv.Client(), srv.URL+"/page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.Image != srv.URL+"/img/logo.png" {
+		t.Errorf("relative image must be resolved against the page, got %s", meta.Image)
+	}
+	if _, err = Fetch(context.Background(), srv.Client(), srv.URL+"/file"); err != ErrNotHTML {
+		t.Errorf("want ErrNotHTML got %v", err)
+	}
+	if _, err = Fetch(context.Background(), srv.Client(), srv.URL+"/missing"); err == nil {
+		t.Error("want error for 404")
+	}
+}
+
+func TestNewClientRefusesPrivate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<head></head>`))
+	}))
+	defer srv.Close()
+
+	_, err := Fetch(context.Background(), NewClient(time.Second), srv.URL)
+	if !errors.Is(err, ErrPrivateTarget) {
+		t.Errorf("want ErrPrivateTarget got %v", err)
+	}
+}
@@ -0,0 +1,309 @@
+package pointSystem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TxDelayed is a fake status, the trade stays in progress and no callback
+// is sent until Settle is called. Use it to simulate a lost pay notify.
+const TxDelayed = "delayed"
+
+var ErrInsufficientBalance = errors.New("insufficient balance")
+
+type FakeOptions struct {
+	// Status of the trades: completed, rollback, cancelled or delayed. default completed
+	Status string
+	// Channels overwrite Status for the trades of the channel
+	Channels map[string]string
+	// Delay before the trade settles and the callback is sent
+	Delay time.Duration
+	// InitBalance of an account seen for the first time
+	InitBalance string
+	// Secret to sign the callbacks
+	Secret string
+	// Notify send the callback, default a GET request to the uri
+	Notify func(ctx context.Context, uri string) error
+}
+
+// Fake is an in-process point system. It keeps balances in memory and
+// calls back the return uri of each pay like the real gateway.
+type Fake struct {
+	opts FakeOptions
+
+	mu          sync.Mutex
+	seq         int64
+	balances    map[string]*big.Int
+	frozen      map[string]*big.Int
+	trades      map[string]*Trade
+	returnURIs  map[string]string
+	idempotency map[string]string
+	wg          sync.WaitGroup
+}
+
+var _ Service = (*Fake)(nil)
+
+func NewFake(opts FakeOptions) *Fake {
+	if opts.Status == "" {
+		opts.Status = TxCompleted
+	}
+	if opts.Notify == nil {
+		opts.Notify = httpNotify
+	}
+	return &Fake{
+		opts:        opts,
+		balances:    make(map[string]*big.Int),
+		frozen:      make(map[string]*big.Int),
+		trades:      make(map[string]*Trade),
+		returnURIs:  make(map[string]string),
+		idempotency: make(map[string]string),
+	}
+}
+
+func httpNotify(ctx context.Context, uri string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("notify %s: %s", uri, resp.Status)
+	}
+	return nil
+}
+
+// SetStatus change the status of the next trades of the channel, all channels when channel is empty.
+func (s *Fake) SetStatus(channel, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if channel == "" {
+		s.opts.Status = status
+		return
+	}
+	if s.opts.Channels == nil {
+		s.opts.Channels = make(map[string]string)
+	}
+	s.opts.Channels[channel] = status
+}
+
+// Deposit add amount to the balance of the address.
+func (s *Fake) Deposit(address, amount string) error {
+	v, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return fmt.Errorf("invalid amount %s", amount)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.account(address).Add(s.account(address), v)
+	return nil
+}
+
+// Balance of the address, frozen amount excluded.
+func (s *Fake) Balance(address string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.account(address).String()
+}
+
+// Wait until all callbacks have been sent, delayed trades excluded.
+func (s *Fake) Wait() {
+	s.wg.Wait()
+}
+
+// account must be called with the lock held
+func (s *Fake) account(address string) *big.Int {
+	v, ok := s.balances[address]
+	if !ok {
+		v, _ = new(big.Int).SetString(s.opts.InitBalance, 10)
+		if v == nil {
+			v = new(big.Int)
+		}
+		s.balances[address] = v
+		s.frozen[address] = new(big.Int)
+	}
+	return v
+}
+
+func (s *Fake) Pay(ctx context.Context, param PayRequest) (txID string, err error) {
+	if param.BindOrder == "" {
+		return "", ErrNoBindOrder
+	}
+	amount, ok := new(big.Int).SetString(param.Amount, 10)
+	if !ok || amount.Sign() < 0 {
+		return "", fmt.Errorf("invalid amount %s", param.Amount)
+	}
+
+	s.mu.Lock()
+	key := IdempotencyKey(param)
+	if txID, ok = s.idempotency[key]; ok {
+		s.mu.Unlock()
+		return txID, nil
+	}
+	balance := s.account(param.FromObject)
+	if balance.Cmp(amount) < 0 {
+		s.mu.Unlock()
+		return "", ErrInsufficientBalance
+	}
+	balance.Sub(balance, amount)
+	s.frozen[param.FromObject].Add(s.frozen[param.FromObject], amount)
+
+	s.seq++
+	txID = fmt.Sprintf("fake-%d-%d", time.Now().Unix(), s.seq)
+	s.trades[txID] = &Trade{
+		Id:         txID,
+		FromObject: param.FromObject,
+		ToSubject:  param.ToSubject,
+		Amount:     param.Amount,
+		Channel:    param.Channel,
+		BindOrder:  param.BindOrder,
+		Status:     TxInProgress,
+		CreatedAt:  time.Now().UnixNano(),
+	}
+	s.returnURIs[txID] = param.ReturnURI
+	s.idempotency[key] = txID
+	status := s.opts.Status
+	if v, ok := s.opts.Channels[param.Channel]; ok {
+		status = v
+	}
+	s.mu.Unlock()
+
+	if status == TxDelayed {
+		return txID, nil
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		time.Sleep(s.opts.Delay)
+		_ = s.Settle(context.Background(), txID, status)
+	}()
+	return txID, nil
+}
+
+// Settle finish the trade with status and send the callback.
+func (s *Fake) Settle(ctx context.Context, txID, status string) error {
+	s.mu.Lock()
+	trade, ok := s.trades[txID]
+	if !ok {
+		s.mu.Unlock()
+		return ErrTradeNotFound
+	}
+	if trade.Status != TxInProgress {
+		s.mu.Unlock()
+		return fmt.Errorf("trade %s already %s", txID, trade.Status)
+	}
+	amount, _ := new(big.Int).SetString(trade.Amount, 10)
+	frozen := s.frozen[trade.FromObject]
+	frozen.Sub(frozen, amount)
+	switch status {
+	case TxCompleted:
+		to := s.account(trade.ToSubject)
+		to.Add(to, amount)
+	case TxRollback, TxCancelled:
+		from := s.account(trade.FromObject)
+		from.Add(from, amount)
+	default:
+		frozen.Add(frozen, amount)
+		s.mu.Unlock()
+		return fmt.Errorf("cannot settle trade with status %s", status)
+	}
+	trade.Status = status
+	returnURI := s.returnURIs[txID]
+	s.mu.Unlock()
+
+	if returnURI == "" {
+		return nil
+	}
+	u, err := url.Parse(returnURI)
+	if err != nil {
+		return err
+	}
+	query := u.Query()
+	query.Set("tx_id", txID)
+	query.Set("tx_status", status)
+	query.Set("tx_timestamp", strconv.FormatInt(time.Now().Unix(), 10))
+	SignQuery(query, s.opts.Secret, time.Now())
+	u.RawQuery = query.Encode()
+	return s.opts.Notify(ctx, u.String())
+}
+
+func (s *Fake) FindAccounts(_ context.Context, address string) (list []Account, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return []Account{{
+		Asset:   "FavT",
+		Balance: s.account(address).String(),
+		Frozen:  s.frozen[address].String(),
+	}}, nil
+}
+
+func (s *Fake) PayList(c *gin.Context) {
+	address, _ := c.Get("address")
+	ref, _ := address.(string)
+
+	s.mu.Lock()
+	list := make([]Trade, 0)
+	for _, trade := range s.trades {
+		if trade.FromObject == ref || trade.ToSubject == ref {
+			list = append(list, *trade)
+		}
+	}
+	s.mu.Unlock()
+	sortTrades(list)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "success",
+		"data": gin.H{
+			"list":  list,
+			"pager": Pager{Page: 1, PageSize: int64(len(list)), TotalRows: len(list)},
+		},
+	})
+}
+
+func (s *Fake) Transaction(_ context.Context, bindOrder string) ([]Trade, error) {
+	s.mu.Lock()
+	list := make([]Trade, 0)
+	for _, trade := range s.trades {
+		if trade.BindOrder == bindOrder {
+			list = append(list, *trade)
+		}
+	}
+	s.mu.Unlock()
+	sortTrades(list)
+	return list, nil
+}
+
+func (s *Fake) TradeInfo(_ context.Context, txId string) (*Trade, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	trade, ok := s.trades[txId]
+	if !ok {
+		return nil, ErrTradeNotFound
+	}
+	t := *trade
+	return &t, nil
+}
+
+func (s *Fake) FindTrade(ctx context.Context, channel, bindOrder, txId string) (*Trade, error) {
+	return findTrade(ctx, s, channel, bindOrder, txId)
+}
+
+func sortTrades(list []Trade) {
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].CreatedAt > list[j].CreatedAt
+	})
+}
@@ -0,0 +1,112 @@
+package pointSystem
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+type callbacks struct {
+	mu   sync.Mutex
+	list []url.Values
+}
+
+func (c *callbacks) notify(_ context.Context, uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.list = append(c.list, u.Query())
+	c.mu.Unlock()
+	return nil
+}
+
+func TestFakePay(t *testing.T) {
+	tests := []struct {
+		status   string
+		from, to string
+	}{
+		{TxCompleted, "900", "100"},
+		{TxRollback, "1000", "0"},
+		{TxCancelled, "1000", "0"},
+	}
+	for _, test := range tests {
+		cb := &callbacks{}
+		fake := NewFake(FakeOptions{Status: test.status, Notify: cb.notify})
+		_ = fake.Deposit("from", "1000")
+
+		txID, err := fake.Pay(context.Background(), PayRequest{
+			FromObject: "from",
+			ToSubject:  "to",
+			Amount:     "100",
+			Channel:    "sub_dao",
+			ReturnURI:  "http://localhost/v1/pay/notify?method=sub_dao&order_id=order",
+			BindOrder:  "order",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		fake.Wait()
+
+		if got := fake.Balance("from"); got != test.from {
+			t.Errorf("%s: from balance want %s got %s", test.status, test.from, got)
+		}
+		if got := fake.Balance("to"); got != test.to {
+			t.Errorf("%s: to balance want %s got %s", test.status, test.to, got)
+		}
+		if len(cb.list) != 1 {
+			t.Fatalf("%s: want 1 callback got %d", test.status, len(cb.list))
+		}
+		q := cb.list[0]
+		if q.Get("order_id") != "order" || q.Get("method") != "sub_dao" || q.Get("tx_id") != txID || q.Get("tx_status") != test.status {
+			t.Errorf("%s: unexpected callback %v", test.status, q)
+		}
+	}
+}
+
+func TestFakeDelayed(t *testing.T) {
+	cb := &callbacks{}
+	fake := NewFake(FakeOptions{InitBalance: "100", Notify: cb.notify})
+	fake.SetStatus("refund_redpacket", TxDelayed)
+
+	param := PayRequest{FromObject: "from", ToSubject: "to", Amount: "60", Channel: "refund_redpacket", BindOrder: "order", ReturnURI: "http://localhost/v1/pay/notify?method=refund_redpacket&order_id=order"}
+	txID, err := fake.Pay(context.Background(), param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake.Wait()
+	if len(cb.list) != 0 {
+		t.Fatalf("delayed trade must not call back")
+	}
+
+	// same bind order, same trade
+	again, err := fake.Pay(context.Background(), param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != txID {
+		t.Errorf("want idempotent tx %s got %s", txID, again)
+	}
+	if _, err = fake.Pay(context.Background(), PayRequest{FromObject: "from", ToSubject: "to", Amount: "60", Channel: "sub_dao", BindOrder: "other"}); err != ErrInsufficientBalance {
+		t.Errorf("want insufficient balance got %v", err)
+	}
+
+	trade, err := fake.FindTrade(context.Background(), "refund_redpacket", "order", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trade.Status != TxInProgress {
+		t.Errorf("want %s got %s", TxInProgress, trade.Status)
+	}
+	if err = fake.Settle(context.Background(), txID, TxCompleted); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.Balance("to"); got != "160" {
+		t.Errorf("to balance want 160 got %s", got)
+	}
+	if len(cb.list) != 1 {
+		t.Errorf("want 1 callback got %d", len(cb.list))
+	}
+}
@@ -1,5 +1,16 @@
 package pointSystem
 
+// Trade status
+const (
+	TxPending    = "pending"
+	TxInProgress = "in_progress"
+	TxCompleted  = "completed"
+	TxFailed     = "failed"
+	TxCancelled  = "cancelled"
+	TxRollback   = "rollback"
+	TxCrashed    = "crashed"
+)
+
 type BaseResponse struct {
 	Code    int      `json:"code"`
 	Msg     string   `json:"msg"`
@@ -32,3 +43,14 @@ type Account struct {
 	Balance string `json:"balance"`
 	Frozen  string `json:"frozen"`
 }
+
+type Trade struct {
+	Id         string `json:"id"`
+	FromObject string `json:"from_object"`
+	ToSubject  string `json:"to_subject"`
+	Amount     string `json:"amount"`
+	Channel    string `json:"channel"`
+	BindOrder  string `json:"bind_order"`
+	Status     string `json:"status"`
+	CreatedAt  int64  `json:"created_at"`
+}
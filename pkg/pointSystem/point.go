@@ -3,16 +3,54 @@ package pointSystem
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"favor-dao-backend/pkg/json"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// maxRetries bounded retry of a request on network errors
+	maxRetries   = 3
+	retryBackoff = 200 * time.Millisecond
+)
+
+var (
+	ErrTradeNotFound = errors.New("trade not found")
+	// ErrNoBindOrder a pay is retried, it is only safe with the idempotency key of its bind order
+	ErrNoBindOrder = errors.New("the bind order of the pay is required")
+)
+
+// errUnavailable the gateway answered but is temporarily unavailable, worth a retry
+type errUnavailable struct {
+	status string
+}
+
+func (e *errUnavailable) Error() string {
+	return "point gateway unavailable: " + e.status
+}
+
+// Service is the point system used to pay and look up trades.
+type Service interface {
+	Pay(ctx context.Context, param PayRequest) (txID string, err error)
+	FindAccounts(ctx context.Context, address string) (list []Account, err error)
+	PayList(c *gin.Context)
+	Transaction(ctx context.Context, bindOrder string) ([]Trade, error)
+	TradeInfo(ctx context.Context, txId string) (*Trade, error)
+	FindTrade(ctx context.Context, channel, bindOrder, txId string) (*Trade, error)
+}
+
+var _ Service = (*Gateway)(nil)
+
 type Gateway struct {
 	baseUrl string
 	client  *http.Client
@@ -26,21 +64,58 @@ func New(baseUrl string) *Gateway {
 }
 
 func (s *Gateway) request(ctx context.Context, method, url string, body, respData interface{}) error {
-	var reqBody io.Reader
+	return s.requestWithHeader(ctx, method, url, nil, body, respData)
+}
+
+func (s *Gateway) requestWithHeader(ctx context.Context, method, url string, header http.Header, body, respData interface{}) error {
+	var rawBody []byte
 	if body != nil {
-		rawBody, err := json.Marshal(body)
+		var err error
+		rawBody, err = json.Marshal(body)
 		if err != nil {
 			return err
 		}
+	}
+
+	var err error
+	for i := 0; ; i++ {
+		err = s.do(ctx, method, url, header, rawBody, respData)
+		if err == nil || i >= maxRetries || !retryable(ctx, err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(retryBackoff << i):
+		}
+	}
+}
+
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var unavailable *errUnavailable
+	if errors.As(err, &unavailable) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func (s *Gateway) do(ctx context.Context, method, url string, header http.Header, rawBody []byte, respData interface{}) error {
+	var reqBody io.Reader
+	if rawBody != nil {
 		reqBody = bytes.NewReader(rawBody)
-	} else {
-		reqBody = nil
 	}
 
 	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
 	if err != nil {
 		return err
 	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
 	req.Header.Set("accept", "application/json")
 	req.Header.Set("content-type", "application/json")
 	resp, err := s.client.Do(req)
@@ -53,31 +128,46 @@ func (s *Gateway) request(ctx context.Context, method, url string, body, respDat
 		}
 	}()
 
-	rawBody, err := io.ReadAll(resp.Body)
+	rawResp, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		var restErr BaseResponse
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return json.Unmarshal(rawResp, respData)
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return &errUnavailable{status: resp.Status}
+	}
+	var restErr BaseResponse
 
-		err = json.Unmarshal(rawBody, &restErr)
-		if err != nil {
-			return fmt.Errorf("%s,%s", resp.Status, err)
-		}
-		return fmt.Errorf("code:%d msg:%s details:%s", restErr.Code, restErr.Msg, restErr.Details)
+	err = json.Unmarshal(rawResp, &restErr)
+	if err != nil {
+		return fmt.Errorf("%s,%s", resp.Status, err)
 	}
-	return json.Unmarshal(rawBody, respData)
+	return fmt.Errorf("code:%d msg:%s details:%s", restErr.Code, restErr.Msg, restErr.Details)
+}
+
+// IdempotencyKey derived from the bind order, so a retried pay of the same order is only executed once.
+// The channel is part of the key because one order may bind several pays, e.g. send and refund a redpacket.
+func IdempotencyKey(param PayRequest) string {
+	sum := sha256.Sum256([]byte(param.Channel + ":" + param.BindOrder))
+	return hex.EncodeToString(sum[:])
 }
 
 func (s *Gateway) Pay(ctx context.Context, param PayRequest) (txID string, err error) {
+	if param.BindOrder == "" {
+		return "", ErrNoBindOrder
+	}
 	u := s.baseUrl + "/v1/pay"
 
 	var resp struct {
 		BaseResponse
 		Data PayResponse `json:"data,omitempty"`
 	}
-	err = s.request(ctx, http.MethodPost, u, param, &resp)
+	header := http.Header{}
+	header.Set("Idempotency-Key", IdempotencyKey(param))
+	err = s.requestWithHeader(ctx, http.MethodPost, u, header, param, &resp)
 	if err != nil {
 		return "", err
 	}
@@ -147,10 +237,68 @@ func (s *Gateway) PayList(c *gin.Context) {
 	io.Copy(c.Writer, resp.Body)
 }
 
-func (s *Gateway) Transaction() (txID string, err error) {
-	return
+// Transaction query the trades bound to the order, the latest first
+func (s *Gateway) Transaction(ctx context.Context, bindOrder string) ([]Trade, error) {
+	query := url.Values{}
+	query.Set("bind_order", bindOrder)
+	u := s.baseUrl + "/v1/pay/list?" + query.Encode()
+
+	var resp struct {
+		BaseResponse
+		Data struct {
+			List  []Trade `json:"list"`
+			Pager Pager   `json:"pager"`
+		} `json:"data,omitempty"`
+	}
+	err := s.request(ctx, http.MethodGet, u, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code != 0 {
+		return nil, errors.New(resp.Msg)
+	}
+	sortTrades(resp.Data.List)
+	return resp.Data.List, nil
 }
 
-func (s *Gateway) TradeInfo(txId string) {
+// TradeInfo query the trade by tx id
+func (s *Gateway) TradeInfo(ctx context.Context, txId string) (*Trade, error) {
+	u := s.baseUrl + "/v1/pay/" + url.PathEscape(txId)
 
+	var resp struct {
+		BaseResponse
+		Data *Trade `json:"data,omitempty"`
+	}
+	err := s.request(ctx, http.MethodGet, u, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code != 0 {
+		return nil, errors.New(resp.Msg)
+	}
+	if resp.Data == nil || resp.Data.Id == "" {
+		return nil, ErrTradeNotFound
+	}
+	return resp.Data, nil
+}
+
+// FindTrade query the trade of the order on the channel, by tx id when it is known
+func (s *Gateway) FindTrade(ctx context.Context, channel, bindOrder, txId string) (*Trade, error) {
+	return findTrade(ctx, s, channel, bindOrder, txId)
+}
+
+func findTrade(ctx context.Context, s Service, channel, bindOrder, txId string) (*Trade, error) {
+	if txId != "" {
+		return s.TradeInfo(ctx, txId)
+	}
+	list, err := s.Transaction(ctx, bindOrder)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		if list[i].Channel == channel {
+			return &list[i], nil
+		}
+	}
+	return nil, ErrTradeNotFound
 }
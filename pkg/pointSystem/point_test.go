@@ -0,0 +1,90 @@
+package pointSystem
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPayRetryWithIdempotencyKey(t *testing.T) {
+	param := PayRequest{
+		FromObject: "0x01",
+		ToSubject:  "0x02",
+		Amount:     "100",
+		Channel:    "sub_dao",
+		BindOrder:  "order",
+	}
+	var (
+		calls int
+		keys  []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":0,"msg":"ok","data":{"id":"tx1"}}`))
+	}))
+	defer srv.Close()
+
+	txID, err := New(srv.URL).Pay(context.Background(), param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txID != "tx1" {
+		t.Errorf("want tx1 got %s", txID)
+	}
+	if calls != 3 {
+		t.Errorf("want 3 calls got %d", calls)
+	}
+	want := IdempotencyKey(param)
+	for _, key := range keys {
+		if key != want {
+			t.Errorf("want idempotency key %s got %s", want, key)
+		}
+	}
+
+	refund := param
+	refund.Channel = "refund_sub_dao"
+	if IdempotencyKey(refund) == want {
+		t.Error("idempotency key must differ between channels")
+	}
+}
+
+func TestNoRetryOnBadRequest(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":1,"msg":"insufficient balance"}`))
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL).Pay(context.Background(), PayRequest{BindOrder: "order"})
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if calls != 1 {
+		t.Errorf("want 1 call got %d", calls)
+	}
+}
+
+func TestPayRequiresBindOrder(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL).Pay(context.Background(), PayRequest{Amount: "1"})
+	if !errors.Is(err, ErrNoBindOrder) {
+		t.Errorf("want ErrNoBindOrder got %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("want no call got %d", calls)
+	}
+}
@@ -0,0 +1,40 @@
+package pointSystem
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"favor-dao-backend/pkg/util"
+)
+
+// Sign the callback query with HMAC-SHA256. The raw string is the sorted
+// k=v pairs joined with &, the sign param itself excluded.
+func Sign(query url.Values, secret string) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		if k != "sign" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	raw := make([]string, 0, len(keys))
+	for _, k := range keys {
+		raw = append(raw, k+"="+query.Get(k))
+	}
+	return util.EncodeHmacSHA256(strings.Join(raw, "&"), secret)
+}
+
+// SignQuery set timestamp, nonce and sign of the callback query
+func SignQuery(query url.Values, secret string, now time.Time) {
+	nonce := make([]byte, 16)
+	_, _ = rand.Read(nonce)
+	query.Set("timestamp", strconv.FormatInt(now.Unix(), 10))
+	query.Set("nonce", hex.EncodeToString(nonce))
+	query.Set("sign", Sign(query, secret))
+}
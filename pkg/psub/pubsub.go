@@ -1,40 +1,82 @@
 package psub
 
 import (
+	"context"
 	"errors"
 	"sync"
+
+	"favor-dao-backend/pkg/json"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
 )
 
 var ErrKeyAlreadyExists = errors.New("key already exists")
 
+// Service fan out notifications to every node through a redis channel,
+// the node holding the subscriber of the key delivers it.
 type Service struct {
-	subPub *sync.Map
+	rdb     *redis.Client
+	channel string
+	subPub  *sync.Map
 }
 
 type Notify struct {
 	Key    string
 	subPub *sync.Map
-	Ch     chan interface{}
+	Ch     chan string
+}
+
+type message struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
 }
 
-func New() *Service {
-	return &Service{
-		subPub: &sync.Map{},
+func New(rdb *redis.Client, channel string) *Service {
+	s := &Service{
+		rdb:     rdb,
+		channel: channel,
+		subPub:  &sync.Map{},
 	}
+	go s.run(context.Background())
+	return s
 }
 
-func (p *Service) Notify(key string, notify interface{}) {
+func (p *Service) run(ctx context.Context) {
+	sub := p.rdb.Subscribe(ctx, p.channel)
+	defer sub.Close()
+
+	// the channel reconnects by itself when the connection is lost
+	for msg := range sub.Channel() {
+		var m message
+		if err := json.Unmarshal([]byte(msg.Payload), &m); err != nil {
+			logrus.Warnf("psub: invalid message on %s: %s", p.channel, err)
+			continue
+		}
+		p.deliver(m.Key, m.Value)
+	}
+}
+
+func (p *Service) deliver(key, value string) {
 	c, ok := p.subPub.Load(key)
 	if !ok {
 		return
 	}
 	obj := c.(*Notify)
 	select {
-	case obj.Ch <- notify:
+	case obj.Ch <- value:
 	default:
 	}
 }
 
+// Notify publish the value of key to all nodes.
+func (p *Service) Notify(ctx context.Context, key, value string) error {
+	payload, err := json.Marshal(message{Key: key, Value: value})
+	if err != nil {
+		return err
+	}
+	return p.rdb.Publish(ctx, p.channel, payload).Err()
+}
+
 func (p *Service) NewSubscribe(key string) (*Notify, error) {
 	v, ok := p.subPub.Load(key)
 	if ok {
@@ -43,7 +85,7 @@ func (p *Service) NewSubscribe(key string) (*Notify, error) {
 	notify := &Notify{
 		Key:    key,
 		subPub: p.subPub,
-		Ch:     make(chan interface{}, 1),
+		Ch:     make(chan string, 1),
 	}
 	p.subPub.Store(key, notify)
 	return notify, nil
@@ -0,0 +1,101 @@
+package tokengate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/redis/go-redis/v9"
+)
+
+// balanceOfABI ERC-20 and ERC-721 share the call, the tokens of the holder or the count of its NFTs
+const balanceOfABI = `[{"constant":true,"inputs":[{"name":"owner","type":"address"}],"name":"balanceOf","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"}]`
+
+const cachePrefix = "tokengate:"
+
+var ErrNoContract = errors.New("no token contract at the address")
+
+var tokenABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(balanceOfABI))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}()
+
+// Checker read the balances from the chain, the results are cached in redis for a while
+// so a busy feed does not call the node for every post.
+type Checker struct {
+	caller bind.ContractCaller
+	rdb    redis.Cmdable
+	ttl    time.Duration
+}
+
+// New the rdb may be nil, the balances are read every time then
+func New(caller bind.ContractCaller, rdb redis.Cmdable, ttl time.Duration) *Checker {
+	return &Checker{
+		caller: caller,
+		rdb:    rdb,
+		ttl:    ttl,
+	}
+}
+
+func cacheKey(contract, holder common.Address) string {
+	return fmt.Sprintf("%s%s:%s", cachePrefix, strings.ToLower(contract.Hex()), strings.ToLower(holder.Hex()))
+}
+
+// BalanceOf the balance of the holder at the latest block
+func (c *Checker) BalanceOf(ctx context.Context, contract, holder common.Address) (*big.Int, error) {
+	key := cacheKey(contract, holder)
+	if c.rdb != nil {
+		if cached, err := c.rdb.Get(ctx, key).Result(); err == nil {
+			if balance, ok := new(big.Int).SetString(cached, 10); ok {
+				return balance, nil
+			}
+		}
+	}
+
+	data, err := tokenABI.Pack("balanceOf", holder)
+	if err != nil {
+		return nil, err
+	}
+	out, err := c.caller.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: data}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, ErrNoContract
+	}
+	values, err := tokenABI.Unpack("balanceOf", out)
+	if err != nil {
+		return nil, err
+	}
+	balance, ok := values[0].(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("unexpected balanceOf result %T", values[0])
+	}
+
+	if c.rdb != nil {
+		c.rdb.Set(ctx, key, balance.String(), c.ttl)
+	}
+	return balance, nil
+}
+
+// Holds the holder holds at least the threshold of the token
+func (c *Checker) Holds(ctx context.Context, contract, holder string, threshold *big.Int) (bool, error) {
+	if !common.IsHexAddress(contract) || !common.IsHexAddress(holder) {
+		return false, fmt.Errorf("invalid address %s or %s", contract, holder)
+	}
+	balance, err := c.BalanceOf(ctx, common.HexToAddress(contract), common.HexToAddress(holder))
+	if err != nil {
+		return false, err
+	}
+	return balance.Cmp(threshold) >= 0, nil
+}
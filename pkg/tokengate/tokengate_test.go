@@ -0,0 +1,73 @@
+package tokengate
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+// balanceCode the runtime of a token which answers any call with the storage slot
+// of the first argument, balanceOf(holder) returns the balance set in the genesis.
+//
+//	PUSH1 0x04 CALLDATALOAD SLOAD PUSH1 0x00 MSTORE PUSH1 0x20 PUSH1 0x00 RETURN
+var balanceCode = common.FromHex("0x6004355460005260206000f3")
+
+func newSimulated(t *testing.T, token common.Address, balances map[common.Address]int64) *backends.SimulatedBackend {
+	storage := make(map[common.Hash]common.Hash, len(balances))
+	for holder, balance := range balances {
+		storage[common.BytesToHash(holder.Bytes())] = common.BigToHash(big.NewInt(balance))
+	}
+	alloc := core.GenesisAlloc{
+		token: {Balance: big.NewInt(0), Code: balanceCode, Storage: storage},
+	}
+	b := backends.NewSimulatedBackend(alloc, 8000000)
+	t.Cleanup(func() { b.Close() })
+	return b
+}
+
+func TestChecker_Holds(t *testing.T) {
+	token := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	rich := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	poor := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	c := New(newSimulated(t, token, map[common.Address]int64{rich: 100, poor: 5}), nil, 0)
+
+	tests := []struct {
+		holder    common.Address
+		threshold int64
+		want      bool
+	}{
+		{rich, 100, true},
+		{rich, 1, true},
+		{rich, 101, false},
+		{poor, 5, true},
+		{poor, 10, false},
+		{common.HexToAddress("0x0000000000000000000000000000000000000003"), 1, false},
+	}
+	for _, tt := range tests {
+		got, err := c.Holds(context.Background(), token.Hex(), tt.holder.Hex(), big.NewInt(tt.threshold))
+		if err != nil {
+			t.Fatalf("Holds %s: %v", tt.holder.Hex(), err)
+		}
+		if got != tt.want {
+			t.Errorf("holder %s threshold %d = %v, want %v", tt.holder.Hex(), tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func TestChecker_NoContract(t *testing.T) {
+	token := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	c := New(newSimulated(t, token, nil), nil, 0)
+
+	_, err := c.Holds(context.Background(), "0x00000000000000000000000000000000000000bb", token.Hex(), big.NewInt(1))
+	if !errors.Is(err, ErrNoContract) {
+		t.Errorf("want ErrNoContract got %v", err)
+	}
+	if _, err = c.Holds(context.Background(), "not an address", token.Hex(), big.NewInt(1)); err == nil {
+		t.Error("an invalid contract address must fail")
+	}
+}
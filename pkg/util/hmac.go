@@ -0,0 +1,14 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+func EncodeHmacSHA256(value, secret string) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write([]byte(value))
+
+	return hex.EncodeToString(m.Sum(nil))
+}
@@ -0,0 +1,39 @@
+package util
+
+import "testing"
+
+func TestEncodeHmacSHA256(t *testing.T) {
+	type args struct {
+		value  string
+		secret string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "test1",
+			args: args{
+				value:  "The quick brown fox jumps over the lazy dog",
+				secret: "key",
+			},
+			want: "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8",
+		},
+		{
+			name: "test2",
+			args: args{
+				value:  "",
+				secret: "",
+			},
+			want: "b613679a0814d9ec772f95d778c35fc5ff1697c493715653c6c712144292c5ad",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EncodeHmacSHA256(tt.args.value, tt.args.secret); got != tt.want {
+				t.Errorf("EncodeHmacSHA256() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}